@@ -3,12 +3,11 @@ module shutdown-alert
 go 1.25.6
 
 require (
+	github.com/godbus/dbus/v5 v5.2.2
 	github.com/lxn/walk v0.0.0-20210112085537-c389da54e794
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e
 	golang.org/x/sys v0.40.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-)
+require gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
@@ -4,11 +4,16 @@ package app
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/lxn/walk"
 	"github.com/lxn/walk/declarative"
 
 	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/ipc"
+	"shutdown-alert/internal/logger"
+	"shutdown-alert/internal/session"
 	"shutdown-alert/internal/startup"
 	"shutdown-alert/internal/ui"
 	"shutdown-alert/internal/win32"
@@ -19,26 +24,96 @@ type App struct {
 	mainWindow    *walk.MainWindow
 	notifyIcon    *walk.NotifyIcon
 	startupAction *walk.Action
-	userConfig    config.UserConfig
+	snoozeAction  *walk.Action
+	// workTimeAction は今日の勤務時間を表示するトレイメニュー項目です。
+	workTimeAction *walk.Action
+	sessionSource  session.Source
+	flow           *reminderFlow
+	userConfig     config.UserConfig
+	// configPath は再読み込みに使う設定ファイルのパスです。
+	configPath string
+	// sessionStart はテンプレート変数 {{.SessionStart}} に使う時刻です（スタートアップ起動のためログオン時刻とみなします）。
+	sessionStart time.Time
 }
 
 // NewAppは新しいアプリケーションインスタンスを作成します。
-func NewApp(userConfig config.UserConfig) *App {
-	return &App{
-		userConfig: userConfig,
-		// mainWindowとnotifyIconはRun内で初期化されます。
+// configPath は userConfig を読み込んだ設定ファイルのパスで、再読み込みに使用します。
+func NewApp(userConfig config.UserConfig, configPath string) *App {
+	app := &App{
+		configPath:   configPath,
+		sessionStart: time.Now(),
+		// mainWindow、notifyIcon、sessionSourceはRun内で初期化されます。
 	}
+	app.flow = newReminderFlow(app, app, app, false)
+	app.applyConfig(userConfig)
+	return app
+}
+
+// applyConfigは設定をアプリケーションとリマインダーの流れに反映します。
+// UIスレッドから呼び出す必要があります。
+// この関数は副作用（アプリケーションの状態の変更）を持ちます。
+func (app *App) applyConfig(userConfig config.UserConfig) {
+	app.userConfig = userConfig
+	app.flow.resident = userConfig.Lifecycle == config.LifecycleResident
+	app.flow.snoozeInterval = time.Duration(userConfig.SnoozeMinutes) * time.Minute
+	app.flow.reminder = func(trigger string, kind session.EndKind, now time.Time) config.Reminder {
+		return expandedReminder(userConfig, trigger, kind, app.sessionStart, now)
+	}
+	app.flow.skips = userConfig.Skips
+	app.flow.schedules = userConfig.Schedules
+	app.flow.doneToday = userConfig.DoneToday
+	app.flow.enableResume(nil, 0)
+	if userConfig.ResumeSession {
+		app.flow.enableResume(session.NewWindowsInitiator(), time.Duration(userConfig.ResumeGraceSeconds)*time.Second)
+	}
+}
+
+// reloadConfigは設定ファイル（管理者の設定を含む）を読み込み直し、妥当な場合だけ反映します。
+// 読み込めない場合は以前の設定のままエラーを返します。
+// UIスレッドから呼び出す必要があります。
+// この関数は副作用（ファイルの読み取り、アプリケーションの状態の変更）を持ちます。
+func (app *App) reloadConfig() error {
+	layered, err := config.LoadConfig(app.configPath)
+	if err != nil {
+		return err
+	}
+	app.applyConfig(layered.Config)
+	return nil
+}
+
+// reloadAndNotifyは設定ファイルを再読み込みし、結果をトレイの通知で表示します。
+// 失敗した場合は以前の設定のまま、エラーログにも記録します。
+// この関数は副作用（ファイルの読み取り、アプリケーションの状態の変更、UIの表示）を持ちます。
+func (app *App) reloadAndNotify() {
+	if err := app.reloadConfig(); err != nil {
+		logger.LogError("app", "設定ファイルを再読み込みできませんでした", err, map[string]interface{}{
+			"path": app.configPath,
+		})
+		_ = app.notifyIcon.ShowWarning(config.DialogTitle, fmt.Sprintf(config.ConfigReloadErrorMessageFormat, err))
+		return
+	}
+	_ = app.notifyIcon.ShowInfo(config.DialogTitle, config.ConfigReloadedMessage)
+}
+
+// HandleRequestはipc.Handlerの実装で、コマンドをUIスレッドで実行して結果を返します。
+// この関数は副作用（ダイアログ表示の予約、設定の再読み込み）を持ちます。
+func (app *App) HandleRequest(request ipc.Request) ipc.Response {
+	done := make(chan ipc.Response, 1)
+	app.mainWindow.Synchronize(func() {
+		done <- handleControl(app.flow, request, app.reloadConfig, app.configPath)
+	})
+	return <-done
 }
 
 // Runはアプリケーションを初期化して実行します。
 // この関数は副作用（UIの作成、メッセージループの実行）を持ちます。
 func (app *App) Run() error {
-	// WndProcコールバックのためにappインスタンスを保存します。
-	appInstance = app
-
 	// スタートアップパスの自動更新（エラーは無視して続行）
 	_ = startup.UpdateIfNeeded()
 
+	// 勤務時間の計算のため、セッションの開始を履歴に記録します。
+	appendHistory(history.NewRecord(app.sessionStart, history.RecordTypeSessionStart))
+
 	err := app.createMainWindow()
 	if err != nil {
 		return fmt.Errorf("メインウィンドウの作成に失敗しました: %w", err)
@@ -48,8 +123,33 @@ func (app *App) Run() error {
 	if err != nil {
 		return fmt.Errorf("通知アイコンの初期化に失敗しました: %w", err)
 	}
+	stopWorkTime := app.startWorkTimeRefresh()
+	defer stopWorkTime()
+	stopSchedules := app.startScheduleCheck()
+	defer stopSchedules()
+
+	// 設定ファイル（管理者の設定を含む）の変更を監視し、UIスレッドで再読み込みします。
+	stopWatch := config.Watch(config.LayerPaths(config.StandardLayers(app.configPath)), config.ConfigWatchIntervalSeconds*time.Second, func() {
+		app.mainWindow.Synchronize(app.reloadAndNotify)
+	})
+	defer stopWatch()
 
-	app.installWndProcHook()
+	// 後から起動したインスタンスからのコマンドを待ち受けます（失敗してもアプリケーションは続行）。
+	if listener, err := ipc.Listen(); err != nil {
+		logger.LogError("app", "コマンドの待ち受けを開始できませんでした", err, nil)
+	} else {
+		go func() { _ = ipc.Serve(listener, app) }()
+		defer listener.Close()
+	}
+
+	// セッション終了イベントの監視を開始します。
+	app.sessionSource = session.NewWindowsSource(app.mainWindow.Handle(), config.ShutdownBlockMessage, func(message string, err error) {
+		logger.LogError("session", message, err, nil)
+	})
+	err = app.sessionSource.Start(app.flow)
+	if err != nil {
+		return fmt.Errorf("セッションイベントの監視開始に失敗しました: %w", err)
+	}
 
 	// Windowsのイベント待ちループに入ります。
 	// この後の処理はイベントドリブンで行われます。
@@ -57,13 +157,36 @@ func (app *App) Run() error {
 
 	// アプリが終了（=Runが終わる）したら以下をクリーンアップします。
 	// finallyブロックのようなものと考えます。
+	if err := app.sessionSource.Stop(); err != nil {
+		logger.LogError("session", "セッションイベントの監視を停止できませんでした", err, nil)
+	}
 	if app.notifyIcon != nil {
 		_ = app.notifyIcon.Dispose()
 	}
 
-	// グローバルインスタンスをクリアします。
-	appInstance = nil
+	return nil
+}
+
+// Simulateはセッション終了の問い合わせ（lock・sleep の場合はロック・スリープの通知）を受けたときと同じ流れでリマインダーを表示します。
+// 実際のセッション終了は行わず、履歴にも記録しません。応答後（「後で」を選んだ場合も）に戻ります。
+// この関数は副作用（UIの表示、URLの起動）を持ちます。
+func Simulate(userConfig config.UserConfig, kind session.EndKind) error {
+	app := NewApp(userConfig, "")
+	// 応答後にアプリケーションを終了させず、呼び出し元に戻ります。
+	app.flow.resident = true
+	app.flow.record = func(history.Record) {}
+	// 今日済ませたことは参照も記録もしません。
+	app.flow.doneToday = nil
+	app.flow.enableResume(nil, 0)
+
+	if err := app.createMainWindow(); err != nil {
+		return fmt.Errorf("メインウィンドウの作成に失敗しました: %w", err)
+	}
+	defer app.mainWindow.Dispose()
 
+	// Windowsのスリープは保留できず、通知として届きます。
+	app.flow.HandleSessionEvent(simulatedEvent(kind, false))
+	app.flow.cancelSnooze()
 	return nil
 }
 
@@ -81,60 +204,93 @@ func (app *App) createMainWindow() error {
 // この関数は副作用（UIの作成）を持ちます。
 func (app *App) initNotifyIcon() error {
 	var err error
-	app.notifyIcon, app.startupAction, err = ui.InitNotifyIcon(
+	app.notifyIcon, app.startupAction, app.snoozeAction, app.workTimeAction, err = ui.InitNotifyIcon(
 		app.mainWindow,
 		app.showConfirmationDialog,    // テスト用にshowConfirmationDialogを渡す
 		app.toggleStartup,             // スタートアップ登録の切り替え
+		app.flow.cancelSnooze,         // 「後で」による再通知の取り消し
+		app.reloadAndNotify,           // 設定の再読み込み
 		func() { walk.App().Exit(0) }, // 終了
 		startup.IsRegistered(),        // 初期状態
+		app.configPath,                // 使用している設定ファイル
 	)
 	return err
 }
 
-// handleShutdownQueryはシャットダウンが検出されたときに確認ダイアログを表示します。
-// この関数は副作用（UIの表示、アプリケーションの終了の可能性）を持ちます。
-func (app *App) handleShutdownQuery() {
-	err := ui.ShowConfirmationDialog(
-		app.mainWindow,
-		app.userConfig.TargetURL,
-		app.userConfig.DialogWidth,
-		app.userConfig.DialogHeight,
-		app.userConfig.DialogMessage,
-		func() {
-			app.openURL()
-			walk.App().Exit(0)
-		},
-		func() {
-			walk.App().Exit(0)
-		},
-	)
+// startWorkTimeRefreshはトレイの勤務時間の表示を定期的に更新し、停止する関数を返します。
+// walkにはタイマーがないため、ゴルーチンのティッカーからSynchronizeでUIスレッドに処理を戻します。
+// この関数は副作用（ゴルーチンの起動、UIの更新）を持ちます。
+func (app *App) startWorkTimeRefresh() (stop func()) {
+	refresh := func() {
+		if err := ui.SetWorkTime(app.notifyIcon, app.workTimeAction, workTimeSummary(time.Now())); err != nil {
+			logger.LogError("app", "勤務時間の表示を更新できませんでした", err, nil)
+		}
+	}
+	refresh()
 
-	if err != nil {
-		// ダイアログの表示に失敗した場合は、単純に終了します。
-		walk.App().Exit(0)
+	ticker := time.NewTicker(config.WorkTimeRefreshSeconds * time.Second)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				app.mainWindow.Synchronize(refresh)
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
 	}
+}
 
-	// ダイアログをフォアグラウンドに表示します。
-	if app.mainWindow != nil {
-		win32.SetForegroundWindow(app.mainWindow.Handle())
+// startScheduleCheckは schedules の時刻を迎えたかを定期的にUIスレッドで確認し、停止する関数を返します。
+// この関数は副作用（ゴルーチンの起動）を持ちます。
+func (app *App) startScheduleCheck() (stop func()) {
+	app.flow.checkSchedules()
+
+	ticker := time.NewTicker(config.ScheduleCheckSeconds * time.Second)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				app.mainWindow.Synchronize(app.flow.checkSchedules)
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
 	}
 }
 
 // showConfirmationDialogはシャットダウン確認メッセージを表示します（テスト用）。
 // この関数は副作用（UIの表示、アプリケーションの終了の可能性）を持ちます。
 func (app *App) showConfirmationDialog() {
-	_ = ui.ShowConfirmationDialog(
+	app.flow.remind(session.EndKindShutdown)
+}
+
+// showReminderは確認ダイアログを表示し、ユーザーの応答を返します（reminderViewの実装）。
+// この関数は副作用（UIの表示）を持ちます。
+func (app *App) showReminder(kind session.EndKind, reminder config.Reminder) (config.ReminderAction, error) {
+	answer := config.BuiltInAction(config.ActionClose)
+	err := ui.ShowConfirmationDialog(
 		app.mainWindow,
-		app.userConfig.TargetURL,
+		kind,
+		reminder,
+		app.userConfig.Countdown,
 		app.userConfig.DialogWidth,
 		app.userConfig.DialogHeight,
-		app.userConfig.DialogMessage,
-		func() {
-			app.openURL()
-			walk.App().Exit(0)
-		},
-		func() {
-			walk.App().Exit(0)
+		func(action config.ReminderAction, checklist ui.ChecklistState) {
+			answer = action
+			recordChecklist(kind, action, checklist)
 		},
 	)
 
@@ -142,6 +298,34 @@ func (app *App) showConfirmationDialog() {
 	if app.mainWindow != nil {
 		win32.SetForegroundWindow(app.mainWindow.Handle())
 	}
+
+	return answer, err
+}
+
+// finishはアプリケーションを終了します（reminderEffectsの実装）。
+// この関数は副作用（アプリケーションの終了）を持ちます。
+func (app *App) finish() {
+	walk.App().Exit(0)
+}
+
+// showSnoozeは再通知の予定をトレイメニューに反映します（reminderEffectsの実装）。
+// この関数は副作用（UIの更新）を持ちます。
+func (app *App) showSnooze(due time.Time, pending bool) {
+	if app.snoozeAction == nil {
+		return
+	}
+	if err := ui.SetSnoozeAction(app.snoozeAction, due, pending); err != nil {
+		logger.LogError("app", "再通知メニューの更新に失敗しました", err, nil)
+	}
+}
+
+// scheduleはdelay後にUIスレッドでcallbackを呼び出すよう予約します（reminderSchedulerの実装）。
+// この関数は副作用（タイマーの予約）を持ちます。
+func (app *App) schedule(delay time.Duration, callback func()) (cancel func()) {
+	timer := time.AfterFunc(delay, func() {
+		app.mainWindow.Synchronize(callback)
+	})
+	return func() { timer.Stop() }
 }
 
 // toggleStartupはスタートアップ登録を切り替えます。
@@ -185,8 +369,33 @@ func (app *App) toggleStartup() {
 	}
 }
 
-// openURLはShellExecuteを使用して対象のURLを開きます。
+// launchはShellExecuteでURLを開くか、コマンドを起動します（reminderEffectsの実装）。
+// 起動できなかった場合はエラーログに記録し、エラーを返します。
 // この関数は副作用（外部アプリケーションの起動）を持ちます。
-func (app *App) openURL() {
-	win32.ShellExecute(app.mainWindow.Handle(), app.userConfig.TargetURL)
+func (app *App) launch(reminder config.Reminder, action config.ReminderAction) error {
+	targetURL, command := launchTarget(reminder, action)
+	if len(command) == 0 {
+		err := win32.ShellExecute(app.mainWindow.Handle(), targetURL)
+		if err != nil {
+			logger.LogError("app", "URLを開けませんでした", err, nil)
+		}
+		return err
+	}
+
+	err := startCommand(command)
+	if err != nil {
+		logger.LogError("app", "コマンドを起動できませんでした", err, map[string]interface{}{
+			"command": command,
+		})
+	}
+	return err
+}
+
+// showDoneTodayは今日済ませたボタンをトレイの通知で表示します（reminderEffectsの実装）。
+// この関数は副作用（UIの表示）を持ちます。
+func (app *App) showDoneToday(action string) {
+	if app.notifyIcon == nil {
+		return
+	}
+	_ = app.notifyIcon.ShowInfo(config.DialogTitle, fmt.Sprintf(config.DoneTodayToastFormat, config.ActionDisplayName(action)))
 }
@@ -0,0 +1,275 @@
+//go:build linux
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/ipc"
+	"shutdown-alert/internal/logger"
+	"shutdown-alert/internal/session"
+	"shutdown-alert/internal/ui"
+)
+
+// openURLCommandはLinuxでURLを既定のアプリケーションで開くコマンドです。
+const openURLCommand = "xdg-open"
+
+// AppはLinux版のメインアプリケーションを表します。
+type App struct {
+	flow       *reminderFlow
+	userConfig config.UserConfig
+	// configPath は再読み込みに使う設定ファイルのパスです。
+	configPath string
+	// sessionStart はテンプレート変数 {{.SessionStart}} に使う時刻です（アプリケーションの起動時刻）。
+	sessionStart time.Time
+	// mu はlogindのシグナルと「後で」のタイマーが同時にflowを操作しないよう直列化します。
+	// ダイアログの表示中は解放し、その間のコマンドや設定の再読み込みを待たせません（flow.asking で重ねての表示を防ぎます）。
+	mu sync.Mutex
+}
+
+// NewAppは新しいアプリケーションインスタンスを作成します。
+// configPath は userConfig を読み込んだ設定ファイルのパスで、再読み込みに使用します。
+func NewApp(userConfig config.UserConfig, configPath string) *App {
+	app := &App{
+		configPath:   configPath,
+		sessionStart: time.Now(),
+	}
+	// Linux版はセッション終了をロックの解放で続行させるため、常に常駐を続けます。
+	app.flow = newReminderFlow(app, app, app, true)
+	app.applyConfig(userConfig)
+	return app
+}
+
+// applyConfigは設定をアプリケーションとリマインダーの流れに反映します。
+// mu を保持して呼び出す必要があります。
+// この関数は副作用（アプリケーションの状態の変更）を持ちます。
+func (app *App) applyConfig(userConfig config.UserConfig) {
+	app.userConfig = userConfig
+	app.flow.snoozeInterval = time.Duration(userConfig.SnoozeMinutes) * time.Minute
+	app.flow.reminder = func(trigger string, kind session.EndKind, now time.Time) config.Reminder {
+		return expandedReminder(userConfig, trigger, kind, app.sessionStart, now)
+	}
+	app.flow.skips = userConfig.Skips
+	app.flow.schedules = userConfig.Schedules
+	app.flow.doneToday = userConfig.DoneToday
+}
+
+// reloadConfigは設定ファイル（管理者の設定を含む）を読み込み直し、妥当な場合だけ反映します。
+// 読み込めない場合は以前の設定のままエラーを返します。
+// mu を保持して呼び出す必要があります。
+// この関数は副作用（ファイルの読み取り、アプリケーションの状態の変更）を持ちます。
+func (app *App) reloadConfig() error {
+	layered, err := config.LoadConfig(app.configPath)
+	if err != nil {
+		return err
+	}
+	app.applyConfig(layered.Config)
+	return nil
+}
+
+// HandleRequestはipc.Handlerの実装で、HandleSessionEventと直列化してコマンドを実行します。
+// この関数は副作用（ダイアログ表示の予約、設定の再読み込み）を持ちます。
+func (app *App) HandleRequest(request ipc.Request) ipc.Response {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return handleControl(app.flow, request, app.reloadConfig, app.configPath)
+}
+
+// Runはsystemd-logindに接続し、シャットダウン／スリープを待ち受けます。
+// この関数は副作用（D-Bus接続、UIの表示）を持ちます。
+func (app *App) Run() error {
+	// 後から起動したインスタンスからのコマンドを待ち受けます。
+	// Linux版にはミューテックスがないため、待ち受けられない場合は2重起動とみなします。
+	listener, err := ipc.Listen()
+	if errors.Is(err, ipc.ErrAlreadyRunning) {
+		return err
+	}
+	if err != nil {
+		logger.LogError("app", "コマンドの待ち受けを開始できませんでした", err, nil)
+	} else {
+		go func() { _ = ipc.Serve(listener, app) }()
+		defer listener.Close()
+	}
+
+	// 設定ファイル（管理者の設定を含む）の変更を監視し、HandleSessionEventと直列化して再読み込みします。
+	stopWatch := config.Watch(config.LayerPaths(config.StandardLayers(app.configPath)), config.ConfigWatchIntervalSeconds*time.Second, func() {
+		app.mu.Lock()
+		defer app.mu.Unlock()
+		if err := app.reloadConfig(); err != nil {
+			log.Printf(config.ConfigReloadErrorMessageFormat, err)
+			logger.LogError("app", "設定ファイルを再読み込みできませんでした", err, map[string]interface{}{
+				"path": app.configPath,
+			})
+			return
+		}
+		log.Print(config.ConfigReloadedMessage)
+	})
+	defer stopWatch()
+
+	// 勤務時間の計算のため、セッションの開始を履歴に記録します。
+	appendHistory(history.NewRecord(app.sessionStart, history.RecordTypeSessionStart))
+
+	stopSchedules := app.startScheduleCheck()
+	defer stopSchedules()
+
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("システムバスへの接続に失敗しました: %w", err)
+	}
+	defer conn.Close()
+
+	source := session.NewLogindSource(conn, config.DialogTitle, config.ShutdownBlockMessage)
+	if err := source.Start(app); err != nil {
+		return fmt.Errorf("logindの監視開始に失敗しました: %w", err)
+	}
+	defer source.Stop()
+
+	return source.Run()
+}
+
+// startScheduleCheckは schedules の時刻を迎えたかを定期的に確認し、停止する関数を返します。
+// 確認はHandleSessionEventと直列化して行います。
+// この関数は副作用（ゴルーチンの起動）を持ちます。
+func (app *App) startScheduleCheck() (stop func()) {
+	check := func() {
+		app.mu.Lock()
+		defer app.mu.Unlock()
+		app.flow.checkSchedules()
+	}
+	check()
+
+	ticker := time.NewTicker(config.ScheduleCheckSeconds * time.Second)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				check()
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// Simulateはセッション終了の問い合わせ（lock の場合はロックの通知）を受けたときと同じ流れでリマインダーを表示します。
+// 実際のセッション終了は行わず、履歴にも記録しません。応答後（「後で」を選んだ場合も）に戻ります。
+// この関数は副作用（UIの表示、URLの起動）を持ちます。
+func Simulate(userConfig config.UserConfig, kind session.EndKind) error {
+	app := NewApp(userConfig, "")
+	app.flow.record = func(history.Record) {}
+	// 今日済ませたことは参照も記録もしません。
+	app.flow.doneToday = nil
+	// Linuxのスリープはlogindのインヒビターロックで保留し、問い合わせとして届きます。
+	app.HandleSessionEvent(simulatedEvent(kind, true))
+
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.flow.cancelSnooze()
+	return nil
+}
+
+// HandleSessionEventはsession.Handlerの実装で、flowへの呼び出しを直列化します。
+// この関数は副作用（UIの表示、URLの起動）を持ちます。
+func (app *App) HandleSessionEvent(event session.Event) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.flow.HandleSessionEvent(event)
+}
+
+// ShouldHoldはsession.Holderの実装で、flowへの呼び出しを直列化します。
+// 応答済み・ルールで表示しない・今日済ませたシャットダウン／スリープは、ロックをすぐ解放して進めます。
+// この関数は副作用（現在時刻の取得、ファイルの読み取り、通知の表示）を持ちます。
+func (app *App) ShouldHold(kind session.EndKind) bool {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return app.flow.ShouldHold(kind)
+}
+
+// showReminderはzenityで確認ダイアログを表示し、ユーザーの応答を返します（reminderViewの実装）。
+// mu を保持して呼び出す必要があります。ダイアログの表示中は mu を解放し、閉じた後に取得し直します。
+// この関数は副作用（UIの表示、mu の一時的な解放）を持ちます。
+func (app *App) showReminder(kind session.EndKind, reminder config.Reminder) (config.ReminderAction, error) {
+	userConfig := app.userConfig
+
+	app.mu.Unlock()
+	answer := config.BuiltInAction(config.ActionClose)
+	err := ui.ShowConfirmationDialog(
+		kind,
+		reminder,
+		userConfig.Countdown,
+		userConfig.DialogWidth,
+		userConfig.DialogHeight,
+		func(action config.ReminderAction, checklist ui.ChecklistState) {
+			answer = action
+			recordChecklist(kind, action, checklist)
+		},
+	)
+	app.mu.Lock()
+	if err != nil {
+		logger.LogError("app", "確認ダイアログの表示に失敗しました", err, map[string]interface{}{
+			"kind": kind.String(),
+		})
+	}
+
+	return answer, err
+}
+
+// finishはアプリケーション終了時の後処理です（reminderEffectsの実装）。
+// Linux版ではプロセスを終了せず、ロックの解放でlogindに処理の続行を委ねます。
+func (app *App) finish() {}
+
+// showSnoozeは再通知の予定の表示です（reminderEffectsの実装）。
+// Linux版にはトレイがないため何もしません。
+func (app *App) showSnooze(due time.Time, pending bool) {}
+
+// scheduleはdelay後にcallbackを呼び出すよう予約します（reminderSchedulerの実装）。
+// callbackはHandleSessionEventと直列化して呼び出します。
+// この関数は副作用（タイマーの予約）を持ちます。
+func (app *App) schedule(delay time.Duration, callback func()) (cancel func()) {
+	timer := time.AfterFunc(delay, func() {
+		app.mu.Lock()
+		defer app.mu.Unlock()
+		callback()
+	})
+	return func() { timer.Stop() }
+}
+
+// launchはxdg-openでURLを開くか、コマンドを起動します（reminderEffectsの実装）。
+// 起動できなかった場合はエラーログに記録し、エラーを返します。
+// この関数は副作用（外部アプリケーションの起動）を持ちます。
+func (app *App) launch(reminder config.Reminder, action config.ReminderAction) error {
+	targetURL, command := launchTarget(reminder, action)
+	if len(command) == 0 {
+		command = []string{openURLCommand, targetURL}
+	}
+
+	err := startCommand(command)
+	if err != nil {
+		logger.LogError("app", "URLまたはコマンドを起動できませんでした", err, map[string]interface{}{
+			"command": command,
+		})
+	}
+	return err
+}
+
+// showDoneTodayは今日済ませたボタンをzenityの通知で表示します（reminderEffectsの実装）。
+// この関数は副作用（外部コマンドの実行）を持ちます。
+func (app *App) showDoneToday(action string) {
+	if err := ui.ShowNotification(fmt.Sprintf(config.DoneTodayToastFormat, config.ActionDisplayName(action))); err != nil {
+		logger.LogError("app", "通知を表示できませんでした", err, nil)
+	}
+}
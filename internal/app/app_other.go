@@ -0,0 +1,16 @@
+//go:build !windows && !linux
+
+package app
+
+import (
+	"errors"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+// Simulateは、セッション終了の監視とダイアログの表示に対応していないOSのため、エラーを返します。
+// この関数は純粋関数です。
+func Simulate(userConfig config.UserConfig, kind session.EndKind) error {
+	return errors.New("このOSでは確認ダイアログを表示できません")
+}
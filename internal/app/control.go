@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/ipc"
+	"shutdown-alert/internal/session"
+)
+
+// handleControlは後から起動したインスタンスから受け取ったコマンドを実行します。
+// リマインダーの流れと同じスレッド（Windowsでは UI スレッド）から呼び出す必要があります。
+// ダイアログはコマンドの応答を返した後に表示するよう予約します（表示中は応答を返せないため）。
+// コマンドによるダイアログでは、lifecycle が exit の場合もアプリケーションを終了しません。
+// この関数は副作用（ダイアログ表示の予約、設定の再読み込み）を持ちます。
+func handleControl(flow *reminderFlow, request ipc.Request, reload func() error, configPath string) ipc.Response {
+	switch request.Command {
+	case ipc.CommandShow:
+		flow.scheduler.schedule(0, func() { flow.remindOnRequest(session.EndKindShutdown) })
+		return ipc.Success("確認ダイアログを表示します")
+
+	case ipc.CommandReload:
+		if err := reload(); err != nil {
+			return ipc.Failure(fmt.Errorf("設定ファイルを再読み込みできませんでした（以前の設定を使用します）: %w", err))
+		}
+		return ipc.Success("設定ファイルを再読み込みしました: " + configPath)
+
+	case ipc.CommandSnooze:
+		delay, err := parseSnoozeDelay(request.Args)
+		if err != nil {
+			return ipc.Failure(err)
+		}
+		flow.postponeFor(session.EndKindShutdown, delay, flow.remindOnRequest)
+		return ipc.Success(fmt.Sprintf("%s に確認ダイアログを表示します", flow.snooze.due.Format(config.TemplateTimeFormat)))
+
+	case ipc.CommandStatus:
+		return ipc.Success(statusMessage(os.Getpid(), configPath, flow.snooze, workTimeSummary(flow.now())))
+	}
+
+	return ipc.Failure(fmt.Errorf("未知のコマンドです: %s", request.Command))
+}
+
+// parseSnoozeDelayは snooze コマンドの引数（30m のような間隔、または分数）を解釈します。
+// この関数は純粋関数です。
+func parseSnoozeDelay(args []string) (time.Duration, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("間隔を1つ指定してください（例: 30m）")
+	}
+
+	delay, err := time.ParseDuration(args[0])
+	if err != nil {
+		minutes, atoiErr := strconv.Atoi(args[0])
+		if atoiErr != nil {
+			return 0, fmt.Errorf("間隔の形式が正しくありません（例: 30m）: %s", args[0])
+		}
+		delay = time.Duration(minutes) * time.Minute
+	}
+
+	if delay < time.Minute || delay > config.MaxSnoozeMinutes*time.Minute {
+		return 0, fmt.Errorf("間隔は1分から%d分の範囲で指定してください: %s", config.MaxSnoozeMinutes, args[0])
+	}
+	return delay, nil
+}
+
+// statusMessageは status コマンドに返す、起動中のインスタンスの状態を返します。
+// この関数は純粋関数です。
+func statusMessage(pid int, configPath string, snooze pendingSnooze, workTime string) string {
+	due := "なし"
+	if snooze.cancel != nil {
+		due = snooze.due.Format(config.TemplateTimeFormat)
+	}
+
+	return strings.Join([]string{
+		fmt.Sprintf("%s は起動しています（PID %d）", config.DialogTitle, pid),
+		"設定ファイル: " + configPath,
+		"再通知: " + due,
+		workTime,
+	}, "\n")
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"testing"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/ipc"
+	"shutdown-alert/internal/session"
+)
+
+func TestHandleControlDoesNotFinish(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   ipc.Request
+		answers   []config.ReminderAction
+		wantShown int
+	}{
+		{
+			name:      "show",
+			request:   ipc.Request{Command: ipc.CommandShow},
+			wantShown: 1,
+		},
+		{
+			name:      "snooze",
+			request:   ipc.Request{Command: ipc.CommandSnooze, Args: []string{"30m"}},
+			wantShown: 1,
+		},
+		{
+			name:      "show で「後で」を選ぶと再表示",
+			request:   ipc.Request{Command: ipc.CommandShow},
+			answers:   []config.ReminderAction{config.BuiltInAction(config.ActionSnooze)},
+			wantShown: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// lifecycle: exit でも、コマンドによる表示ではアプリケーションを終了しません。
+			flow := newTestFlow(false, tt.answers...)
+
+			response := handleControl(flow.reminderFlow, tt.request, func() error { return nil }, "config.yaml")
+			if !response.OK {
+				t.Fatalf("handleControl() = %+v", response)
+			}
+			for len(flow.scheduler.pending) > 0 && len(flow.view.shown) < tt.wantShown {
+				flow.scheduler.fire()
+			}
+
+			if got := len(flow.view.shown); got != tt.wantShown {
+				t.Errorf("shown = %d, want %d", got, tt.wantShown)
+			}
+			if flow.effects.finished != 0 {
+				t.Errorf("finish() called %d times, want 0", flow.effects.finished)
+			}
+		})
+	}
+}
+
+func TestRemindOnRequestWhileAsking(t *testing.T) {
+	flow := newTestFlow(true)
+	flow.asking = true
+
+	flow.remindOnRequest(session.EndKindShutdown)
+
+	if len(flow.view.shown) != 0 {
+		t.Errorf("shown = %v, want none while another dialog is open", flow.view.shown)
+	}
+}
+
+func TestParseSnoozeDelay(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: []string{"30m"}, want: "30m0s"},
+		{args: []string{"15"}, want: "15m0s"},
+		{args: []string{"30s"}, wantErr: true},
+		{args: []string{"abc"}, wantErr: true},
+		{args: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSnoozeDelay(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSnoozeDelay(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("parseSnoozeDelay(%v) = %v, want %s", tt.args, got, tt.want)
+		}
+	}
+}
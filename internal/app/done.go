@@ -0,0 +1,62 @@
+package app
+
+import (
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/daystate"
+	"shutdown-alert/internal/logger"
+)
+
+// suppressedTodayは今日 done_today のボタンを選んでいる場合に表示を抑え、抑えたかどうかを返します。
+// 動作が通知（DoneTodayToast）の場合は、確認ダイアログの代わりに通知を表示します。
+// この関数は副作用（ファイルの読み取り、通知の表示）を持ちます。
+func (flow *reminderFlow) suppressedToday() bool {
+	if len(flow.doneToday) == 0 {
+		return false
+	}
+
+	mode, action := config.DoneTodayMode(flow.doneToday, flow.loadDone(flow.now()))
+	if mode == config.DoneTodayToast {
+		flow.effects.showDoneToday(action)
+	}
+	return mode != ""
+}
+
+// recordDoneは選ばれたボタンが done_today に指定されている場合、今日済ませたことを記録します。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func (flow *reminderFlow) recordDone(answer config.ReminderAction) {
+	if _, ok := flow.doneToday[answer.ID()]; !ok {
+		return
+	}
+	flow.markDone(flow.now(), answer.ID())
+}
+
+// loadDoneTodayは今日選んだ done_today のボタンを返します。読み込めない場合はエラーログに記録し、何も選んでいないものとします。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func loadDoneToday(now time.Time) []string {
+	store, err := daystate.DefaultStore()
+	if err == nil {
+		var state daystate.State
+		state, err = store.Load(now)
+		if err == nil {
+			return state.Actions
+		}
+	}
+	logger.LogError("app", "今日済ませたことを読み込めませんでした", err, nil)
+	return nil
+}
+
+// markDoneTodayは今日 action を選んだことを保存します。失敗した場合はエラーログに記録して続行します。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func markDoneToday(now time.Time, action string) {
+	store, err := daystate.DefaultStore()
+	if err == nil {
+		err = store.Mark(now, action)
+	}
+	if err != nil {
+		logger.LogError("app", "今日済ませたことを記録できませんでした", err, map[string]interface{}{
+			"action": action,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+func TestDoneToday(t *testing.T) {
+	report := config.ReminderAction{Label: "日報", URL: "https://report.example.com"}
+	shutdown := session.Event{Type: session.EventQuery, Kind: session.EndKindShutdown}
+
+	tests := []struct {
+		name       string
+		doneToday  map[string]string
+		answers    []config.ReminderAction
+		launchErr  error
+		wantShown  int
+		wantDone   []string
+		wantHold   bool
+		wantToasts []string
+	}{
+		{
+			name:      "done_today なし",
+			answers:   []config.ReminderAction{config.BuiltInAction(config.ActionOpen)},
+			wantShown: 2,
+			wantHold:  true,
+		},
+		{
+			name:      "skip は以降の表示を抑える",
+			doneToday: map[string]string{config.ActionOpen: config.DoneTodaySkip},
+			answers:   []config.ReminderAction{config.BuiltInAction(config.ActionOpen)},
+			wantShown: 1,
+			wantDone:  []string{config.ActionOpen},
+		},
+		{
+			name:       "toast は通知だけ表示",
+			doneToday:  map[string]string{"日報": config.DoneTodayToast},
+			answers:    []config.ReminderAction{report},
+			wantShown:  1,
+			wantDone:   []string{"日報"},
+			wantToasts: []string{"日報"},
+		},
+		{
+			name:      "起動に失敗したら記録しない",
+			doneToday: map[string]string{config.ActionOpen: config.DoneTodaySkip},
+			answers:   []config.ReminderAction{config.BuiltInAction(config.ActionOpen)},
+			launchErr: errors.New("xdg-open がありません"),
+			wantShown: 2,
+			wantHold:  true,
+		},
+		{
+			name:      "指定していないボタンは記録しない",
+			doneToday: map[string]string{config.ActionOpen: config.DoneTodaySkip},
+			wantShown: 2,
+			wantHold:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newTestFlow(true, tt.answers...)
+			flow.doneToday = tt.doneToday
+			flow.effects.launchErr = tt.launchErr
+
+			flow.HandleSessionEvent(shutdown)
+			flow.HandleSessionEvent(session.Event{Type: session.EventCancel})
+
+			// 2回目のセッション終了：Source と同じく、保留する場合だけ問い合わせます。
+			hold := flow.ShouldHold(session.EndKindShutdown)
+			if hold != tt.wantHold {
+				t.Errorf("ShouldHold() = %v, want %v", hold, tt.wantHold)
+			}
+			if hold {
+				flow.HandleSessionEvent(shutdown)
+			}
+
+			if got := len(flow.view.shown); got != tt.wantShown {
+				t.Errorf("shown = %d, want %d", got, tt.wantShown)
+			}
+			if !slices.Equal(flow.done, tt.wantDone) {
+				t.Errorf("done = %v, want %v", flow.done, tt.wantDone)
+			}
+			if !reflect.DeepEqual(flow.effects.doneToday, tt.wantToasts) {
+				t.Errorf("toasts = %v, want %v", flow.effects.doneToday, tt.wantToasts)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/session"
+)
+
+// testNow はテストで使う現在時刻です。
+var testNow = time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+
+// shownReminder は表示したリマインダーの終了理由とメッセージです。
+type shownReminder struct {
+	kind    session.EndKind
+	message string
+}
+
+// fakeView は決まった応答を順に返す reminderView です。
+type fakeView struct {
+	// answers は表示のたびに先頭から返す応答です。尽きた場合は「閉じる」を返します。
+	answers []config.ReminderAction
+	// err は表示の失敗を表します。
+	err   error
+	shown []shownReminder
+}
+
+// showReminder は表示を記録し、次の応答を返します。
+func (view *fakeView) showReminder(kind session.EndKind, reminder config.Reminder) (config.ReminderAction, error) {
+	view.shown = append(view.shown, shownReminder{kind: kind, message: reminder.DialogMessage})
+	if view.err != nil {
+		return config.ReminderAction{}, view.err
+	}
+	if len(view.answers) == 0 {
+		return config.BuiltInAction(config.ActionClose), nil
+	}
+	answer := view.answers[0]
+	view.answers = view.answers[1:]
+	return answer, nil
+}
+
+// fakeEffects は副作用を記録する reminderEffects です。
+type fakeEffects struct {
+	// launchErr は起動の失敗を表します。
+	launchErr error
+	launched  []config.ReminderAction
+	// launchedFrom は起動したボタンごとの、ボタンを表示したリマインダーです。
+	launchedFrom []config.Reminder
+	finished     int
+	doneToday    []string
+}
+
+// launch は起動したボタンを記録します。
+func (effects *fakeEffects) launch(reminder config.Reminder, action config.ReminderAction) error {
+	effects.launched = append(effects.launched, action)
+	effects.launchedFrom = append(effects.launchedFrom, reminder)
+	return effects.launchErr
+}
+
+// finish は後処理の回数を数えます。
+func (effects *fakeEffects) finish() { effects.finished++ }
+
+// showSnooze は何もしません。
+func (effects *fakeEffects) showSnooze(due time.Time, pending bool) {}
+
+// showDoneToday は通知したボタンを記録します。
+func (effects *fakeEffects) showDoneToday(action string) {
+	effects.doneToday = append(effects.doneToday, action)
+}
+
+// fakeScheduler は予約を記録し、テストから発火させる reminderScheduler です。
+type fakeScheduler struct {
+	pending []func()
+	delays  []time.Duration
+}
+
+// schedule は callback を記録します。取り消した予約は発火しません。
+func (scheduler *fakeScheduler) schedule(delay time.Duration, callback func()) (cancel func()) {
+	canceled := false
+	scheduler.delays = append(scheduler.delays, delay)
+	scheduler.pending = append(scheduler.pending, func() {
+		if !canceled {
+			callback()
+		}
+	})
+	return func() { canceled = true }
+}
+
+// fire は予約をすべて発火させます。発火中の予約は次の呼び出しまで発火しません。
+func (scheduler *fakeScheduler) fire() {
+	pending := scheduler.pending
+	scheduler.pending = nil
+	for _, callback := range pending {
+		callback()
+	}
+}
+
+// fakeInitiator は開始したセッション終了を記録する session.Initiator です。
+type fakeInitiator struct {
+	initiated []session.EndKind
+}
+
+// Initiate は kind を記録します。
+func (initiator *fakeInitiator) Initiate(kind session.EndKind) error {
+	initiator.initiated = append(initiator.initiated, kind)
+	return nil
+}
+
+// testFlow はテスト用の reminderFlow と、その偽の実装です。
+type testFlow struct {
+	*reminderFlow
+	view      *fakeView
+	effects   *fakeEffects
+	scheduler *fakeScheduler
+	records   []history.Record
+	done      []string
+}
+
+// newTestFlow はファイルや時計に触れない reminderFlow を作成します。
+func newTestFlow(resident bool, answers ...config.ReminderAction) *testFlow {
+	test := &testFlow{
+		view:      &fakeView{answers: answers},
+		effects:   &fakeEffects{},
+		scheduler: &fakeScheduler{},
+	}
+	flow := newReminderFlow(test.view, test.effects, test.scheduler, resident)
+	flow.now = func() time.Time { return testNow }
+	flow.reminder = config.DefaultUserConfig().ReminderAt
+	flow.snoozeInterval = 10 * time.Minute
+	flow.record = func(record history.Record) { test.records = append(test.records, record) }
+	flow.workStart = func(now time.Time) time.Time { return now }
+	flow.loadDone = func(time.Time) []string { return test.done }
+	flow.markDone = func(_ time.Time, action string) { test.done = append(test.done, action) }
+	test.reminderFlow = flow
+	return test
+}
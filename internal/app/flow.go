@@ -0,0 +1,262 @@
+package app
+
+import (
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/logger"
+	"shutdown-alert/internal/session"
+)
+
+// reminderViewはリマインダーダイアログを表示します（OSごとのUI実装）。
+type reminderView interface {
+	// showReminderは reminder（テンプレートを展開したもの）のダイアログを表示し、ユーザーが選んだボタンを返します。
+	showReminder(kind session.EndKind, reminder config.Reminder) (config.ReminderAction, error)
+}
+
+// reminderEffectsはリマインダーの結果として行う副作用を表します（OSごとの実装）。
+type reminderEffects interface {
+	// launchはボタンに対応するURLまたはコマンドを起動します。
+	// 組み込みの「開く」の場合は、ダイアログに表示した reminder の target_url を開きます。起動できなかった場合はエラーを返します。
+	launch(reminder config.Reminder, action config.ReminderAction) error
+	// finishはアプリケーションを終了するときの後処理を行います。
+	finish()
+	// showSnoozeは「後で」による再表示の予定が変わったことを表示します。
+	// pending が false の場合、予定はありません（due は使用しません）。
+	showSnooze(due time.Time, pending bool)
+	// showDoneTodayは今日 action（ReminderAction.ID）を済ませているため、確認ダイアログの代わりに通知を表示します。
+	showDoneToday(action string)
+}
+
+// reminderFlowはセッションイベントを受け取り、リマインダーの流れを駆動します。
+// OSに依存しないため、偽のSource・View・Effectsを渡せばどのOSでもテストできます。
+type reminderFlow struct {
+	view    reminderView
+	effects reminderEffects
+	// initiator は中断したセッション終了を再開します。nil の場合は再開しません。
+	initiator session.Initiator
+	// resumeGrace はURLを開いてから再開するまでの猶予です。猶予の間は scheduler で待ちます。
+	resumeGrace time.Duration
+	// resident が true の場合、応答後も常駐を続け、セッションが実際に終了したときに後処理を行います。
+	resident bool
+	// armed は次のセッション終了でリマインダーを表示するかどうかです。
+	// 応答後に解除し、セッション終了がキャンセルされたら再設定するためミュータブルです。
+	armed bool
+	// scheduler は「後で」が選ばれたときの再表示を予約します。
+	scheduler reminderScheduler
+	// snoozeInterval は「後で」を選んでから再表示するまでの間隔です。
+	snoozeInterval time.Duration
+	// now は現在時刻を返します（テストでは偽の時計に差し替えます）。
+	now func() time.Time
+	// snooze は予約中の再表示です。予約の追加・取り消しで変わるためミュータブルです。
+	snooze pendingSnooze
+	// record はリマインダーの表示と応答を履歴に記録します（テストでは偽の実装に差し替えます）。
+	record func(history.Record)
+	// reminder は表示のきっかけ・終了理由・現在時刻に対応する、テンプレートを展開したリマインダーを返します。
+	reminder func(trigger string, kind session.EndKind, now time.Time) config.Reminder
+	// skips はルールによりリマインダーを表示しないきっかけかを返します。nil の場合は常に表示します。
+	skips func(trigger string, kind session.EndKind, now time.Time) bool
+	// schedules はセッション終了を待たずにリマインダーを表示する時刻です。
+	schedules []config.Schedule
+	// scheduleChecked は schedules の時刻を最後に確認した時刻です。確認のたびに進むためミュータブルです。
+	scheduleChecked time.Time
+	// workStart は今日の勤務の開始時刻を返します（テストでは偽の実装に差し替えます）。
+	workStart func(now time.Time) time.Time
+	// asking はダイアログを表示している間 true です。表示中に別のダイアログを重ねないために使います。
+	asking bool
+	// doneToday は done_today（ボタンごとの、選んだ日のそれ以降の表示を抑える動作）です。空の場合は抑えません。
+	doneToday map[string]string
+	// loadDone は今日選んだ done_today のボタンを返します（テストでは偽の実装に差し替えます）。
+	loadDone func(now time.Time) []string
+	// markDone は今日 done_today のボタンを選んだことを保存します（テストでは偽の実装に差し替えます）。
+	markDone func(now time.Time, action string)
+}
+
+// newReminderFlowは新しいreminderFlowを作成します。
+// resident が false の場合は、リマインダーに応答するたびに後処理（アプリケーションの終了）を行います。
+func newReminderFlow(view reminderView, effects reminderEffects, scheduler reminderScheduler, resident bool) *reminderFlow {
+	return &reminderFlow{
+		view:      view,
+		effects:   effects,
+		resident:  resident,
+		armed:     true,
+		scheduler: scheduler,
+		now:       time.Now,
+		record:    appendHistory,
+		workStart: workStart,
+		loadDone:  loadDoneToday,
+		markDone:  markDoneToday,
+	}
+}
+
+// enableResumeは応答後に中断したセッション終了を再開するように設定します。
+// grace はURLを開いた場合にのみ待ちます。
+func (flow *reminderFlow) enableResume(initiator session.Initiator, grace time.Duration) {
+	flow.initiator = initiator
+	flow.resumeGrace = grace
+}
+
+// ShouldHoldはsession.Holderの実装です。
+// 応答済みのセッション終了と、ルールでリマインダーを表示しないセッション終了は保留せずに通します。
+// 今日 done_today のボタンを選んでいる場合も保留せず、動作が通知の場合はここで通知を表示します
+// （保留しないセッション終了には EventQuery が届かないため）。
+// この関数は副作用（現在時刻の取得、ファイルの読み取り、通知の表示）を持ちます。
+func (flow *reminderFlow) ShouldHold(kind session.EndKind) bool {
+	if !flow.armed || flow.skipped(config.TriggerForEndKind(kind), kind) {
+		return false
+	}
+	return !flow.suppressedToday()
+}
+
+// skippedはルールにより、現在の表示のきっかけでリマインダーを表示しないかを返します。
+// この関数は副作用（現在時刻の取得）を持ちます。
+func (flow *reminderFlow) skipped(trigger string, kind session.EndKind) bool {
+	return flow.skips != nil && flow.skips(trigger, kind, flow.now())
+}
+
+// HandleSessionEventはsession.Handlerの実装です。
+// この関数は副作用（UIの表示、URLの起動、アプリケーションの終了）を持ちます。
+func (flow *reminderFlow) HandleSessionEvent(event session.Event) {
+	switch event.Type {
+	case session.EventQuery:
+		if !flow.armed || flow.skipped(config.TriggerForEndKind(event.Kind), event.Kind) || flow.suppressedToday() {
+			return
+		}
+		flow.query(event.Kind)
+
+	case session.EventNotice:
+		// ロック・スリープは保留できないため、応答を待たずに進みます。ダイアログはロック解除・復帰後に目にします。
+		if flow.asking {
+			return
+		}
+		flow.notify(config.TriggerForEndKind(event.Kind), event.Kind)
+
+	case session.EventCancel:
+		// セッション終了がキャンセルされたので、次回に備えてリマインダーを再設定します。
+		flow.armed = true
+
+	case session.EventEnd:
+		flow.effects.finish()
+	}
+}
+
+// queryはセッション終了の問い合わせに対してリマインダーを表示し、応答に応じた処理を行います。
+// 「後で」が選ばれた場合はセッション終了を中断したまま、間隔をおいて問い合わせをやり直します。
+// この関数は副作用（UIの表示、URLの起動、セッション終了の開始、アプリケーションの終了）を持ちます。
+func (flow *reminderFlow) query(kind session.EndKind) {
+	// 新しい問い合わせが来たら、予約中の再表示は不要です。
+	flow.cancelSnooze()
+
+	answer, err := flow.ask(config.TriggerForEndKind(kind), kind)
+	if err == nil && answer.Name == config.ActionSnooze {
+		flow.postpone(kind, flow.query)
+		return
+	}
+
+	// 応答後はもう一度セッション終了を操作されても保留しません。
+	flow.armed = false
+	if err != nil {
+		flow.finishUnlessResident()
+		return
+	}
+	flow.resume(kind, answer)
+}
+
+// remindはリマインダーダイアログを表示し、応答に応じた処理を行います。
+// セッション終了を伴わない（トレイメニューからの）表示のため、再開は行いません。
+// この関数は副作用（UIの表示、URLの起動、アプリケーションの終了）を持ちます。
+func (flow *reminderFlow) remind(kind session.EndKind) {
+	// ダイアログの表示に失敗した場合も、単純に後処理へ進みます。
+	answer, err := flow.ask(config.TriggerSessionEnd, kind)
+	if err == nil && answer.Name == config.ActionSnooze {
+		flow.postpone(kind, flow.remind)
+		return
+	}
+	flow.finishUnlessResident()
+}
+
+// remindOnRequestは後から起動したインスタンスのコマンドによりリマインダーダイアログを表示し、応答に応じた処理を行います。
+// 起動中のアプリケーションへの依頼のため、lifecycle が exit の場合も応答後に後処理（アプリケーションの終了）は行いません。
+// ダイアログの表示中に依頼された場合は重ねて表示しません。
+// この関数は副作用（UIの表示、URLの起動）を持ちます。
+func (flow *reminderFlow) remindOnRequest(kind session.EndKind) {
+	if flow.asking {
+		return
+	}
+	answer, err := flow.ask(config.TriggerSessionEnd, kind)
+	if err == nil && answer.Name == config.ActionSnooze {
+		flow.postpone(kind, flow.remindOnRequest)
+	}
+}
+
+// finishUnlessResidentは常駐モードでない場合に後処理（アプリケーションの終了）を行います。
+// この関数は副作用（アプリケーションの終了）を持ちます。
+func (flow *reminderFlow) finishUnlessResident() {
+	if !flow.resident {
+		flow.effects.finish()
+	}
+}
+
+// askはリマインダーダイアログを表示し、URLやコマンドのボタンが選ばれた場合は起動します。
+// リマインダーは表示する時点で一度だけ展開し、表示した内容のURLを起動します。
+// 表示のきっかけによらず、表示（query）と応答（answer）を履歴に記録します。応答の opened は起動の結果です。
+// 選ばれたボタンが done_today に指定されている場合は、起動に成功したときだけ今日済ませたことを記録します。
+// この関数は副作用（UIの表示、URL・コマンドの起動、ファイルへの書き込み）を持ちます。
+func (flow *reminderFlow) ask(trigger string, kind session.EndKind) (config.ReminderAction, error) {
+	now := flow.now()
+	flow.record(sessionRecord(now, history.RecordTypeQuery, trigger, kind, config.ReminderAction{}, false))
+	reminder := flow.reminder(trigger, kind, now)
+	flow.asking = true
+	answer, err := flow.view.showReminder(kind, reminder)
+	flow.asking = false
+	if err != nil {
+		return answer, err
+	}
+
+	// 起動の失敗は launch が記録済みです。ダイアログには応答しているため、エラーは返しません。
+	launched := !answer.Launches() || flow.effects.launch(reminder, answer) == nil
+	flow.record(sessionRecord(flow.now(), history.RecordTypeAnswer, trigger, kind, answer, launched && answer.OpensURL()))
+	if launched {
+		flow.recordDone(answer)
+	}
+	return answer, nil
+}
+
+// resumeは再開が有効な場合に中断したセッション終了を開始し、後処理（アプリケーションの終了）を行います。
+// URLやコマンドを起動した場合は、読み込みを終えられるよう猶予の後に開始するよう scheduler で予約します
+// （UIスレッドを止めないよう、「後で」と同じく待機はしません）。後処理も開始の後に行います。
+// Restart Manager による終了要求（EndKindCloseApp）は、再起動するかどうかを要求元が決めるため再開しません。
+// この関数は副作用（タイマーの予約、セッション終了の開始、アプリケーションの終了）を持ちます。
+func (flow *reminderFlow) resume(kind session.EndKind, answer config.ReminderAction) {
+	if flow.initiator == nil || kind == session.EndKindCloseApp {
+		flow.finishUnlessResident()
+		return
+	}
+
+	initiator := flow.initiator
+	initiate := func() {
+		if err := initiator.Initiate(kind); err != nil {
+			logger.LogError("app", "セッション終了を再開できませんでした", err, map[string]interface{}{
+				"kind": kind.String(),
+			})
+		}
+		flow.finishUnlessResident()
+	}
+	if answer.Launches() && flow.resumeGrace > 0 {
+		flow.scheduler.schedule(flow.resumeGrace, initiate)
+		return
+	}
+	initiate()
+}
+
+// simulatedEventは Simulate で終了理由 kind に対して渡すセッションイベントを返します。
+// 実際のOSと同じく、ロックは問い合わせがないため通知として渡します。
+// sleepHeld が false の場合（スリープを保留できないWindows）は、スリープも通知として渡します。
+// この関数は純粋関数です。
+func simulatedEvent(kind session.EndKind, sleepHeld bool) session.Event {
+	if kind == session.EndKindLock || (kind == session.EndKindSleep && !sleepHeld) {
+		return session.Event{Type: session.EventNotice, Kind: kind}
+	}
+	return session.Event{Type: session.EventQuery, Kind: kind}
+}
@@ -0,0 +1,487 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/session"
+)
+
+func TestSimulatedEvent(t *testing.T) {
+	tests := []struct {
+		kind      session.EndKind
+		sleepHeld bool
+		want      session.EventType
+	}{
+		{kind: session.EndKindShutdown, sleepHeld: false, want: session.EventQuery},
+		{kind: session.EndKindLogoff, sleepHeld: true, want: session.EventQuery},
+		{kind: session.EndKindLock, sleepHeld: false, want: session.EventNotice},
+		{kind: session.EndKindLock, sleepHeld: true, want: session.EventNotice},
+		// Windowsのスリープは保留できず、通知として届きます。
+		{kind: session.EndKindSleep, sleepHeld: false, want: session.EventNotice},
+		// Linuxのスリープはlogindで保留し、問い合わせとして届きます。
+		{kind: session.EndKindSleep, sleepHeld: true, want: session.EventQuery},
+	}
+
+	for _, tt := range tests {
+		got := simulatedEvent(tt.kind, tt.sleepHeld)
+		if got.Type != tt.want || got.Kind != tt.kind {
+			t.Errorf("simulatedEvent(%v, %v) = %+v, want %v", tt.kind, tt.sleepHeld, got, tt.want)
+		}
+	}
+}
+
+func TestSleepUsesSuspendTrigger(t *testing.T) {
+	suspendMessage, sessionEndMessage := "スリープします", "退勤します"
+	suspendRule := config.Rule{When: config.RuleCondition{Triggers: []string{config.TriggerSuspend}}, DialogMessage: &suspendMessage}
+	sessionEndRule := config.Rule{When: config.RuleCondition{Triggers: []string{config.TriggerSessionEnd}}, DialogMessage: &sessionEndMessage}
+
+	tests := []struct {
+		name      string
+		rules     []config.Rule
+		event     session.Event
+		wantHold  bool
+		wantShown []shownReminder
+	}{
+		{
+			name:  "Linuxのスリープはルールがなければ表示しない",
+			event: session.Event{Type: session.EventQuery, Kind: session.EndKindSleep},
+		},
+		{
+			name:  "Linuxのスリープは session_end のルールでは表示しない",
+			rules: []config.Rule{sessionEndRule},
+			event: session.Event{Type: session.EventQuery, Kind: session.EndKindSleep},
+		},
+		{
+			name:      "Linuxのスリープは suspend のルールで表示する",
+			rules:     []config.Rule{suspendRule},
+			event:     session.Event{Type: session.EventQuery, Kind: session.EndKindSleep},
+			wantHold:  true,
+			wantShown: []shownReminder{{kind: session.EndKindSleep, message: suspendMessage}},
+		},
+		{
+			name:      "Windowsのスリープは suspend のルールで表示する",
+			rules:     []config.Rule{suspendRule},
+			event:     session.Event{Type: session.EventNotice, Kind: session.EndKindSleep},
+			wantHold:  true,
+			wantShown: []shownReminder{{kind: session.EndKindSleep, message: suspendMessage}},
+		},
+		{
+			name:      "シャットダウンは session_end",
+			rules:     []config.Rule{suspendRule, sessionEndRule},
+			event:     session.Event{Type: session.EventQuery, Kind: session.EndKindShutdown},
+			wantHold:  true,
+			wantShown: []shownReminder{{kind: session.EndKindShutdown, message: sessionEndMessage}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userConfig := config.DefaultUserConfig()
+			userConfig.Rules = tt.rules
+			flow := newTestFlow(true)
+			flow.skips = userConfig.Skips
+			flow.reminder = userConfig.ReminderAt
+
+			if got := flow.ShouldHold(tt.event.Kind); got != tt.wantHold {
+				t.Errorf("ShouldHold(%v) = %v, want %v", tt.event.Kind, got, tt.wantHold)
+			}
+			flow.HandleSessionEvent(tt.event)
+			if !reflect.DeepEqual(flow.view.shown, tt.wantShown) {
+				t.Errorf("shown = %v, want %v", flow.view.shown, tt.wantShown)
+			}
+		})
+	}
+}
+
+func TestAskRecordsEveryPath(t *testing.T) {
+	lockRule := config.Rule{When: config.RuleCondition{Triggers: []string{config.TriggerLock}}}
+
+	tests := []struct {
+		name        string
+		resident    bool
+		run         func(flow *testFlow)
+		wantTrigger string
+		wantKind    session.EndKind
+	}{
+		{
+			name:     "セッション終了の問い合わせ",
+			resident: true,
+			run: func(flow *testFlow) {
+				flow.HandleSessionEvent(session.Event{Type: session.EventQuery, Kind: session.EndKindRestart})
+			},
+			wantTrigger: config.TriggerSessionEnd,
+			wantKind:    session.EndKindRestart,
+		},
+		{
+			name:        "トレイメニュー",
+			resident:    true,
+			run:         func(flow *testFlow) { flow.remind(session.EndKindShutdown) },
+			wantTrigger: config.TriggerSessionEnd,
+			wantKind:    session.EndKindShutdown,
+		},
+		{
+			name:        "show コマンド",
+			run:         func(flow *testFlow) { flow.remindOnRequest(session.EndKindShutdown) },
+			wantTrigger: config.TriggerSessionEnd,
+			wantKind:    session.EndKindShutdown,
+		},
+		{
+			name:        "schedules",
+			resident:    true,
+			run:         func(flow *testFlow) { flow.notify(config.TriggerSchedule, session.EndKindShutdown) },
+			wantTrigger: config.TriggerSchedule,
+			wantKind:    session.EndKindShutdown,
+		},
+		{
+			name:     "ロック",
+			resident: true,
+			run: func(flow *testFlow) {
+				flow.HandleSessionEvent(session.Event{Type: session.EventNotice, Kind: session.EndKindLock})
+			},
+			wantTrigger: config.TriggerLock,
+			wantKind:    session.EndKindLock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userConfig := config.DefaultUserConfig()
+			userConfig.Rules = []config.Rule{lockRule}
+			flow := newTestFlow(tt.resident, config.BuiltInAction(config.ActionOpen))
+			flow.skips = userConfig.Skips
+
+			tt.run(flow)
+
+			if len(flow.records) != 2 {
+				t.Fatalf("records = %+v, want query and answer", flow.records)
+			}
+			query, answer := flow.records[0], flow.records[1]
+			if query.Type != history.RecordTypeQuery || answer.Type != history.RecordTypeAnswer {
+				t.Errorf("record types = %s, %s", query.Type, answer.Type)
+			}
+			for _, record := range flow.records {
+				if record.Trigger != tt.wantTrigger || record.EndKind != tt.wantKind.String() {
+					t.Errorf("record = %+v, want trigger %s, end_kind %s", record, tt.wantTrigger, tt.wantKind)
+				}
+			}
+			if answer.Action != config.ActionOpen || !answer.Opened {
+				t.Errorf("answer = %+v, want opened %s", answer, config.ActionOpen)
+			}
+		})
+	}
+}
+
+func TestAnswerOpenedFollowsLaunchResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		answer     config.ReminderAction
+		launchErr  error
+		wantOpened bool
+	}{
+		{name: "開くに成功", answer: config.BuiltInAction(config.ActionOpen), wantOpened: true},
+		{name: "開くに失敗", answer: config.BuiltInAction(config.ActionOpen), launchErr: errors.New("xdg-open がありません")},
+		{name: "独自のURLに成功", answer: config.ReminderAction{Label: "打刻", URL: "https://attendance.example.com"}, wantOpened: true},
+		{name: "独自のURLに失敗", answer: config.ReminderAction{Label: "打刻", URL: "https://attendance.example.com"}, launchErr: errors.New("起動できません")},
+		{name: "コマンドはURLではない", answer: config.ReminderAction{Label: "日報", Command: []string{"report"}}},
+		{name: "閉じる", answer: config.BuiltInAction(config.ActionClose)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newTestFlow(true, tt.answer)
+			flow.effects.launchErr = tt.launchErr
+
+			flow.HandleSessionEvent(session.Event{Type: session.EventQuery, Kind: session.EndKindShutdown})
+
+			answer := flow.records[len(flow.records)-1]
+			if answer.Type != history.RecordTypeAnswer || answer.Opened != tt.wantOpened {
+				t.Errorf("answer = %+v, want opened %v", answer, tt.wantOpened)
+			}
+		})
+	}
+}
+
+func TestAskExpandsReminderOnce(t *testing.T) {
+	flow := newTestFlow(true, config.BuiltInAction(config.ActionOpen))
+	var expandedAt []time.Time
+	flow.reminder = func(trigger string, kind session.EndKind, now time.Time) config.Reminder {
+		expandedAt = append(expandedAt, now)
+		return config.Reminder{
+			TargetURL:     "https://attendance.example.com/" + now.Format("1504"),
+			DialogMessage: now.Format("15:04"),
+		}
+	}
+
+	flow.HandleSessionEvent(session.Event{Type: session.EventQuery, Kind: session.EndKindShutdown})
+
+	if !reflect.DeepEqual(expandedAt, []time.Time{testNow}) {
+		t.Errorf("expandedAt = %v, want [%v]", expandedAt, testNow)
+	}
+	if want := []shownReminder{{kind: session.EndKindShutdown, message: "18:00"}}; !reflect.DeepEqual(flow.view.shown, want) {
+		t.Errorf("shown = %v, want %v", flow.view.shown, want)
+	}
+	if len(flow.effects.launchedFrom) != 1 || flow.effects.launchedFrom[0].TargetURL != "https://attendance.example.com/1800" {
+		t.Errorf("launchedFrom = %v, want the reminder shown in the dialog", flow.effects.launchedFrom)
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	query := func(kind session.EndKind) func(*testFlow) {
+		return func(flow *testFlow) { flow.HandleSessionEvent(session.Event{Type: session.EventQuery, Kind: kind}) }
+	}
+	event := func(eventType session.EventType) func(*testFlow) {
+		return func(flow *testFlow) {
+			flow.HandleSessionEvent(session.Event{Type: eventType, Kind: session.EndKindShutdown})
+		}
+	}
+	fire := func(flow *testFlow) { flow.scheduler.fire() }
+	snooze := config.BuiltInAction(config.ActionSnooze)
+	open := config.BuiltInAction(config.ActionOpen)
+
+	tests := []struct {
+		name          string
+		resident      bool
+		answers       []config.ReminderAction
+		steps         []func(*testFlow)
+		wantShown     int
+		wantInitiated []session.EndKind
+		wantDelays    []time.Duration
+		wantFinished  int
+		wantArmed     bool
+	}{
+		{
+			name:          "応答後は同じセッション終了を保留しない",
+			resident:      true,
+			steps:         []func(*testFlow){query(session.EndKindShutdown), query(session.EndKindShutdown)},
+			wantShown:     1,
+			wantInitiated: []session.EndKind{session.EndKindShutdown},
+		},
+		{
+			name:          "キャンセル後は再び表示",
+			resident:      true,
+			steps:         []func(*testFlow){query(session.EndKindShutdown), event(session.EventCancel), query(session.EndKindRestart)},
+			wantShown:     2,
+			wantInitiated: []session.EndKind{session.EndKindShutdown, session.EndKindRestart},
+		},
+		{
+			name:          "常駐しない場合は応答後に終了",
+			steps:         []func(*testFlow){query(session.EndKindLogoff)},
+			wantShown:     1,
+			wantInitiated: []session.EndKind{session.EndKindLogoff},
+			wantFinished:  1,
+		},
+		{
+			name:          "常駐する場合はセッションの終了で終了",
+			resident:      true,
+			steps:         []func(*testFlow){query(session.EndKindShutdown), event(session.EventEnd)},
+			wantShown:     1,
+			wantInitiated: []session.EndKind{session.EndKindShutdown},
+			wantFinished:  1,
+		},
+		{
+			name:          "URLを開いた場合は猶予の後に再開",
+			resident:      true,
+			answers:       []config.ReminderAction{open},
+			steps:         []func(*testFlow){query(session.EndKindRestart), fire},
+			wantShown:     1,
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+			wantDelays:    []time.Duration{3 * time.Second},
+		},
+		{
+			name:       "猶予の間は再開も終了もしない",
+			answers:    []config.ReminderAction{open},
+			steps:      []func(*testFlow){query(session.EndKindShutdown)},
+			wantShown:  1,
+			wantDelays: []time.Duration{3 * time.Second},
+		},
+		{
+			name:          "常駐しない場合も猶予の後に再開して終了",
+			answers:       []config.ReminderAction{open},
+			steps:         []func(*testFlow){query(session.EndKindShutdown), fire},
+			wantShown:     1,
+			wantInitiated: []session.EndKind{session.EndKindShutdown},
+			wantDelays:    []time.Duration{3 * time.Second},
+			wantFinished:  1,
+		},
+		{
+			name:          "「後で」は中断したまま問い合わせをやり直す",
+			resident:      true,
+			answers:       []config.ReminderAction{snooze},
+			steps:         []func(*testFlow){query(session.EndKindShutdown), fire},
+			wantShown:     2,
+			wantInitiated: []session.EndKind{session.EndKindShutdown},
+			wantDelays:    []time.Duration{10 * time.Minute},
+		},
+		{
+			name:       "「後で」の間は再開しない",
+			resident:   true,
+			answers:    []config.ReminderAction{snooze},
+			steps:      []func(*testFlow){query(session.EndKindShutdown)},
+			wantShown:  1,
+			wantDelays: []time.Duration{10 * time.Minute},
+			wantArmed:  true,
+		},
+		{
+			name:          "新しい問い合わせで予約中の再表示を取り消す",
+			resident:      true,
+			answers:       []config.ReminderAction{snooze},
+			steps:         []func(*testFlow){query(session.EndKindShutdown), query(session.EndKindRestart), fire},
+			wantShown:     2,
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+			wantDelays:    []time.Duration{10 * time.Minute},
+		},
+		{
+			name:      "ダイアログを表示できない場合は再開しない",
+			resident:  true,
+			steps:     []func(*testFlow){func(flow *testFlow) { flow.view.err = errors.New("表示できません") }, query(session.EndKindShutdown)},
+			wantShown: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newTestFlow(tt.resident, tt.answers...)
+			initiator := &fakeInitiator{}
+			flow.enableResume(initiator, 3*time.Second)
+
+			for _, step := range tt.steps {
+				step(flow)
+			}
+
+			if got := len(flow.view.shown); got != tt.wantShown {
+				t.Errorf("shown = %d, want %d", got, tt.wantShown)
+			}
+			if !reflect.DeepEqual(initiator.initiated, tt.wantInitiated) {
+				t.Errorf("initiated = %v, want %v", initiator.initiated, tt.wantInitiated)
+			}
+			if !reflect.DeepEqual(flow.scheduler.delays, tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", flow.scheduler.delays, tt.wantDelays)
+			}
+			if flow.effects.finished != tt.wantFinished {
+				t.Errorf("finished = %d, want %d", flow.effects.finished, tt.wantFinished)
+			}
+			if flow.armed != tt.wantArmed {
+				t.Errorf("armed = %v, want %v", flow.armed, tt.wantArmed)
+			}
+		})
+	}
+}
+
+func TestRemindFinishesUnlessResident(t *testing.T) {
+	tests := []struct {
+		name         string
+		resident     bool
+		answers      []config.ReminderAction
+		wantShown    int
+		wantFinished int
+	}{
+		{name: "常駐しない", wantShown: 1, wantFinished: 1},
+		{name: "常駐する", resident: true, wantShown: 1},
+		{name: "「後で」は再表示の後に終了", answers: []config.ReminderAction{config.BuiltInAction(config.ActionSnooze)}, wantShown: 2, wantFinished: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newTestFlow(tt.resident, tt.answers...)
+
+			flow.remind(session.EndKindShutdown)
+			if len(flow.scheduler.pending) > 0 && flow.effects.finished != 0 {
+				t.Error("再表示の予約中に終了しました")
+			}
+			flow.scheduler.fire()
+
+			if got := len(flow.view.shown); got != tt.wantShown {
+				t.Errorf("shown = %d, want %d", got, tt.wantShown)
+			}
+			if flow.effects.finished != tt.wantFinished {
+				t.Errorf("finished = %d, want %d", flow.effects.finished, tt.wantFinished)
+			}
+		})
+	}
+}
+
+func TestResume(t *testing.T) {
+	tests := []struct {
+		name          string
+		kind          session.EndKind
+		initiator     bool
+		grace         time.Duration
+		answer        config.ReminderAction
+		wantInitiated []session.EndKind
+		wantDelays    []time.Duration
+	}{
+		{
+			name:   "再開しない設定",
+			kind:   session.EndKindRestart,
+			answer: config.BuiltInAction(config.ActionOpen),
+		},
+		{
+			name:          "閉じるはすぐに再開",
+			kind:          session.EndKindRestart,
+			initiator:     true,
+			grace:         5 * time.Second,
+			answer:        config.BuiltInAction(config.ActionClose),
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+		},
+		{
+			name:          "開くは猶予の後に再開",
+			kind:          session.EndKindRestart,
+			initiator:     true,
+			grace:         5 * time.Second,
+			answer:        config.BuiltInAction(config.ActionOpen),
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+			wantDelays:    []time.Duration{5 * time.Second},
+		},
+		{
+			name:          "コマンドも猶予の後に再開",
+			kind:          session.EndKindRestart,
+			initiator:     true,
+			grace:         5 * time.Second,
+			answer:        config.ReminderAction{Label: "日報", Command: []string{"report"}},
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+			wantDelays:    []time.Duration{5 * time.Second},
+		},
+		{
+			name:          "猶予が0の場合は待たない",
+			kind:          session.EndKindRestart,
+			initiator:     true,
+			answer:        config.BuiltInAction(config.ActionOpen),
+			wantInitiated: []session.EndKind{session.EndKindRestart},
+		},
+		{
+			name:      "更新による終了要求は再開しない",
+			kind:      session.EndKindCloseApp,
+			initiator: true,
+			grace:     5 * time.Second,
+			answer:    config.BuiltInAction(config.ActionOpen),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flow := newTestFlow(true)
+			initiator := &fakeInitiator{}
+			if tt.initiator {
+				flow.enableResume(initiator, tt.grace)
+			}
+
+			flow.resume(tt.kind, tt.answer)
+			if len(tt.wantDelays) > 0 && len(initiator.initiated) > 0 {
+				t.Errorf("猶予の前に再開しました: %v", initiator.initiated)
+			}
+			flow.scheduler.fire()
+
+			if !reflect.DeepEqual(initiator.initiated, tt.wantInitiated) {
+				t.Errorf("initiated = %v, want %v", initiator.initiated, tt.wantInitiated)
+			}
+			if !reflect.DeepEqual(flow.scheduler.delays, tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", flow.scheduler.delays, tt.wantDelays)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/logger"
+	"shutdown-alert/internal/session"
+	"shutdown-alert/internal/ui"
+	"shutdown-alert/internal/worktime"
+)
+
+// recordChecklistはチェックリストの状態と選ばれたボタンを履歴に記録します。
+// チェックリストがない場合は何も記録しません。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func recordChecklist(kind session.EndKind, action config.ReminderAction, checklist ui.ChecklistState) {
+	if len(checklist.Items) == 0 {
+		return
+	}
+
+	appendHistory(checklistRecord(time.Now(), kind, action, checklist))
+}
+
+// appendHistoryはレコードを履歴に追記します。失敗した場合はエラーログに記録して続行します。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func appendHistory(record history.Record) {
+	if err := history.Append(record); err != nil {
+		logger.LogError("app", "履歴を記録できませんでした", err, map[string]interface{}{
+			"type":     record.Type,
+			"end_kind": record.EndKind,
+		})
+	}
+}
+
+// sessionRecordはリマインダーの表示（問い合わせ）や応答のレコードを作成します。
+// opened は選ばれたボタンでURLを開けたかどうか（起動の結果）です。
+// 応答以外のレコードでは action と opened は使用しません。
+// この関数は純粋関数です。
+func sessionRecord(now time.Time, recordType, trigger string, kind session.EndKind, action config.ReminderAction, opened bool) history.Record {
+	record := history.NewRecord(now, recordType)
+	record.EndKind = kind.String()
+	record.Trigger = trigger
+	if recordType == history.RecordTypeAnswer {
+		record.Action = action.ID()
+		record.Opened = opened
+	}
+	return record
+}
+
+// historyReaderはトレイの表示やスケジュールの確認のたびに参照する履歴です。
+// 履歴は追記のみのため、最初に読み込んだ後は追記された行だけを読み込みます。
+var historyReader = history.NewReader()
+
+// workTimeSummaryは履歴から今日の勤務時間をトレイ表示用の文字列にして返します。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func workTimeSummary(now time.Time) string {
+	records, err := historyReader.Records()
+	if err != nil {
+		logger.LogError("app", "履歴を読み込めませんでした", err, nil)
+	}
+	return worktime.TraySummary(records, now)
+}
+
+// workStartは履歴から今日の勤務の開始時刻（今日の最初の記録の時刻）を返します。
+// 今日の記録がない場合はゼロ値を返します。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func workStart(now time.Time) time.Time {
+	records, err := historyReader.Records()
+	if err != nil {
+		logger.LogError("app", "履歴を読み込めませんでした", err, nil)
+	}
+	today, ok := worktime.Today(records, now)
+	if !ok {
+		return time.Time{}
+	}
+	return today.Start
+}
+
+// checklistRecordはチェックリストの状態から履歴のレコードを作成します。
+// この関数は純粋関数です。
+func checklistRecord(now time.Time, kind session.EndKind, action config.ReminderAction, checklist ui.ChecklistState) history.Record {
+	record := history.NewRecord(now, history.RecordTypeChecklist)
+	record.EndKind = kind.String()
+	record.Action = action.ID()
+	for index, item := range checklist.Items {
+		record.Checklist = append(record.Checklist, history.ChecklistEntry{
+			Label:    item.Label,
+			Required: item.Required,
+			Checked:  checklist.Checked[index],
+		})
+	}
+	return record
+}
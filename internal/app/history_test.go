@@ -0,0 +1,70 @@
+package app
+
+import (
+	"reflect"
+	"testing"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/session"
+	"shutdown-alert/internal/ui"
+)
+
+func TestChecklistRecord(t *testing.T) {
+	items := []config.ChecklistItem{
+		{Label: "勤怠を登録", Required: true},
+		{Label: "コードをpush"},
+	}
+
+	tests := []struct {
+		name      string
+		action    config.ReminderAction
+		checked   []bool
+		wantEntry []history.ChecklistEntry
+	}{
+		{
+			name:    "すべてチェック",
+			action:  config.BuiltInAction(config.ActionOpen),
+			checked: []bool{true, true},
+			wantEntry: []history.ChecklistEntry{
+				{Label: "勤怠を登録", Required: true, Checked: true},
+				{Label: "コードをpush", Checked: true},
+			},
+		},
+		{
+			name:    "「後で」は未完了のまま記録",
+			action:  config.BuiltInAction(config.ActionSnooze),
+			checked: []bool{false, true},
+			wantEntry: []history.ChecklistEntry{
+				{Label: "勤怠を登録", Required: true},
+				{Label: "コードをpush", Checked: true},
+			},
+		},
+		{
+			name:    "独自のボタンは表示名で記録",
+			action:  config.ReminderAction{Label: "日報", Command: []string{"report"}},
+			checked: []bool{true, false},
+			wantEntry: []history.ChecklistEntry{
+				{Label: "勤怠を登録", Required: true, Checked: true},
+				{Label: "コードをpush"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checklist := ui.ChecklistState{Items: items, Checked: tt.checked}
+			record := checklistRecord(testNow, session.EndKindShutdown, tt.action, checklist)
+
+			if record.Type != history.RecordTypeChecklist || record.EndKind != "shutdown" || record.Action != tt.action.ID() {
+				t.Errorf("record = %+v", record)
+			}
+			if got, _ := record.Time(); !got.Equal(testNow) {
+				t.Errorf("Time() = %v, want %v", got, testNow)
+			}
+			if !reflect.DeepEqual(record.Checklist, tt.wantEntry) {
+				t.Errorf("Checklist = %+v, want %+v", record.Checklist, tt.wantEntry)
+			}
+		})
+	}
+}
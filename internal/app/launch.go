@@ -0,0 +1,23 @@
+package app
+
+import (
+	"os/exec"
+
+	"shutdown-alert/internal/config"
+)
+
+// launchTargetはボタンが起動するURLまたはコマンドを返します。
+// 組み込みの「開く」はリマインダー（テンプレート展開済み）のURLを使用します。
+// この関数は純粋関数です。
+func launchTarget(reminder config.Reminder, action config.ReminderAction) (targetURL string, command []string) {
+	if action.Name == config.ActionOpen {
+		return reminder.TargetURL, nil
+	}
+	return action.URL, action.Command
+}
+
+// startCommandはコマンドを起動し、終了を待たずに戻ります。
+// この関数は副作用（外部プロセスの起動）を持ちます。
+func startCommand(command []string) error {
+	return exec.Command(command[0], command[1:]...).Start()
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+// checkSchedulesは前回の確認から現在までに schedules の時刻を迎えた場合、リマインダーを表示します。
+// 定期的に呼び出す必要があります。最初の呼び出しでは確認を始める時刻を記録するだけです。
+// ダイアログの表示中は確認を延期し、閉じた後の呼び出しでまとめて確認します。
+// この関数は副作用（履歴の読み取り、UIの表示、URLの起動）を持ちます。
+func (flow *reminderFlow) checkSchedules() {
+	if flow.asking {
+		return
+	}
+	now := flow.now()
+	from := flow.scheduleChecked
+	flow.scheduleChecked = now
+	if from.IsZero() || len(flow.schedules) == 0 {
+		return
+	}
+
+	if len(config.DueSchedules(flow.schedules, from, now, flow.workStart(now))) > 0 {
+		flow.notify(config.TriggerSchedule, session.EndKindShutdown)
+	}
+}
+
+// notifyはセッション終了を保留しない（スケジュール・ロック・スリープによる）リマインダーを表示し、応答に応じた処理を行います。
+// この後のセッション終了にも備えるため、応答後も後処理（アプリケーションの終了）は行いません。
+// ルールでダイアログを表示しないきっかけ・時刻の場合と、今日 done_today のボタンを選んでいる場合は表示しません。
+// この関数は副作用（ファイルの読み取り、UIの表示、URLの起動）を持ちます。
+func (flow *reminderFlow) notify(trigger string, kind session.EndKind) {
+	if flow.skipped(trigger, kind) || flow.suppressedToday() {
+		return
+	}
+	answer, err := flow.ask(trigger, kind)
+	if err == nil && answer.Name == config.ActionSnooze {
+		flow.postpone(kind, func(kind session.EndKind) {
+			flow.notify(trigger, kind)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"time"
+
+	"shutdown-alert/internal/session"
+)
+
+// reminderSchedulerは一定時間後の処理を予約します（OSごとの実装）。
+// callback はリマインダーの流れと同じスレッド（Windowsでは UI スレッド）で呼び出す必要があります。
+type reminderScheduler interface {
+	// scheduleは delay 後に callback を呼び出すよう予約し、予約を取り消す関数を返します。
+	schedule(delay time.Duration, callback func()) (cancel func())
+}
+
+// pendingSnoozeは「後で」によって予約された再表示を表します。
+type pendingSnooze struct {
+	// cancel は予約を取り消します。nil の場合は予約がありません。
+	cancel func()
+	// generation は予約ごとに増える番号です。
+	// 取り消しと発火が行き違った古い予約を無視するために使います。
+	generation int
+	// due は再表示する時刻です。
+	due time.Time
+}
+
+// postponeは snooze_minutes の間隔をおいて again(kind) を呼び出すよう予約します。
+// 既に予約がある場合は置き換えます。
+// この関数は副作用（タイマーの予約、トレイ表示の更新）を持ちます。
+func (flow *reminderFlow) postpone(kind session.EndKind, again func(session.EndKind)) {
+	flow.postponeFor(kind, flow.snoozeInterval, again)
+}
+
+// postponeForは delay の間隔をおいて again(kind) を呼び出すよう予約します。
+// 既に予約がある場合は置き換えます。
+// この関数は副作用（タイマーの予約、トレイ表示の更新）を持ちます。
+func (flow *reminderFlow) postponeFor(kind session.EndKind, delay time.Duration, again func(session.EndKind)) {
+	flow.cancelSnooze()
+
+	generation := flow.snooze.generation + 1
+	due := flow.now().Add(delay)
+	cancel := flow.scheduler.schedule(delay, func() {
+		if flow.snooze.cancel == nil || flow.snooze.generation != generation {
+			return
+		}
+		flow.snooze.cancel = nil
+		flow.effects.showSnooze(time.Time{}, false)
+		again(kind)
+	})
+
+	flow.snooze = pendingSnooze{cancel: cancel, generation: generation, due: due}
+	flow.effects.showSnooze(due, true)
+}
+
+// cancelSnoozeは予約中の再表示を取り消します（予約がなければ何もしません）。
+// この関数は副作用（タイマーの取り消し、トレイ表示の更新）を持ちます。
+func (flow *reminderFlow) cancelSnooze() {
+	if flow.snooze.cancel == nil {
+		return
+	}
+
+	flow.snooze.cancel()
+	flow.snooze.cancel = nil
+	flow.effects.showSnooze(time.Time{}, false)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/logger"
+	"shutdown-alert/internal/session"
+)
+
+// expandedReminderは表示のきっかけ・終了理由・現在時刻 now に対応するリマインダー（一致するルールを適用したもの）のテンプレートを展開して返します。
+// 展開に失敗した場合や、展開した target_url が安全でない場合は記録して、デフォルトのリマインダーを返します。
+// この関数は副作用（ユーザー名・ホスト名の取得、エラーの記録）を持ちます。
+func expandedReminder(userConfig config.UserConfig, trigger string, kind session.EndKind, sessionStart, now time.Time) config.Reminder {
+	hostname, _ := os.Hostname()
+	data := config.NewTemplateData(kind, sessionStart, now, currentUserName(), hostname)
+
+	expanded, err := expandOrDefault(userConfig.ReminderAt(trigger, kind, now), kind, data)
+	if err != nil {
+		logger.LogError("app", "テンプレートを展開できなかったため、デフォルトのリマインダーを使用します", err, map[string]interface{}{
+			"kind": kind.String(),
+		})
+	}
+	return expanded
+}
+
+// expandOrDefaultは reminder のテンプレートを展開して返します。
+// 展開に失敗した場合は、展開前の内容（安全でないURLを含むかもしれません）を起動しないよう、
+// デフォルトのリマインダーを展開したものをエラーとともに返します。
+// この関数は純粋関数です。
+func expandOrDefault(reminder config.Reminder, kind session.EndKind, data config.TemplateData) (config.Reminder, error) {
+	expanded, err := reminder.Expand(data)
+	if err == nil {
+		return expanded, nil
+	}
+
+	fallback := config.DefaultUserConfig().ReminderFor(kind)
+	if expandedFallback, fallbackErr := fallback.Expand(data); fallbackErr == nil {
+		fallback = expandedFallback
+	}
+	return fallback, err
+}
+
+// currentUserNameはログオン中のユーザー名を返します（Windowsのドメイン名は除きます）。
+// 取得できない場合は空文字列を返します。
+// この関数は副作用（ユーザー情報の取得）を持ちます。
+func currentUserName() string {
+	current, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	name := current.Username
+	if index := strings.LastIndex(name, `\`); index >= 0 {
+		name = name[index+1:]
+	}
+	return name
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+func TestExpandOrDefault(t *testing.T) {
+	data := config.NewTemplateData(session.EndKindRestart, time.Time{}, time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), "taro", "pc01")
+	custom := []config.ReminderAction{{Label: "打刻", URL: "https://attendance.example.com"}, config.BuiltInAction(config.ActionClose)}
+
+	tests := []struct {
+		name     string
+		reminder config.Reminder
+		wantURL  string
+		wantErr  bool
+	}{
+		{
+			name:     "展開できる",
+			reminder: config.Reminder{TargetURL: "https://example.com/{{.User}}", Actions: custom},
+			wantURL:  "https://example.com/taro",
+		},
+		{
+			name:     "安全でないURLはデフォルトに戻す",
+			reminder: config.Reminder{TargetURL: `{{if eq .User "user"}}https://example.com{{else}}file:///etc/passwd{{end}}`, Actions: custom},
+			wantURL:  config.TargetURL,
+			wantErr:  true,
+		},
+		{
+			name:     "展開できないテンプレートはデフォルトに戻す",
+			reminder: config.Reminder{TargetURL: "https://example.com/{{.Unknown}}", Actions: custom},
+			wantURL:  config.TargetURL,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandOrDefault(tt.reminder, session.EndKindRestart, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expandOrDefault() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.TargetURL != tt.wantURL {
+				t.Errorf("TargetURL = %q, want %q", got.TargetURL, tt.wantURL)
+			}
+			if tt.wantErr && len(got.Actions) != len(config.DefaultUserConfig().Actions) {
+				t.Errorf("Actions = %v, デフォルトのボタン構成を期待します", got.Actions)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// このパッケージはGUIを起動せずに実行するサブコマンドを提供する。
+//
+// main はコマンドライン引数がある場合に Run を呼び出し、その戻り値を終了コードとして終了する。
+// 出力先は引数で受け取るため、標準出力以外にも書き出せる。
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+	"shutdown-alert/internal/ipc"
+	"shutdown-alert/internal/worktime"
+)
+
+// 終了コード
+const (
+	// ExitOKは正常終了を表します。
+	ExitOK = 0
+	// ExitErrorは実行中のエラーを表します。
+	ExitError = 1
+	// ExitUsageはコマンドライン引数の誤りを表します。
+	ExitUsage = 2
+)
+
+// command はサブコマンド1つ分の定義です。
+type command struct {
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands はサブコマンド名ごとの定義を返します。
+// configFlag は --config オプションで指定された設定ファイルのパス（指定がなければ空）です。
+// この関数は純粋関数です。
+func commands(configFlag string) map[string]command {
+	return map[string]command{
+		"worktime":             {usage: "worktime [-days N]  直近N日の勤務時間（最初の記録から最後の記録まで）を表示します", run: runWorkTime},
+		"report":               {usage: "report [-period week|month] [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-format csv|json]  勤怠レポートを出力します", run: runReport},
+		"validate-config":      {usage: "validate-config [パス]  設定ファイルを検証し、エラーを表示します（省略時は探索で見つかったファイル）", run: runValidateConfig(configFlag)},
+		"config":               {usage: "config path|origins|schema|migrate [パス]  使用する設定ファイルと探索した場所、キーごとの出どころ、JSON Schemaを表示するか、設定ファイルを最新の形式に書き換えます", run: runConfig(configFlag)},
+		"holidays":             {usage: "holidays [-year YYYY] [japan|パス]  1年分の休日を表示します（省略時は今年の日本の祝日）", run: runHolidays},
+		"print-default-config": {usage: "print-default-config  組み込みのデフォルト設定をYAMLで出力します", run: runPrintDefaultConfig},
+		"startup":              {usage: "startup register|unregister|status  スタートアップ登録を操作します", run: runStartup},
+		"logs":                 {usage: "logs show|clear  エラーログを表示・削除します", run: runLogs},
+		"simulate-shutdown":    {usage: "simulate-shutdown [-config パス] [shutdown|restart|logoff|sleep|lock|close_app]  セッションを終了せずに確認ダイアログを表示します", run: runSimulateShutdown(configFlag)},
+		ipc.CommandShow:        {usage: "show  起動中のアプリケーションに確認ダイアログを表示させます", run: remoteCommand(ipc.CommandShow, 0)},
+		ipc.CommandReload:      {usage: "reload  起動中のアプリケーションに設定ファイルを再読み込みさせます", run: remoteCommand(ipc.CommandReload, 0)},
+		ipc.CommandSnooze:      {usage: "snooze <間隔>  起動中のアプリケーションに、間隔（例: 30m）をおいて確認ダイアログを表示させます", run: remoteCommand(ipc.CommandSnooze, 1)},
+		ipc.CommandStatus:      {usage: "status  起動中のアプリケーションの状態を表示します", run: remoteCommand(ipc.CommandStatus, 0)},
+	}
+}
+
+// ParseGlobalFlags はサブコマンドより前に指定するオプション（--config）を解釈し、残りの引数を返します。
+// この関数は副作用（エラーの出力）を持ちます。
+func ParseGlobalFlags(args []string, stderr io.Writer) (configFlag string, rest []string, err error) {
+	flags := flag.NewFlagSet("shutdown-alert", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&configFlag, "config", "", "設定ファイルのパス")
+	if err := flags.Parse(args); err != nil {
+		return "", nil, err
+	}
+	return configFlag, flags.Args(), nil
+}
+
+// Run はargs[0]のサブコマンドを実行し、終了コードを返します。
+// configFlag は --config オプションで指定された設定ファイルのパス（指定がなければ空）です。
+// この関数は副作用（サブコマンドの実行、出力）を持ちます。
+func Run(args []string, configFlag string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands(configFlag)[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "未知のサブコマンドです: %s\n", args[0])
+		printUsage(stderr)
+		return ExitUsage
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+// printUsage はサブコマンドの一覧を出力します。
+// この関数は副作用（出力）を持ちます。
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "使い方: shutdown-alert [--config パス] <サブコマンド> [引数]")
+	fmt.Fprintln(w, "サブコマンドを指定しない場合は常駐アプリケーションとして起動します。")
+	fmt.Fprintln(w)
+	cmds := commands("")
+	for _, name := range sortedNames(cmds) {
+		fmt.Fprintf(w, "  %s\n", cmds[name].usage)
+	}
+}
+
+// sortedNames はサブコマンド名を名前順に返します。
+// この関数は純粋関数です。
+func sortedNames(cmds map[string]command) []string {
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runWorkTime は直近の勤務時間を1日1行で出力します。今日の分は現在時刻までとします。
+// この関数は副作用（ファイルの読み取り、出力）を持ちます。
+func runWorkTime(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("worktime", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dayCount := flags.Int("days", config.WorkTimeDays, "表示する日数（今日を含む）")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *dayCount < 1 {
+		fmt.Fprintf(stderr, "-days には1以上を指定してください: %d\n", *dayCount)
+		return ExitUsage
+	}
+
+	records, err := history.ReadAll()
+	if err != nil {
+		fmt.Fprintf(stderr, "履歴を読み込めませんでした: %v\n", err)
+		return ExitError
+	}
+
+	now := time.Now()
+	days := worktime.Since(worktime.Days(records, now.Location()), now.AddDate(0, 0, -(*dayCount-1)))
+	today, working := worktime.Today(records, now)
+	for _, day := range days {
+		if working && day.Date.Equal(today.Date) {
+			day = today
+		}
+		fmt.Fprint(stdout, day.Line())
+	}
+	return ExitOK
+}
+
+// レポートの期間と形式
+const (
+	// reportPeriodWeekは今日を含む直近7日間です。
+	reportPeriodWeek = "week"
+	// reportPeriodMonthは今月1日から今日までです。
+	reportPeriodMonth = "month"
+	reportFormatCSV   = "csv"
+	reportFormatJSON  = "json"
+)
+
+// runReport は指定した期間の勤怠レポートをCSVまたはJSONで出力します。
+// -from・-to を指定した場合は -period より優先します。
+// この関数は副作用（ファイルの読み取り、出力）を持ちます。
+func runReport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	period := flags.String("period", reportPeriodWeek, "期間（week: 直近7日間、month: 今月）")
+	fromText := flags.String("from", "", "開始日（YYYY-MM-DD）")
+	toText := flags.String("to", "", "終了日（YYYY-MM-DD、その日を含む）")
+	format := flags.String("format", reportFormatCSV, "出力形式（csv または json）")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	now := time.Now()
+	from, to, err := reportRange(*period, *fromText, *toText, now)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	if *format != reportFormatCSV && *format != reportFormatJSON {
+		fmt.Fprintf(stderr, "-format には %s または %s を指定してください: %s\n", reportFormatCSV, reportFormatJSON, *format)
+		return ExitUsage
+	}
+
+	records, err := history.ReadAll()
+	if err != nil {
+		fmt.Fprintf(stderr, "履歴を読み込めませんでした: %v\n", err)
+		return ExitError
+	}
+
+	rows := worktime.Report(records, from, to, now.Location())
+	if *format == reportFormatJSON {
+		err = worktime.WriteJSON(stdout, rows)
+	} else {
+		err = worktime.WriteCSV(stdout, rows)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "レポートを出力できませんでした: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// reportRange はレポートの開始日と終了日を返します。
+// fromText・toText が空の場合は period と now から決めます。
+// この関数は純粋関数です。
+func reportRange(period, fromText, toText string, now time.Time) (from, to time.Time, err error) {
+	switch period {
+	case reportPeriodWeek:
+		from, to = now.AddDate(0, 0, -6), now
+	case reportPeriodMonth:
+		from, to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), now
+	default:
+		return from, to, fmt.Errorf("-period には %s または %s を指定してください: %s", reportPeriodWeek, reportPeriodMonth, period)
+	}
+
+	if fromText != "" {
+		if from, err = time.ParseInLocation(config.TemplateDateFormat, fromText, now.Location()); err != nil {
+			return from, to, fmt.Errorf("-from の日付が正しくありません: %s", fromText)
+		}
+	}
+	if toText != "" {
+		if to, err = time.ParseInLocation(config.TemplateDateFormat, toText, now.Location()); err != nil {
+			return from, to, fmt.Errorf("-to の日付が正しくありません: %s", toText)
+		}
+	}
+	if to.Before(from) {
+		return from, to, fmt.Errorf("-to には -from 以降の日付を指定してください")
+	}
+	return from, to, nil
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"shutdown-alert/internal/config"
+)
+
+// runValidateConfig は設定ファイルを読み込み、バリデーションエラーがあれば表示するサブコマンドを返します。
+// パスを省略した場合は、GUIの起動時と同じ探索で見つかったファイルを検証します。
+// この関数は純粋関数です。
+func runValidateConfig(configFlag string) func(args []string, stdout, stderr io.Writer) int {
+	return func(args []string, stdout, stderr io.Writer) int {
+		return validateConfig(configFlag, args, stdout, stderr)
+	}
+}
+
+// validateConfig は validate-config サブコマンドの本体です。
+// この関数は副作用（ファイルの読み取り、出力）を持ちます。
+func validateConfig(configFlag string, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "設定ファイルのパスは1つだけ指定してください")
+		return ExitUsage
+	}
+
+	// パスを指定した場合は、そのファイルだけを検証します（管理者の設定ファイルは locked_keys も検証します）。
+	if flags.NArg() == 1 {
+		path := flags.Arg(0)
+		var err error
+		if sameFile(path, config.PolicyConfigPath()) {
+			_, err = config.LoadLayeredConfig([]config.ConfigLayer{{Name: config.ConfigLayerPolicy, Path: path, Policy: true}})
+		} else {
+			_, err = config.LoadUserConfig(path)
+		}
+		return reportValidation(path, err, nil, stdout, stderr)
+	}
+
+	path := config.DiscoverConfig(configFlag).Path
+	layered, err := config.LoadConfig(path)
+	return reportValidation(path, err, layered.Ignored, stdout, stderr)
+}
+
+// reportValidation は設定ファイルの検証結果を出力し、終了コードを返します。
+// 問題はすべて、ファイル・行・列とともに1行に1つずつ出力します。
+// この関数は副作用（出力）を持ちます。
+func reportValidation(path string, err error, ignored []string, stdout, stderr io.Writer) int {
+	for _, key := range ignored {
+		fmt.Fprintf(stdout, "ロックされているため無視したキー: %s\n", key)
+	}
+
+	var problems config.ValidationErrors
+	switch {
+	case errors.As(err, &problems):
+		for _, problem := range problems {
+			fmt.Fprintln(stderr, problem.Error())
+		}
+		fmt.Fprintf(stderr, "%d 件の問題があります\n", len(problems))
+		return ExitError
+	case err != nil:
+		fmt.Fprintf(stderr, "%s: %v\n", path, err)
+		return ExitError
+	}
+
+	fmt.Fprintf(stdout, "%s: 問題はありません\n", path)
+	return ExitOK
+}
+
+// sameFile は2つのパスが同じファイルを指すかを返します（ファイルがない場合はパスを比較します）。
+// この関数は副作用（ファイルシステムへのアクセス）を持ちます。
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// runPrintDefaultConfig は組み込みのデフォルト設定を設定ファイルの形式で出力します。
+// この関数は副作用（出力）を持ちます。
+func runPrintDefaultConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		fmt.Fprintln(stderr, "print-default-config は引数を取りません")
+		return ExitUsage
+	}
+
+	data, err := config.DefaultConfigYAML()
+	if err != nil {
+		fmt.Fprintf(stderr, "デフォルト設定を出力できませんでした: %v\n", err)
+		return ExitError
+	}
+	_, _ = stdout.Write(data)
+	return ExitOK
+}
+
+// runConfig は設定ファイルに関する操作（config path・config origins・config schema・config migrate）のサブコマンドを返します。
+// この関数は純粋関数です。
+func runConfig(configFlag string) func(args []string, stdout, stderr io.Writer) int {
+	return func(args []string, stdout, stderr io.Writer) int {
+		if len(args) == 0 {
+			fmt.Fprintln(stderr, "使い方: config path|origins|schema|migrate [パス]")
+			return ExitUsage
+		}
+
+		location := config.DiscoverConfig(configFlag)
+		switch {
+		case args[0] == "path" && len(args) == 1:
+			printConfigLocation(stdout, location)
+			fmt.Fprintf(stdout, "\n%s: %s\n", config.ConfigLayerLabelPolicy, config.PolicyConfigPath())
+			return ExitOK
+		case args[0] == "origins" && len(args) == 1:
+			layered, err := config.LoadConfig(location.Path)
+			if err != nil {
+				fmt.Fprintf(stderr, "設定ファイルの読み込みに失敗しました: %v\n", err)
+			}
+			printConfigOrigins(stdout, layered)
+			return ExitOK
+		case args[0] == "schema" && len(args) == 1:
+			schema, err := config.ConfigSchema()
+			if err != nil {
+				fmt.Fprintf(stderr, "スキーマを出力できませんでした: %v\n", err)
+				return ExitError
+			}
+			_, _ = stdout.Write(append(schema, '\n'))
+			return ExitOK
+		case args[0] == "migrate" && len(args) <= 2:
+			path := location.Path
+			if len(args) == 2 {
+				path = args[1]
+			}
+			return migrateConfig(path, stdout, stderr)
+		}
+
+		fmt.Fprintln(stderr, "使い方: config path|origins|schema|migrate [パス]")
+		return ExitUsage
+	}
+}
+
+// migrateConfig は設定ファイルを最新の形式に書き換え、結果を出力します。
+// この関数は副作用（ファイルの読み書き、出力）を持ちます。
+func migrateConfig(path string, stdout, stderr io.Writer) int {
+	from, err := config.MigrateConfigFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", path, err)
+		return ExitError
+	}
+	if from == config.CurrentConfigVersion {
+		fmt.Fprintf(stdout, "%s: 最新の形式（%s %d）です\n", path, config.VersionKey, from)
+		return ExitOK
+	}
+	fmt.Fprintf(stdout, "%s: %s %d から %d に変換しました（元のファイル: %s）\n", path, config.VersionKey, from, config.CurrentConfigVersion, path+config.ConfigBackupSuffix)
+	return ExitOK
+}
+
+// printConfigOrigins は最上位のキーと、層ごとに指定されたキーの出どころ（どの層の値か）とロックの有無を出力します。
+// この関数は副作用（出力）を持ちます。
+func printConfigOrigins(w io.Writer, layered config.LayeredConfig) {
+	keys := config.ConfigKeys()
+	for key := range layered.Origins {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range layered.Locked {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		line := fmt.Sprintf("%s: %s", key, config.ConfigLayerLabel(layered.OriginOf(key)))
+		if layered.IsLocked(key) {
+			line += "（ロック）"
+		}
+		fmt.Fprintln(w, line)
+	}
+	for _, ignored := range layered.Ignored {
+		fmt.Fprintf(w, "ロックされているため無視したキー: %s\n", ignored)
+	}
+}
+
+// printConfigLocation は使用する設定ファイルと、優先度の高い順に探索した場所を出力します。
+// この関数は副作用（出力）を持ちます。
+func printConfigLocation(w io.Writer, location config.ConfigLocation) {
+	if location.Exists {
+		fmt.Fprintf(w, "%s（%s）\n", location.Path, config.ConfigSourceLabel(location.Source))
+	} else {
+		fmt.Fprintf(w, "%s（%s、ファイルがないためデフォルト値を使用）\n", location.Path, config.ConfigSourceLabel(location.Source))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "探索した場所（優先度の高い順）:")
+	for _, candidate := range location.Candidates {
+		mark := " "
+		if candidate.Exists {
+			mark = "*"
+		}
+		fmt.Fprintf(w, "  %s %s（%s）\n", mark, candidate.Path, config.ConfigSourceLabel(candidate.Source))
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/holiday"
+)
+
+// runHolidays は1年分の休日を1日1行で出力します。
+// カレンダーを省略した場合は組み込みの日本の祝日を、年を省略した場合は今年を表示します。
+// この関数は副作用（ファイルの読み取り、出力）を持ちます。
+func runHolidays(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("holidays", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	year := flags.Int("year", time.Now().Year(), "表示する年")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "カレンダーは1つだけ指定してください")
+		return ExitUsage
+	}
+
+	name := holiday.JapanCalendarName
+	if flags.NArg() == 1 {
+		name = flags.Arg(0)
+	}
+	calendar, ok := holiday.BuiltIn(name)
+	if !ok {
+		loaded, err := holiday.LoadFile(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "カレンダーを読み込めませんでした: %v\n", err)
+			return ExitError
+		}
+		calendar = loaded
+	}
+
+	for date := time.Date(*year, time.January, 1, 0, 0, 0, 0, time.Local); date.Year() == *year; date = date.AddDate(0, 0, 1) {
+		if holidayName, ok := calendar.Holiday(date); ok {
+			fmt.Fprintf(stdout, config.HolidayLineFormat, date.Format(holiday.DateFormat), config.WeekdayLabel(date.Weekday()), holidayName)
+		}
+	}
+	return ExitOK
+}
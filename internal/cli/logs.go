@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"shutdown-alert/internal/logger"
+)
+
+// runLogs はエラーログ（error_log.json）を表示（show）または削除（clear）します。
+// この関数は副作用（ファイルの読み取り・削除、出力）を持ちます。
+func runLogs(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "使い方: logs show|clear")
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "show":
+		entries, err := logger.ReadAll()
+		if err != nil {
+			fmt.Fprintf(stderr, "ログを読み込めませんでした: %v\n", err)
+			return ExitError
+		}
+		for _, entry := range entries {
+			fmt.Fprintln(stdout, logLine(entry))
+		}
+		return ExitOK
+
+	case "clear":
+		if err := logger.Clear(); err != nil {
+			fmt.Fprintf(stderr, "ログを削除できませんでした: %v\n", err)
+			return ExitError
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(stderr, "未知の操作です（show または clear を指定してください）: %s\n", args[0])
+	return ExitUsage
+}
+
+// logLine はログエントリ1件を1行の文字列にします。
+// この関数は純粋関数です。
+func logLine(entry logger.LogEntry) string {
+	line := fmt.Sprintf("%s [%s] %s: %s", entry.Timestamp, entry.Level, entry.Component, entry.Message)
+	if entry.Error != "" {
+		line += ": " + entry.Error
+	}
+	if len(entry.Context) > 0 {
+		if context, err := json.Marshal(entry.Context); err == nil {
+			line += " " + string(context)
+		}
+	}
+	return line
+}
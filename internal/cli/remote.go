@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"shutdown-alert/internal/ipc"
+)
+
+// remoteCommand は起動中のインスタンスにコマンドを転送するサブコマンドを返します。
+// argCount は受け取る引数の数です。
+// この関数は純粋関数です。
+func remoteCommand(name string, argCount int) func(args []string, stdout, stderr io.Writer) int {
+	return func(args []string, stdout, stderr io.Writer) int {
+		if len(args) != argCount {
+			fmt.Fprintf(stderr, "%s の引数は%d個です\n", name, argCount)
+			return ExitUsage
+		}
+		return runRemote(ipc.Request{Command: name, Args: args}, stdout, stderr)
+	}
+}
+
+// Forward は引数のないコマンドを起動中のインスタンスに送り、結果を表示して終了コードを返します。
+// 後から起動したインスタンスが、起動を依頼に置き換えるために使います。
+// この関数は副作用（通信、出力）を持ちます。
+func Forward(command string, stdout, stderr io.Writer) int {
+	return runRemote(ipc.Request{Command: command}, stdout, stderr)
+}
+
+// runRemote は起動中のインスタンスにコマンドを送り、結果を表示します。
+// この関数は副作用（通信、出力）を持ちます。
+func runRemote(request ipc.Request, stdout, stderr io.Writer) int {
+	response, err := ipc.Send(request)
+	if errors.Is(err, ipc.ErrNotRunning) {
+		fmt.Fprintln(stderr, ipc.ErrNotRunning)
+		return ExitError
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitError
+	}
+
+	if !response.OK {
+		fmt.Fprintln(stderr, response.Error)
+		return ExitError
+	}
+	fmt.Fprintln(stdout, response.Message)
+	return ExitOK
+}
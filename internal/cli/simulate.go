@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"shutdown-alert/internal/app"
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+// runSimulateShutdown はセッションを終了せずに、終了時と同じ確認ダイアログを表示するサブコマンドを返します。
+// この関数は純粋関数です。
+func runSimulateShutdown(configFlag string) func(args []string, stdout, stderr io.Writer) int {
+	return func(args []string, stdout, stderr io.Writer) int {
+		return simulateShutdown(configFlag, args, stdout, stderr)
+	}
+}
+
+// simulateShutdown は simulate-shutdown サブコマンドの本体です。
+// 終了理由を省略した場合はシャットダウンとして表示します。
+// この関数は副作用（ファイルの読み取り、UIの表示、URLの起動）を持ちます。
+func simulateShutdown(configFlag string, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("simulate-shutdown", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "設定ファイルのパス（省略時は探索で見つかったファイル）")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "終了理由は1つだけ指定してください")
+		return ExitUsage
+	}
+
+	kind := session.EndKindShutdown
+	if flags.NArg() == 1 {
+		parsed, ok := session.ParseEndKind(flags.Arg(0))
+		if !ok {
+			fmt.Fprintf(stderr, "未知の終了理由です: %s\n", flags.Arg(0))
+			return ExitUsage
+		}
+		kind = parsed
+	}
+
+	// GUIの起動時と同じく、読み込めない場合はデフォルト値で続行します。
+	if *configPath == "" {
+		*configPath = config.DiscoverConfig(configFlag).Path
+	}
+	layered, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "設定ファイルの読み込みに失敗しました（デフォルト値を使用）: %v\n", err)
+	}
+
+	// ルールでダイアログを表示しない場合は、実際のセッション終了と同じく何も表示しません。
+	trigger := config.TriggerForEndKind(kind)
+	now := time.Now()
+	if rule, index, ok := layered.Config.RuleFor(trigger, kind, now); ok && rule.Skip {
+		fmt.Fprintf(stdout, "ルール「%s」により確認ダイアログを表示しません\n", config.RuleLabel(rule, index))
+		return ExitOK
+	} else if !ok && !config.TriggerEnabledByDefault(trigger) {
+		fmt.Fprintf(stdout, "triggers に %s を指定したルールが一致しないため確認ダイアログを表示しません\n", trigger)
+		return ExitOK
+	}
+
+	if err := app.Simulate(layered.Config, kind); err != nil {
+		fmt.Fprintf(stderr, "確認ダイアログを表示できませんでした: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// runStartup はWindows以外では対応していないことを表示します。
+// Linux版の自動起動はsystemdのユーザーユニットなど、ディストリビューションの仕組みで設定します。
+// この関数は副作用（出力）を持ちます。
+func runStartup(args []string, stdout, stderr io.Writer) int {
+	fmt.Fprintln(stderr, "Windows以外ではスタートアップ登録に対応していません（systemdのユーザーユニットなどで設定してください）")
+	return ExitError
+}
@@ -0,0 +1,46 @@
+//go:build windows
+
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"shutdown-alert/internal/startup"
+)
+
+// runStartup はスタートアップ登録を行う（register）、解除する（unregister）、状態を表示する（status）のいずれかを実行します。
+// この関数は副作用（レジストリの読み書き、出力）を持ちます。
+func runStartup(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "使い方: startup register|unregister|status")
+		return ExitUsage
+	}
+
+	switch args[0] {
+	case "register":
+		if err := startup.Register(); err != nil {
+			fmt.Fprintf(stderr, "スタートアップ登録に失敗しました: %v\n", err)
+			return ExitError
+		}
+		return ExitOK
+
+	case "unregister":
+		if err := startup.Unregister(); err != nil {
+			fmt.Fprintf(stderr, "スタートアップ登録の解除に失敗しました: %v\n", err)
+			return ExitError
+		}
+		return ExitOK
+
+	case "status":
+		if startup.IsRegistered() {
+			fmt.Fprintln(stdout, "registered")
+		} else {
+			fmt.Fprintln(stdout, "unregistered")
+		}
+		return ExitOK
+	}
+
+	fmt.Fprintf(stderr, "未知の操作です（register・unregister・status のいずれかを指定してください）: %s\n", args[0])
+	return ExitUsage
+}
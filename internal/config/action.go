@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReminderAction はダイアログのボタン1つ分の設定を保持します。
+// 設定ファイルでは、組み込みのボタンは名前だけの文字列（open / snooze / close）、
+// 独自のボタンは label・key・url または command・default を持つマッピングで記述します。
+type ReminderAction struct {
+	// Name は組み込みのボタン名（ActionOpen / ActionSnooze / ActionClose）です。独自のボタンでは空です。
+	Name string `yaml:"-"`
+	// Label は独自のボタンの表示名です。
+	Label string `yaml:"label,omitempty"`
+	// Key は独自のボタンのアクセラレータキー（英数字1文字）です。
+	Key string `yaml:"key,omitempty"`
+	// URL は独自のボタンで開くURLです。Command とはどちらか一方のみ指定します。
+	URL string `yaml:"url,omitempty"`
+	// Command は独自のボタンで起動するコマンドと引数です。
+	Command []string `yaml:"command,omitempty"`
+	// Default が true のボタンはEnterキーで選ばれます。
+	Default bool `yaml:"default,omitempty"`
+}
+
+// BuiltInAction は組み込みのボタンを表す ReminderAction を返します。
+// この関数は純粋関数です。
+func BuiltInAction(name string) ReminderAction {
+	return ReminderAction{Name: name}
+}
+
+// IsBuiltIn は組み込みのボタンかどうかを返します。
+// この関数は純粋関数です。
+func (action ReminderAction) IsBuiltIn() bool {
+	return action.Name != ""
+}
+
+// ID はボタンを識別する名前を返します（組み込みはボタン名、独自のボタンは表示名）。
+// countdown.action ではこの名前でボタンを指定します。
+// この関数は純粋関数です。
+func (action ReminderAction) ID() string {
+	if action.IsBuiltIn() {
+		return action.Name
+	}
+	return action.Label
+}
+
+// Launches は選ばれたときにURLやコマンドを起動するボタンかどうかを返します。
+// この関数は純粋関数です。
+func (action ReminderAction) Launches() bool {
+	return !action.IsBuiltIn() || action.Name == ActionOpen
+}
+
+// OpensURL は選ばれたときにURLを開くボタン（組み込みの「開く」またはURLの独自のボタン）かどうかを返します。
+// この関数は純粋関数です。
+func (action ReminderAction) OpensURL() bool {
+	return action.Name == ActionOpen || action.URL != ""
+}
+
+// ActionDisplayName は ReminderAction.ID のボタンの表示名（アクセラレータなし）を返します。
+// 組み込みのボタンは「開く」などの表示名、独自のボタンは表示名（ID と同じ）です。
+// この関数は純粋関数です。
+func ActionDisplayName(id string) string {
+	switch id {
+	case ActionOpen:
+		return ActionNameOpen
+	case ActionSnooze:
+		return ActionNameSnooze
+	case ActionClose:
+		return ActionNameClose
+	}
+	return id
+}
+
+// UnmarshalYAML は文字列（組み込み）とマッピング（独自）の両方の記述を読み込みます。
+func (action *ReminderAction) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*action = BuiltInAction(node.Value)
+		return nil
+	}
+
+	// 同じ型のままDecodeすると再帰するため、メソッドを持たない型で読み込みます。
+	type plain ReminderAction
+	var custom plain
+	if err := node.Decode(&custom); err != nil {
+		return err
+	}
+	*action = ReminderAction(custom)
+	return nil
+}
+
+// MarshalYAML は組み込みのボタンを文字列、独自のボタンをマッピングとして書き出します。
+func (action ReminderAction) MarshalYAML() (interface{}, error) {
+	if action.IsBuiltIn() {
+		return action.Name, nil
+	}
+	type plain ReminderAction
+	return plain(action), nil
+}
+
+// validateAction はボタン1つ分の設定の妥当性を検証します。
+// この関数は純粋関数です。
+func validateAction(action ReminderAction) error {
+	if action.IsBuiltIn() {
+		if !isKnownAction(action.Name) {
+			return fmt.Errorf("未知のアクションです（%s・%s・%s のいずれかを指定してください）: %s", ActionOpen, ActionSnooze, ActionClose, action.Name)
+		}
+		return nil
+	}
+
+	if action.Label == "" {
+		return fmt.Errorf("label を指定してください")
+	}
+	if isKnownAction(action.Label) {
+		return fmt.Errorf("label に組み込みのアクション名は使用できません: %s", action.Label)
+	}
+	if action.Key != "" && !isAcceleratorKey(action.Key) {
+		return fmt.Errorf("%s: key には英数字1文字を指定してください: %s", action.Label, action.Key)
+	}
+
+	switch {
+	case action.URL != "" && len(action.Command) > 0:
+		return fmt.Errorf("%s: url と command はどちらか一方のみ指定してください", action.Label)
+	case action.URL != "":
+		if err := validateURL(action.URL); err != nil {
+			return fmt.Errorf("%s.url: %w", action.Label, err)
+		}
+	case len(action.Command) > 0:
+		if action.Command[0] == "" {
+			return fmt.Errorf("%s: command の1つ目にはプログラムを指定してください", action.Label)
+		}
+	default:
+		return fmt.Errorf("%s: url または command を指定してください", action.Label)
+	}
+
+	return nil
+}
+
+// isAcceleratorKey はアクセラレータキーとして使える英数字1文字かを返します。
+// この関数は純粋関数です。
+func isAcceleratorKey(key string) bool {
+	if utf8.RuneCountInString(key) != 1 {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(key)
+	return ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+
+	"shutdown-alert/internal/holiday"
+	"shutdown-alert/internal/session"
 )
 
 const (
@@ -19,14 +26,114 @@ const (
 	DialogWidth = 600
 	// 確認ダイアログの最小高さ
 	DialogHeight = 400
+	// MaxDialogSizeはdialog_width・dialog_heightに指定できる最大値です。
+	MaxDialogSize = 10000
 
 	// DialogMessageFormatはダイアログメッセージの書式文字列です。
+	// {{.URL}} などのテンプレート変数は表示時に展開されます（TemplateData を参照）。
 	DialogMessageFormat = `PCをシャットダウンしようとしています。
-	https://www.google.com を開きますか？`
+	{{.URL}} を開きますか？`
+
+	// DialogMessageRestartFormatは再起動時のダイアログメッセージです。
+	// dialog_message が指定されていない場合にのみ使用されます。
+	DialogMessageRestartFormat = `PCを再起動しようとしています。
+{{.URL}} を開きますか？`
+	// DialogMessageLogoffFormatはログオフ時のダイアログメッセージです。
+	// dialog_message が指定されていない場合にのみ使用されます。
+	DialogMessageLogoffFormat = `ログオフしようとしています。
+{{.URL}} を開きますか？`
+	// DialogMessageSleepFormatはスリープ時のダイアログメッセージです。
+	// dialog_message が指定されていない場合にのみ使用されます。
+	DialogMessageSleepFormat = `PCをスリープしようとしています。
+{{.URL}} を開きますか？`
+	// DialogMessageLockFormatはロック時のダイアログメッセージです。
+	// ロックした後に表示されるため、ロックを解除したときに目にします。
+	// dialog_message が指定されていない場合にのみ使用されます。
+	DialogMessageLockFormat = `PCをロックしました。
+{{.URL}} を開きますか？`
+	// DialogMessageCloseAppFormatはインストーラーやWindows Updateがアプリケーションの終了を求めたときのダイアログメッセージです。
+	// dialog_message が指定されていない場合にのみ使用されます。
+	DialogMessageCloseAppFormat = `更新のためにPCを再起動しようとしています。
+{{.URL}} を開きますか？`
+
+	// CountdownSecondsは確認ダイアログが自動で応答するまでの秒数です（0は無効）。
+	CountdownSeconds = 0
+	// CountdownActionはカウントダウンが0になったときに選ぶアクションです。
+	CountdownAction = ActionClose
+	// MaxCountdownSecondsはcountdown.secondsに指定できる最大値です。
+	MaxCountdownSeconds = 3600
+
+	// SnoozeMinutesは「後で」を選んでからリマインダーを再表示するまでの分数です。
+	SnoozeMinutes = 10
+	// MaxSnoozeMinutesはsnooze_minutesに指定できる最大値です（1日）。
+	MaxSnoozeMinutes = 1440
+
+	// Lifecycleはダイアログへの応答後のアプリケーションの振る舞いです。
+	Lifecycle = LifecycleExit
+
+	// ResumeSessionはダイアログへの応答後に中断したセッション終了を再開するかどうかです。
+	ResumeSession = false
+	// ResumeGraceSecondsはURLを開いてからセッション終了を再開するまでの猶予（秒）です。
+	ResumeGraceSeconds = 10
+	// MaxResumeGraceSecondsはresume_grace_secondsに指定できる最大値です。
+	MaxResumeGraceSeconds = 600
+
+	// MaxScheduleAfterMinutesはschedulesのafter_minutesに指定できる最大値です（1日）。
+	MaxScheduleAfterMinutes = 1440
 
 	// ---上書き不可能な設定---
+	// CurrentConfigVersionは設定ファイルの形式の現在のバージョンです（version キー）。
+	// 形式を変更するときは1つ上げ、migrate.go の configMigrations に変換を追加します。
+	CurrentConfigVersion = 1
+	// VersionKeyは設定ファイルの形式のバージョンを指定するキーです。
+	VersionKey = "version"
+	// ConfigBackupSuffixは config migrate で書き換える前のファイルに付ける接尾辞です。
+	ConfigBackupSuffix = ".bak"
+
 	//確認ダイアログのタイトル
 	DialogTitle = "Shutdown Alert"
+	// 確認ダイアログのタイトルに付ける終了理由の表示名
+	EndKindLabelShutdown = "シャットダウン"
+	EndKindLabelRestart  = "再起動"
+	EndKindLabelLogoff   = "ログオフ"
+	EndKindLabelSleep    = "スリープ"
+	EndKindLabelLock     = "ロック"
+	EndKindLabelCloseApp = "更新による再起動"
+
+	// TemplateDateFormatはテンプレート変数 {{.Date}} の書式です。
+	TemplateDateFormat = "2006-01-02"
+	// TemplateTimeFormatはテンプレート変数 {{.SessionStart}} の書式です。
+	TemplateTimeFormat = "15:04"
+	// ElapsedFormatは経過時間（時間・分）の書式です。
+	ElapsedFormat = "%dh%02dm"
+
+	// LifecycleExitはダイアログへの応答後にアプリケーションを終了するモードです。
+	LifecycleExit = "exit"
+	// LifecycleResidentはセッションが実際に終了するまで常駐を続けるモードです。
+	LifecycleResident = "resident"
+
+	// ActionOpenは「開く」ボタンを表すアクション名です。
+	ActionOpen = "open"
+	// ActionCloseは「閉じる」ボタンを表すアクション名です。
+	ActionClose = "close"
+	// ActionSnoozeは「後で」ボタンを表すアクション名です。
+	ActionSnooze = "snooze"
+
+	// TriggerSessionEndはセッション終了（シャットダウン・再起動・ログオフ）の問い合わせによる表示です。
+	TriggerSessionEnd = "session_end"
+	// TriggerScheduleは schedules の時刻による表示です。
+	TriggerSchedule = "schedule"
+	// TriggerLockは画面のロックによる表示です（Windowsのみ）。
+	TriggerLock = "lock"
+	// TriggerSuspendはスリープ・休止状態による表示です。
+	TriggerSuspend = "suspend"
+
+	// DoneTodaySkipは done_today のボタンを選んだ日に、それ以降の確認ダイアログを表示しない動作です。
+	DoneTodaySkip = "skip"
+	// DoneTodayToastは done_today のボタンを選んだ日に、それ以降は確認ダイアログの代わりに通知だけを表示する動作です。
+	DoneTodayToast = "toast"
+	// DoneTodayToastFormatは done_today により確認ダイアログの代わりに表示する通知の書式です（ボタンの表示名）。
+	DoneTodayToastFormat = "今日は「%s」を済ませています。"
 
 	// ShutdownBlockMessageはシャットダウン画面に表示されるメッセージです。
 	ShutdownBlockMessage = "確認ダイアログに応答してください"
@@ -35,6 +142,27 @@ const (
 	OpenButtonLabel = "開く(&O)"
 	// ExitButtonLabelは終了ボタンのラベルです。
 	ExitButtonLabel = "閉じる(&E)"
+	// SnoozeButtonLabelは「後で」ボタンのラベルです。
+	SnoozeButtonLabel = "後で(&L)"
+
+	// CountdownMessageFormatはカウントダウン中にメッセージの下へ表示する書式文字列です。
+	// 残り秒数と自動で選ばれるアクションの表示名が入ります。
+	CountdownMessageFormat = "あと %d 秒で「%s」を自動で選択します。"
+	// ChecklistTitleはダイアログのチェックリストの見出しです。
+	ChecklistTitle = "チェックリスト"
+	// ChecklistRequiredSuffixは必須の項目の表示名に付ける印です。
+	ChecklistRequiredSuffix = "（必須）"
+	// ChecklistCheckColumnはLinux版のチェックリストのチェック列の見出しです。
+	ChecklistCheckColumn = "完了"
+	// ChecklistItemColumnはLinux版のチェックリストの項目列の見出しです。
+	ChecklistItemColumn = "項目"
+
+	// ActionNameOpenは「開く」アクションの表示名です。
+	ActionNameOpen = "開く"
+	// ActionNameCloseは「閉じる」アクションの表示名です。
+	ActionNameClose = "閉じる"
+	// ActionNameSnoozeは「後で」アクションの表示名です。
+	ActionNameSnooze = "後で"
 
 	// TrayIconTooltipはトレイアイコンのツールチップテキストです。
 	TrayIconTooltip = "Shutdown Alertが動作しています."
@@ -44,6 +172,37 @@ const (
 	// TrayMenuStartupはスタートアップ登録メニュー項目のラベルです。
 	// &文字はキーボードアクセラレータ（Alt+S）を示します。
 	TrayMenuStartup = "スタートアップに登録(&S)"
+	// TrayMenuSnoozeFormatは「後で」による再表示を取り消すメニュー項目の書式文字列です。
+	// 再表示する時刻（15:04形式）が入ります。再表示の予定がない間は非表示です。
+	TrayMenuSnoozeFormat = "%s の再通知を取り消す(&L)"
+	// TrayWorkTimeFormatは今日の勤務時間をトレイに表示する書式文字列です（経過時間・開始時刻）。
+	TrayWorkTimeFormat = "今日: %s（%s から）"
+	// TrayWorkTimeNoneはまだ今日の記録がないときのトレイの表示です。
+	TrayWorkTimeNone = "今日: 記録なし"
+	// WorkTimeRefreshSecondsはトレイの勤務時間の表示を更新する間隔（秒）です。
+	WorkTimeRefreshSeconds = 60
+	// WorkTimeDaysはworktimeサブコマンドで表示する既定の日数です。
+	WorkTimeDays = 7
+	// WorkTimeLineFormatはworktimeサブコマンドの1日分の出力の書式文字列です（日付・曜日・開始・終了・経過時間）。
+	WorkTimeLineFormat = "%s（%s） %s - %s  %s\n"
+	// HolidayLineFormatはholidaysサブコマンドの1日分の出力の書式文字列です（日付・曜日・休日の名前）。
+	HolidayLineFormat = "%s（%s） %s\n"
+
+	// ScheduleCheckSecondsはschedulesの時刻を迎えたかを確認する間隔（秒）です。
+	ScheduleCheckSeconds = 30
+
+	// TrayMenuReloadは設定ファイルの再読み込みメニュー項目のラベルです。
+	// &文字はキーボードアクセラレータ（Alt+R）を示します。
+	TrayMenuReload = "設定を再読み込み(&R)"
+	// TrayConfigPathFormatは使用している設定ファイルのパスをトレイに表示する書式文字列です。
+	TrayConfigPathFormat = "設定ファイル: %s"
+	// ConfigReloadedMessageは設定ファイルを再読み込みしたときの通知メッセージです。
+	ConfigReloadedMessage = "設定ファイルを再読み込みしました。"
+	// ConfigReloadErrorMessageFormatは再読み込みに失敗したときのメッセージの書式文字列です。
+	ConfigReloadErrorMessageFormat = "設定ファイルを再読み込みできませんでした（以前の設定を使用します）:\n%v"
+	// ConfigWatchIntervalSecondsは設定ファイルの変更を確認する間隔（秒）です。
+	ConfigWatchIntervalSeconds = 2
+
 	// TrayMenuExitは終了メニュー項目のラベルです。
 	// &文字はキーボードアクセラレータ（Alt+E）を示します。
 	TrayMenuExit = "&終了(&E)"
@@ -58,9 +217,45 @@ const (
 	// RegistryValueNameはスタートアップ登録に使用するレジストリ値の名前です。
 	RegistryValueName = "ShutdownAlert"
 
+	// ConfigFileNameは設定ファイルの名前です。
+	ConfigFileName = "config.yaml"
+	// ConfigEnvVarは設定ファイルのパスを指定する環境変数の名前です。
+	ConfigEnvVar = "SHUTDOWN_ALERT_CONFIG"
+	// UserConfigDirNameWindowsは %APPDATA% 内の設定ディレクトリの名前です。
+	UserConfigDirNameWindows = "ShutdownAlert"
+	// UserConfigDirNameは XDG_CONFIG_HOME 内の設定ディレクトリの名前です。
+	UserConfigDirName = "shutdown-alert"
+
+	// PolicyFileNameは管理者の設定ファイルの名前です。
+	PolicyFileName = "policy.yaml"
+	// PolicyConfigDirWindowsは環境変数 ProgramData がない場合に使う、管理者の設定ディレクトリの親です。
+	PolicyConfigDirWindows = `C:\ProgramData`
+	// PolicyConfigDirはWindows以外での管理者の設定ディレクトリの親です。
+	PolicyConfigDir = "/etc"
+	// LockedKeysKeyは管理者の設定ファイルでロックするキーを指定するキーです。
+	LockedKeysKey = "locked_keys"
+
+	// 設定の層の表示名
+	ConfigLayerLabelDefault = "デフォルト値"
+	ConfigLayerLabelPolicy  = "管理者の設定"
+	ConfigLayerLabelUser    = "ユーザー設定"
+
+	// 設定ファイルの探索場所の表示名
+	ConfigSourceLabelFlag    = "--config オプション"
+	ConfigSourceLabelEnv     = "環境変数 " + ConfigEnvVar
+	ConfigSourceLabelUserDir = "ユーザー設定ディレクトリ"
+	ConfigSourceLabelExeDir  = "実行ファイルと同じディレクトリ"
+	ConfigSourceLabelWorkDir = "カレントディレクトリ"
+
 	// LogFileNameはエラーログファイルの名前です。
 	LogFileName = "error_log.json"
 
+	// HistoryFileNameは履歴（チェックリストの記録など）を追記するファイルの名前です。
+	HistoryFileName = "history.jsonl"
+
+	// DoneTodayFileNameは今日選んだ done_today のボタンを保存するファイルの名前です。
+	DoneTodayFileName = "done_today.json"
+
 	// MaxLogEntriesはログファイルに保持する最大エントリ数です。
 	MaxLogEntries = 100
 
@@ -81,73 +276,554 @@ const (
 
 // UserConfig はユーザーが設定ファイルで指定可能な設定を保持します。
 type UserConfig struct {
+	// Version は設定ファイルの形式のバージョンです。読み込み時に CurrentConfigVersion の形式へ変換済みです。
+	Version       int    `yaml:"version"`
 	TargetURL     string `yaml:"target_url"`
 	DialogWidth   int    `yaml:"dialog_width"`
 	DialogHeight  int    `yaml:"dialog_height"`
 	DialogMessage string `yaml:"dialog_message"`
+	// Actions はダイアログに表示する既定のボタン構成です（end_kinds で上書きできます）。
+	Actions []ReminderAction `yaml:"actions"`
+	// Checklist はダイアログに表示する既定のチェックリストです（end_kinds で上書きできます）。
+	Checklist []ChecklistItem `yaml:"checklist,omitempty"`
+	// SnoozeMinutes は「後で」を選んでからリマインダーを再表示するまでの分数です。
+	SnoozeMinutes int `yaml:"snooze_minutes"`
+	// Countdown は確認ダイアログの自動応答の設定です。
+	Countdown Countdown `yaml:"countdown"`
+	// Lifecycle は応答後にアプリケーションを終了するか（LifecycleExit）、常駐を続けるか（LifecycleResident）です。
+	Lifecycle string `yaml:"lifecycle"`
+	// ResumeSession が true の場合、応答後に中断したシャットダウン・再起動・ログオフを再開します。
+	ResumeSession bool `yaml:"resume_session"`
+	// ResumeGraceSeconds はURLを開いてから再開するまでの猶予（秒）です。
+	ResumeGraceSeconds int `yaml:"resume_grace_seconds"`
+	// EndKinds は終了理由（session.EndKind の名前）ごとのリマインダーです。
+	// 読み込み時に省略された項目はトップレベルの値で補完済みです。
+	EndKinds map[string]Reminder `yaml:"end_kinds,omitempty"`
+	// Rules は時刻・曜日・休日などの条件でリマインダーを切り替えるルールです（上から順に評価します）。
+	Rules []Rule `yaml:"rules,omitempty"`
+	// Calendars は rules の when.holidays に指定したカレンダーです（設定ファイルの読み込み時に読み込みます）。
+	Calendars map[string]holiday.Calendar `yaml:"-"`
+	// Schedules はセッション終了を待たずにリマインダーを表示する時刻です。
+	Schedules []Schedule `yaml:"schedules,omitempty"`
+	// DoneToday はボタン（ReminderAction.ID）ごとに、選んだ日のそれ以降の表示を抑える動作（DoneTodaySkip / DoneTodayToast）です。
+	DoneToday map[string]string `yaml:"done_today,omitempty"`
 }
 
-// LoadUserConfig は設定ファイルを読み込み、デフォルト値とマージした設定を返します。
-// この関数は副作用（ファイル読み込み）を持ちます。
-func LoadUserConfig(configPath string) (UserConfig, error) {
-	// デフォルト値で初期化
+// Reminder は終了理由ごとのリマインダー内容を保持します。
+type Reminder struct {
+	TargetURL     string `yaml:"target_url"`
+	DialogMessage string `yaml:"dialog_message"`
+	// Actions はダイアログに表示するボタン（組み込みまたは独自）を表示順に並べたものです。
+	Actions []ReminderAction `yaml:"actions"`
+	// Checklist はダイアログに表示するチェックリストです。空の場合は表示しません。
+	Checklist []ChecklistItem `yaml:"checklist,omitempty"`
+}
+
+// ChecklistItem はチェックリストの項目を保持します。
+type ChecklistItem struct {
+	Label string `yaml:"label"`
+	// Required が true の項目にチェックを入れるまで、「後で」以外のボタンは押せません。
+	Required bool `yaml:"required"`
+}
+
+// Countdown は確認ダイアログの自動応答の設定を保持します。
+type Countdown struct {
+	// Seconds は自動で応答するまでの秒数です。0 の場合はカウントダウンしません。
+	Seconds int `yaml:"seconds"`
+	// Action は0秒になったときに選ぶボタンの ReminderAction.ID（組み込みの名前または独自のボタンの表示名）です。
+	Action string `yaml:"action"`
+}
+
+// countdownOverride は countdown のYAML表現です。
+// ポインタ型を使用してフィールドの存在を判定します。
+type countdownOverride struct {
+	Seconds *int    `yaml:"seconds,omitempty"`
+	Action  *string `yaml:"action,omitempty"`
+}
+
+// reminderOverride は end_kinds の各項目のYAML表現です。
+// ポインタ型を使用してフィールドの存在を判定します。
+type reminderOverride struct {
+	TargetURL     *string          `yaml:"target_url,omitempty"`
+	DialogMessage *string          `yaml:"dialog_message,omitempty"`
+	Actions       []ReminderAction `yaml:"actions,omitempty"`
+	Checklist     []ChecklistItem  `yaml:"checklist,omitempty"`
+}
+
+// ReminderFor は終了理由に対応するリマインダーを返します。
+// end_kinds に指定がない場合はトップレベルの設定から組み立てます。
+// この関数は純粋関数です。
+func (userConfig UserConfig) ReminderFor(kind session.EndKind) Reminder {
+	if reminder, ok := userConfig.EndKinds[kind.String()]; ok {
+		return reminder
+	}
+
+	return Reminder{
+		TargetURL:     userConfig.TargetURL,
+		DialogMessage: userConfig.DialogMessage,
+		Actions:       userConfig.Actions,
+		Checklist:     userConfig.Checklist,
+	}
+}
+
+// DefaultUserConfig は設定ファイルがない場合に使用する組み込みのデフォルト設定を返します。
+// この関数は純粋関数です。
+func DefaultUserConfig() UserConfig {
 	config := UserConfig{
-		TargetURL:     TargetURL,
-		DialogWidth:   DialogWidth,
-		DialogHeight:  DialogHeight,
-		DialogMessage: DialogMessageFormat,
+		Version:            CurrentConfigVersion,
+		TargetURL:          TargetURL,
+		DialogWidth:        DialogWidth,
+		DialogHeight:       DialogHeight,
+		DialogMessage:      DialogMessageFormat,
+		Actions:            defaultActions(),
+		SnoozeMinutes:      SnoozeMinutes,
+		Countdown:          Countdown{Seconds: CountdownSeconds, Action: CountdownAction},
+		Lifecycle:          Lifecycle,
+		ResumeSession:      ResumeSession,
+		ResumeGraceSeconds: ResumeGraceSeconds,
 	}
+	// 組み込みの値だけで組み立てるため、問題は起きません。
+	config.EndKinds = resolveReminders(&validator{}, config, defaultDialogMessages(), nil)
+	return config
+}
+
+// DefaultConfigYAML は組み込みのデフォルト設定を設定ファイルの形式（YAML）で返します。
+// end_kinds には終了理由ごとの組み込みメッセージだけを書き出します（その他の項目はトップレベルの値と同じため）。
+// この関数は純粋関数です。
+func DefaultConfigYAML() ([]byte, error) {
+	defaults := DefaultUserConfig()
+	overrides := map[string]reminderOverride{}
+	for name, message := range defaultDialogMessages() {
+		overrides[name] = reminderOverride{DialogMessage: &message}
+	}
+	defaults.EndKinds = nil
+
+	// 1つのEncoderで続けて書き出すと別の文書（---区切り）になるため、1つずつ書き出して連結します。
+	var buf bytes.Buffer
+	for _, value := range []interface{}{defaults, map[string]map[string]reminderOverride{"end_kinds": overrides}} {
+		// config.yaml.example と同じく2文字で字下げします。
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// userConfigOverride は設定ファイルのYAML表現です。
+// ポインタ型を使用してフィールドの存在を判定します。
+type userConfigOverride struct {
+	// Version は読み込み時の変換（MigrateConfig）にのみ使用します。
+	Version       *int                        `yaml:"version,omitempty"`
+	TargetURL     *string                     `yaml:"target_url,omitempty"`
+	DialogWidth   *int                        `yaml:"dialog_width,omitempty"`
+	DialogHeight  *int                        `yaml:"dialog_height,omitempty"`
+	DialogMessage *string                     `yaml:"dialog_message,omitempty"`
+	Actions       []ReminderAction            `yaml:"actions,omitempty"`
+	Checklist     []ChecklistItem             `yaml:"checklist,omitempty"`
+	SnoozeMinutes *int                        `yaml:"snooze_minutes,omitempty"`
+	Countdown     *countdownOverride          `yaml:"countdown,omitempty"`
+	Lifecycle     *string                     `yaml:"lifecycle,omitempty"`
+	ResumeSession *bool                       `yaml:"resume_session,omitempty"`
+	ResumeGrace   *int                        `yaml:"resume_grace_seconds,omitempty"`
+	EndKinds      map[string]reminderOverride `yaml:"end_kinds,omitempty"`
+	Rules         []Rule                      `yaml:"rules,omitempty"`
+	Schedules     []Schedule                  `yaml:"schedules,omitempty"`
+	DoneToday     map[string]string           `yaml:"done_today,omitempty"`
+}
 
+// LoadUserConfig は設定ファイルを読み込み、デフォルト値とマージした設定を返します。
+// 管理者の設定（LoadConfig を参照）は含みません。
+// この関数は副作用（ファイル読み込み）を持ちます。
+func LoadUserConfig(configPath string) (UserConfig, error) {
 	// ファイルが存在すれば読み込んで上書き
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		// ファイルが存在しない場合もエラーとして返す
-		return config, err
+		return DefaultUserConfig(), err
+	}
+
+	inFile := func(string) string { return configPath }
+	root, err := parseConfigDocument(data)
+	if err != nil {
+		return DefaultUserConfig(), withFile(err, inFile)
 	}
+	config, err := resolveUserConfig(root)
+	err = joinErrors(err, loadCalendars(&config, filepath.Dir(configPath)))
+	return config, withFile(err, inFile)
+}
 
-	// YAMLをパース（ポインタ型を使用してフィールドの存在を判定）
-	var userConfig struct {
-		TargetURL     *string `yaml:"target_url,omitempty"`
-		DialogWidth   *int    `yaml:"dialog_width,omitempty"`
-		DialogHeight  *int    `yaml:"dialog_height,omitempty"`
-		DialogMessage *string `yaml:"dialog_message,omitempty"`
+// parseConfigDocument は設定ファイルの内容をパースし、最新の形式に変換した最上位のマッピングを返します。
+// 空のファイル（コメントのみを含む）の場合は nil を返します。
+// この関数は純粋関数です。
+func parseConfigDocument(data []byte) (*yaml.Node, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, syntaxError(err)
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, nil
 	}
 
-	if err := yaml.Unmarshal(data, &userConfig); err != nil {
-		return config, err
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, ValidationErrors{{Line: root.Line, Column: root.Column, Message: "設定ファイルの最上位は「キー: 値」の形式で記述してください"}}
 	}
 
+	// 古い形式のファイルは、書き換えずに読み込み時だけ変換します（書き換えは config migrate で行います）。
+	root, _, err := MigrateConfig(root)
+	return root, err
+}
+
+// resolveUserConfig は設定ファイルの最上位のマッピングをデフォルト値とマージした設定を返します。
+// 最初の問題で止まらずにすべての問題を ValidationErrors として返し、問題のある項目はデフォルト値のままにします。
+// root が nil の場合はデフォルト値を返します。
+// この関数は純粋関数です。
+func resolveUserConfig(root *yaml.Node) (UserConfig, error) {
+	// デフォルト値で初期化
+	config := DefaultUserConfig()
+	if root == nil {
+		return config, nil
+	}
+
+	// YAMLをデコード（ポインタ型を使用してフィールドの存在を判定）
+	v := &validator{root: root}
+	var userConfig userConfigOverride
+	v.decodeMapping(root, "", &userConfig)
+	checkNestedKeys(v, root)
+
 	// 設定値が指定されていれば上書き（nilチェックでフィールドの存在を判定）
 	if userConfig.TargetURL != nil {
 		// URLのバリデーション
-		if err := validateURL(*userConfig.TargetURL); err != nil {
-			return config, fmt.Errorf("target_url のバリデーションエラー: %w", err)
+		if err := validateTargetURL(*userConfig.TargetURL); err != nil {
+			v.add("target_url", err)
+		} else {
+			config.TargetURL = *userConfig.TargetURL
 		}
-		config.TargetURL = *userConfig.TargetURL
 	}
 	if userConfig.DialogWidth != nil {
 		// ダイアログ幅のバリデーション
-		if *userConfig.DialogWidth < 0 || *userConfig.DialogWidth > 10000 {
-			return config, fmt.Errorf("dialog_width は 0 から 10000 の範囲で指定してください: %d", *userConfig.DialogWidth)
+		if *userConfig.DialogWidth < 0 || *userConfig.DialogWidth > MaxDialogSize {
+			v.add("dialog_width", fmt.Errorf("0 から %d の範囲で指定してください: %d", MaxDialogSize, *userConfig.DialogWidth))
+		} else {
+			config.DialogWidth = *userConfig.DialogWidth
 		}
-		config.DialogWidth = *userConfig.DialogWidth
 	}
 	if userConfig.DialogHeight != nil {
 		// ダイアログ高さのバリデーション
-		if *userConfig.DialogHeight < 0 || *userConfig.DialogHeight > 10000 {
-			return config, fmt.Errorf("dialog_height は 0 から 10000 の範囲で指定してください: %d", *userConfig.DialogHeight)
+		if *userConfig.DialogHeight < 0 || *userConfig.DialogHeight > MaxDialogSize {
+			v.add("dialog_height", fmt.Errorf("0 から %d の範囲で指定してください: %d", MaxDialogSize, *userConfig.DialogHeight))
+		} else {
+			config.DialogHeight = *userConfig.DialogHeight
 		}
-		config.DialogHeight = *userConfig.DialogHeight
 	}
 	if userConfig.DialogMessage != nil {
 		// メッセージのバリデーション
 		if err := validateDialogMessage(*userConfig.DialogMessage); err != nil {
-			return config, fmt.Errorf("dialog_message のバリデーションエラー: %w", err)
+			v.add("dialog_message", err)
+		} else {
+			config.DialogMessage = *userConfig.DialogMessage
+		}
+	}
+	if userConfig.Actions != nil {
+		if err := validateActions(userConfig.Actions); err != nil {
+			v.add("actions", err)
+		} else {
+			config.Actions = userConfig.Actions
+		}
+	}
+	if userConfig.Checklist != nil {
+		if err := validateChecklist(userConfig.Checklist); err != nil {
+			v.add("checklist", err)
+		} else {
+			config.Checklist = userConfig.Checklist
+		}
+	}
+	if userConfig.SnoozeMinutes != nil {
+		// 再表示までの分数のバリデーション
+		if *userConfig.SnoozeMinutes < 1 || *userConfig.SnoozeMinutes > MaxSnoozeMinutes {
+			v.add("snooze_minutes", fmt.Errorf("1 から %d の範囲で指定してください: %d", MaxSnoozeMinutes, *userConfig.SnoozeMinutes))
+		} else {
+			config.SnoozeMinutes = *userConfig.SnoozeMinutes
+		}
+	}
+	if userConfig.Countdown != nil {
+		config.Countdown = resolveCountdown(v, config.Countdown, *userConfig.Countdown)
+	}
+	if userConfig.Lifecycle != nil {
+		// ライフサイクルのバリデーション
+		if *userConfig.Lifecycle != LifecycleExit && *userConfig.Lifecycle != LifecycleResident {
+			v.add("lifecycle", fmt.Errorf("%s または %s を指定してください: %s", LifecycleExit, LifecycleResident, *userConfig.Lifecycle))
+		} else {
+			config.Lifecycle = *userConfig.Lifecycle
+		}
+	}
+	if userConfig.ResumeSession != nil {
+		config.ResumeSession = *userConfig.ResumeSession
+	}
+	if userConfig.ResumeGrace != nil {
+		// 猶予時間のバリデーション
+		if *userConfig.ResumeGrace < 0 || *userConfig.ResumeGrace > MaxResumeGraceSeconds {
+			v.add("resume_grace_seconds", fmt.Errorf("0 から %d の範囲で指定してください: %d", MaxResumeGraceSeconds, *userConfig.ResumeGrace))
+		} else {
+			config.ResumeGraceSeconds = *userConfig.ResumeGrace
 		}
-		config.DialogMessage = *userConfig.DialogMessage
 	}
 
-	return config, nil
+	// 終了理由ごとのリマインダーを組み立て
+	// dialog_message が指定されている場合は、組み込みの理由別メッセージより優先します。
+	builtInMessages := defaultDialogMessages()
+	if userConfig.DialogMessage != nil {
+		builtInMessages = map[string]string{}
+	}
+	config.EndKinds = resolveReminders(v, config, builtInMessages, userConfig.EndKinds)
+	if userConfig.Rules != nil {
+		config.Rules = resolveRules(v, userConfig.Rules)
+	}
+	if userConfig.Schedules != nil {
+		config.Schedules = resolveSchedules(v, userConfig.Schedules)
+	}
+
+	if err := validateCountdownAction(config); err != nil {
+		v.add("countdown.action", err)
+	}
+	if userConfig.DoneToday != nil {
+		config.DoneToday = resolveDoneToday(v, config, userConfig.DoneToday)
+	}
+
+	return config, v.err()
+}
+
+// checkNestedKeys はマッピングの中（countdown・end_kinds・rules・schedules・actions・checklist）の未知のキーを記録します。
+// この関数は副作用（v の変更）を持ちます。
+func checkNestedKeys(v *validator, root *yaml.Node) {
+	actionKeys := yamlKeys(reflect.TypeOf(ReminderAction{}))
+	checklistKeys := yamlKeys(reflect.TypeOf(ChecklistItem{}))
+	checkItems := func(list *yaml.Node, key string, known []string) {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			return
+		}
+		for i, item := range list.Content {
+			v.checkKeys(item, fmt.Sprintf("%s[%d].", key, i), known)
+		}
+	}
+
+	v.checkKeys(mappingValue(root, "countdown"), "countdown.", yamlKeys(reflect.TypeOf(countdownOverride{})))
+	checkItems(mappingValue(root, "actions"), "actions", actionKeys)
+	checkItems(mappingValue(root, "checklist"), "checklist", checklistKeys)
+	checkRuleKeys(v, mappingValue(root, "rules"), checkItems)
+	checkScheduleKeys(v, mappingValue(root, "schedules"))
+
+	endKinds := mappingValue(root, "end_kinds")
+	if endKinds == nil || endKinds.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(endKinds.Content); i += 2 {
+		name, reminder := endKinds.Content[i].Value, endKinds.Content[i+1]
+		prefix := "end_kinds." + name
+		v.checkKeys(reminder, prefix+".", yamlKeys(reflect.TypeOf(reminderOverride{})))
+		checkItems(mappingValue(reminder, "actions"), prefix+".actions", actionKeys)
+		checkItems(mappingValue(reminder, "checklist"), prefix+".checklist", checklistKeys)
+	}
+}
+
+// defaultActions はダイアログに表示する既定のボタン構成を返します。
+// この関数は純粋関数です。
+func defaultActions() []ReminderAction {
+	return []ReminderAction{BuiltInAction(ActionOpen), BuiltInAction(ActionClose)}
+}
+
+// defaultDialogMessages は終了理由ごとの組み込みメッセージを返します。
+// シャットダウンはトップレベルの dialog_message を使用するため含みません。
+// この関数は純粋関数です。
+func defaultDialogMessages() map[string]string {
+	return map[string]string{
+		session.EndKindRestart.String():  DialogMessageRestartFormat,
+		session.EndKindLogoff.String():   DialogMessageLogoffFormat,
+		session.EndKindSleep.String():    DialogMessageSleepFormat,
+		session.EndKindLock.String():     DialogMessageLockFormat,
+		session.EndKindCloseApp.String(): DialogMessageCloseAppFormat,
+	}
+}
+
+// resolveReminders は終了理由ごとのリマインダーを、
+// 組み込みメッセージ・end_kinds の指定・トップレベルの値の順に優先して組み立てます。
+// 組み込みメッセージも end_kinds の指定もない終了理由は結果に含めません（トップレベルの値を使用）。
+// 問題のある項目は v に記録し、その項目だけ指定がないものとして組み立てます。
+// この関数は副作用（v の変更）を持ちます。
+func resolveReminders(v *validator, base UserConfig, builtInMessages map[string]string, overrides map[string]reminderOverride) map[string]Reminder {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := session.ParseEndKind(name); !ok {
+			v.add("end_kinds."+name, fmt.Errorf("未知の終了理由です: %s", name))
+		}
+	}
+
+	// base の EndKinds は補完元として使わない（トップレベルの値のみを使う）
+	base.EndKinds = nil
+
+	reminders := map[string]Reminder{}
+	for _, kind := range session.EndKinds() {
+		name := kind.String()
+		override, hasOverride := overrides[name]
+		builtInMessage, hasBuiltInMessage := builtInMessages[name]
+		if !hasOverride && !hasBuiltInMessage {
+			continue
+		}
+
+		reminder := base.ReminderFor(kind)
+		if hasBuiltInMessage {
+			reminder.DialogMessage = builtInMessage
+		}
+
+		prefix := "end_kinds." + name + "."
+		if override.TargetURL != nil {
+			if err := validateTargetURL(*override.TargetURL); err != nil {
+				v.add(prefix+"target_url", err)
+			} else {
+				reminder.TargetURL = *override.TargetURL
+			}
+		}
+		if override.DialogMessage != nil {
+			if err := validateDialogMessage(*override.DialogMessage); err != nil {
+				v.add(prefix+"dialog_message", err)
+			} else {
+				reminder.DialogMessage = *override.DialogMessage
+			}
+		}
+		if override.Actions != nil {
+			if err := validateActions(override.Actions); err != nil {
+				v.add(prefix+"actions", err)
+			} else {
+				reminder.Actions = override.Actions
+			}
+		}
+		if override.Checklist != nil {
+			// 空のリスト（checklist: []）を指定すると、その終了理由ではチェックリストを表示しません。
+			if err := validateChecklist(override.Checklist); err != nil {
+				v.add(prefix+"checklist", err)
+			} else {
+				reminder.Checklist = override.Checklist
+			}
+		}
+
+		reminders[name] = reminder
+	}
+
+	return reminders
+}
+
+// resolveCountdown は countdown の指定を base に上書きして返します。
+// 問題のある項目は v に記録し、base の値のままにします。
+// この関数は副作用（v の変更）を持ちます。
+func resolveCountdown(v *validator, base Countdown, override countdownOverride) Countdown {
+	if override.Seconds != nil {
+		if *override.Seconds < 0 || *override.Seconds > MaxCountdownSeconds {
+			v.add("countdown.seconds", fmt.Errorf("0 から %d の範囲で指定してください: %d", MaxCountdownSeconds, *override.Seconds))
+		} else {
+			base.Seconds = *override.Seconds
+		}
+	}
+	if override.Action != nil {
+		// 独自のボタンの表示名も指定できるため、存在の確認は validateCountdownAction で行います。
+		if *override.Action == "" {
+			v.add("countdown.action", fmt.Errorf("action が空です"))
+		} else {
+			base.Action = *override.Action
+		}
+	}
+	return base
+}
+
+// validateCountdownAction は countdown.action が組み込みのボタン名か、
+// いずれかのボタン構成に含まれる独自のボタンの表示名であることを検証します。
+// この関数は純粋関数です。
+func validateCountdownAction(config UserConfig) error {
+	if isKnownAction(config.Countdown.Action) || config.hasAction(config.Countdown.Action) {
+		return nil
+	}
+	return fmt.Errorf("action に指定したボタンがありません（%s・%s・%s または独自のボタンの label を指定してください）: %s", ActionOpen, ActionSnooze, ActionClose, config.Countdown.Action)
+}
+
+// hasAction はいずれかのボタン構成（トップレベル・end_kinds・rules）に、ID が id のボタンがあるかを返します。
+// この関数は純粋関数です。
+func (config UserConfig) hasAction(id string) bool {
+	candidates := [][]ReminderAction{config.Actions}
+	for _, reminder := range config.EndKinds {
+		candidates = append(candidates, reminder.Actions)
+	}
+	for _, rule := range config.Rules {
+		candidates = append(candidates, rule.Actions)
+	}
+	for _, actions := range candidates {
+		for _, action := range actions {
+			if action.ID() == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateActions はボタン構成の妥当性を検証します。
+// 「閉じる」はダイアログのキャンセルボタンを兼ねるため必須です。
+// Enterキーで選ばれる（default: true の）ボタンは1つまでです。
+// この関数は純粋関数です。
+func validateActions(actions []ReminderAction) error {
+	seen := map[string]bool{}
+	defaults := 0
+	for _, action := range actions {
+		if err := validateAction(action); err != nil {
+			return err
+		}
+		if seen[action.ID()] {
+			return fmt.Errorf("アクションが重複しています: %s", action.ID())
+		}
+		seen[action.ID()] = true
+		if action.Default {
+			defaults++
+		}
+	}
+
+	if !seen[ActionClose] {
+		return fmt.Errorf("%s は必須です", ActionClose)
+	}
+	if defaults > 1 {
+		return fmt.Errorf("default: true を指定できるボタンは1つまでです")
+	}
+
+	return nil
+}
+
+// validateChecklist はチェックリストの妥当性を検証します。
+// 項目の表示名は空にできず、重複も許可しません。
+// この関数は純粋関数です。
+func validateChecklist(items []ChecklistItem) error {
+	seen := map[string]bool{}
+	for _, item := range items {
+		if item.Label == "" {
+			return fmt.Errorf("label を指定してください")
+		}
+		if seen[item.Label] {
+			return fmt.Errorf("項目が重複しています: %s", item.Label)
+		}
+		seen[item.Label] = true
+	}
+	return nil
+}
+
+// isKnownAction はアクション名が定義済みかを返します。
+// この関数は純粋関数です。
+func isKnownAction(action string) bool {
+	return action == ActionOpen || action == ActionSnooze || action == ActionClose
 }
 
 // validateDialogMessage はダイアログメッセージの妥当性を検証します。
@@ -158,6 +834,11 @@ func validateDialogMessage(message string) error {
 		return fmt.Errorf("メッセージが空です")
 	}
 
+	// テンプレートの構文と変数名を検証
+	if _, err := expandTemplate("dialog_message", message, sampleTemplateData()); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// 設定ファイルの探索場所の種類
+const (
+	// ConfigSourceFlagは --config オプションで指定されたファイルです。
+	ConfigSourceFlag = "flag"
+	// ConfigSourceEnvは環境変数 ConfigEnvVar で指定されたファイルです。
+	ConfigSourceEnv = "env"
+	// ConfigSourceUserDirはユーザーごとの設定ディレクトリ（%APPDATA% または XDG_CONFIG_HOME）のファイルです。
+	ConfigSourceUserDir = "user"
+	// ConfigSourceExeDirは実行ファイルと同じディレクトリのファイルです。
+	ConfigSourceExeDir = "exe"
+	// ConfigSourceWorkDirはカレントディレクトリのファイルです。
+	ConfigSourceWorkDir = "cwd"
+)
+
+// ConfigCandidate は設定ファイルの探索場所1つを表します。
+type ConfigCandidate struct {
+	Path   string
+	Source string
+	// Exists はファイルが存在するかどうかです。
+	Exists bool
+}
+
+// ConfigLocation は探索の結果、使用する設定ファイルを表します。
+type ConfigLocation struct {
+	ConfigCandidate
+	// Candidates は優先度の高い順に並べた、確認したすべての場所です。
+	Candidates []ConfigCandidate
+}
+
+// configSearchPaths は優先度の高い順に設定ファイルの探索場所を返します。
+// flagPath・envPath が空の場合、およびディレクトリが取得できなかった場所は含めません。
+// この関数は純粋関数です。
+func configSearchPaths(flagPath, envPath, userDir, exeDir, workDir string) []ConfigCandidate {
+	var candidates []ConfigCandidate
+	add := func(path, source string) {
+		if path != "" {
+			candidates = append(candidates, ConfigCandidate{Path: path, Source: source})
+		}
+	}
+	join := func(dir string) string {
+		if dir == "" {
+			return ""
+		}
+		return filepath.Join(dir, ConfigFileName)
+	}
+
+	add(flagPath, ConfigSourceFlag)
+	add(envPath, ConfigSourceEnv)
+	add(join(userDir), ConfigSourceUserDir)
+	add(join(exeDir), ConfigSourceExeDir)
+	add(join(workDir), ConfigSourceWorkDir)
+	return candidates
+}
+
+// chooseConfig は探索場所から使用する設定ファイルを選びます。
+// --config と環境変数による明示的な指定は、ファイルがなくてもそのまま使用します（読み込み時にエラーになります）。
+// それ以外は存在する最初のファイルを選び、どこにもない場合は実行ファイルと同じディレクトリ（なければ最後の場所）を選びます。
+// この関数は純粋関数です。
+func chooseConfig(candidates []ConfigCandidate) ConfigLocation {
+	location := ConfigLocation{Candidates: candidates}
+	if len(candidates) == 0 {
+		return location
+	}
+
+	for _, candidate := range candidates {
+		explicit := candidate.Source == ConfigSourceFlag || candidate.Source == ConfigSourceEnv
+		if explicit || candidate.Exists {
+			location.ConfigCandidate = candidate
+			return location
+		}
+	}
+
+	location.ConfigCandidate = candidates[len(candidates)-1]
+	for _, candidate := range candidates {
+		if candidate.Source == ConfigSourceExeDir {
+			location.ConfigCandidate = candidate
+		}
+	}
+	return location
+}
+
+// DiscoverConfig は設定ファイルを次の順に探し、使用するファイルを返します。
+// --config オプション、環境変数 ConfigEnvVar、ユーザーごとの設定ディレクトリ、
+// 実行ファイルと同じディレクトリ、カレントディレクトリ。
+// スタートアップから起動した場合はカレントディレクトリが実行ファイルのディレクトリと異なるため、
+// 実行ファイルと同じディレクトリをカレントディレクトリより優先します。
+// この関数は副作用（環境変数の参照、ファイルシステムへのアクセス）を持ちます。
+func DiscoverConfig(flagPath string) ConfigLocation {
+	userDir := ""
+	if dir, err := os.UserConfigDir(); err == nil {
+		userDir = filepath.Join(dir, userConfigDirName())
+	}
+	exeDir := ""
+	if execPath, err := os.Executable(); err == nil {
+		exeDir = filepath.Dir(execPath)
+	}
+	workDir, _ := os.Getwd()
+
+	return discoverConfig(flagPath, os.Getenv(ConfigEnvVar), userDir, exeDir, workDir)
+}
+
+// discoverConfig は指定した探索場所でファイルの有無を確かめ、使用する設定ファイルを返します。
+// 環境変数やディレクトリの取得を DiscoverConfig に分けているため、一時ディレクトリを渡してテストできます。
+// 存在するかを確かめられないファイル（アクセス権がないなど）は、存在するものとして扱います（読み込み時にエラーになります）。
+// この関数は副作用（ファイルシステムへのアクセス）を持ちます。
+func discoverConfig(flagPath, envPath, userDir, exeDir, workDir string) ConfigLocation {
+	candidates := configSearchPaths(flagPath, envPath, userDir, exeDir, workDir)
+	for i := range candidates {
+		_, err := os.Stat(candidates[i].Path)
+		candidates[i].Exists = err == nil || !errors.Is(err, os.ErrNotExist)
+	}
+	return chooseConfig(candidates)
+}
+
+// userConfigDirName はユーザーごとの設定ディレクトリ内のディレクトリ名を返します。
+// Windowsは %APPDATA%\ShutdownAlert、それ以外はXDGの慣習に合わせて小文字の名前を使います。
+// この関数は純粋関数です。
+func userConfigDirName() string {
+	if runtime.GOOS == "windows" {
+		return UserConfigDirNameWindows
+	}
+	return UserConfigDirName
+}
+
+// ConfigSourceLabel は探索場所の種類の表示名を返します。
+// この関数は純粋関数です。
+func ConfigSourceLabel(source string) string {
+	switch source {
+	case ConfigSourceFlag:
+		return ConfigSourceLabelFlag
+	case ConfigSourceEnv:
+		return ConfigSourceLabelEnv
+	case ConfigSourceUserDir:
+		return ConfigSourceLabelUserDir
+	case ConfigSourceExeDir:
+		return ConfigSourceLabelExeDir
+	case ConfigSourceWorkDir:
+		return ConfigSourceLabelWorkDir
+	}
+	return source
+}
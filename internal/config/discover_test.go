@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiscoverConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		// exists は設定ファイルを置く場所（flag・env・user・exe・cwd）です。
+		exists []string
+		// omit は指定しない（取得できなかった）場所です。
+		omit        []string
+		wantSource  string
+		wantExists  bool
+		wantSources []string
+	}{
+		{
+			name:        "--config を最優先",
+			exists:      []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+			wantSource:  ConfigSourceFlag,
+			wantExists:  true,
+			wantSources: []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "--config はファイルがなくても使用",
+			exists:      []string{ConfigSourceUserDir},
+			wantSource:  ConfigSourceFlag,
+			wantSources: []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "環境変数はファイルがなくても使用",
+			exists:      []string{ConfigSourceUserDir},
+			omit:        []string{ConfigSourceFlag},
+			wantSource:  ConfigSourceEnv,
+			wantSources: []string{ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "ユーザー設定ディレクトリを実行ファイルのディレクトリより優先",
+			exists:      []string{ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+			omit:        []string{ConfigSourceFlag, ConfigSourceEnv},
+			wantSource:  ConfigSourceUserDir,
+			wantExists:  true,
+			wantSources: []string{ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "実行ファイルのディレクトリをカレントディレクトリより優先",
+			exists:      []string{ConfigSourceExeDir, ConfigSourceWorkDir},
+			omit:        []string{ConfigSourceFlag, ConfigSourceEnv},
+			wantSource:  ConfigSourceExeDir,
+			wantExists:  true,
+			wantSources: []string{ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "カレントディレクトリ",
+			exists:      []string{ConfigSourceWorkDir},
+			omit:        []string{ConfigSourceFlag, ConfigSourceEnv},
+			wantSource:  ConfigSourceWorkDir,
+			wantExists:  true,
+			wantSources: []string{ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "どこにもない場合は実行ファイルのディレクトリ",
+			omit:        []string{ConfigSourceFlag, ConfigSourceEnv},
+			wantSource:  ConfigSourceExeDir,
+			wantSources: []string{ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+		{
+			name:        "どこにもなく実行ファイルのディレクトリも不明な場合は最後の場所",
+			omit:        []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceExeDir},
+			wantSource:  ConfigSourceWorkDir,
+			wantSources: []string{ConfigSourceUserDir, ConfigSourceWorkDir},
+		},
+		{
+			name: "探索場所がない",
+			omit: []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			paths := map[string]string{}
+			for _, source := range []string{ConfigSourceFlag, ConfigSourceEnv, ConfigSourceUserDir, ConfigSourceExeDir, ConfigSourceWorkDir} {
+				if slices.Contains(tt.omit, source) {
+					continue
+				}
+				dir := filepath.Join(root, source)
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				paths[source] = dir
+				if slices.Contains(tt.exists, source) {
+					if err := os.WriteFile(filepath.Join(dir, ConfigFileName), []byte("version: 1\n"), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			file := func(source string) string {
+				if paths[source] == "" {
+					return ""
+				}
+				return filepath.Join(paths[source], ConfigFileName)
+			}
+
+			location := discoverConfig(file(ConfigSourceFlag), file(ConfigSourceEnv), paths[ConfigSourceUserDir], paths[ConfigSourceExeDir], paths[ConfigSourceWorkDir])
+
+			if location.Source != tt.wantSource || location.Exists != tt.wantExists {
+				t.Errorf("location = %s (exists %v), want %s (exists %v)", location.Source, location.Exists, tt.wantSource, tt.wantExists)
+			}
+			if location.Path != file(tt.wantSource) {
+				t.Errorf("Path = %q, want %q", location.Path, file(tt.wantSource))
+			}
+			var sources []string
+			for _, candidate := range location.Candidates {
+				sources = append(sources, candidate.Source)
+				if candidate.Exists != slices.Contains(tt.exists, candidate.Source) {
+					t.Errorf("%s: Exists = %v", candidate.Source, candidate.Exists)
+				}
+			}
+			if !slices.Equal(sources, tt.wantSources) {
+				t.Errorf("Candidates = %v, want %v", sources, tt.wantSources)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DoneTodayMode は done_today の動作 modes と今日選んだボタン（ReminderAction.ID）から、
+// それ以降の表示を抑える動作と、その元になったボタンを返します。
+// modes に指定したボタンを選んでいない場合は空文字列を返します。
+// 複数のボタンを選んでいる場合は、表示しない動作（DoneTodaySkip）を通知（DoneTodayToast）より優先します。
+// この関数は純粋関数です。
+func DoneTodayMode(modes map[string]string, done []string) (mode, action string) {
+	for _, id := range done {
+		switch modes[id] {
+		case DoneTodaySkip:
+			return DoneTodaySkip, id
+		case DoneTodayToast:
+			if mode == "" {
+				mode, action = DoneTodayToast, id
+			}
+		}
+	}
+	return mode, action
+}
+
+// resolveDoneToday は done_today を検証して返します。
+// 問題のある項目は記録して除き、残りの項目を返します。
+// config はボタン構成（end_kinds・rules を含む）を解決済みである必要があります。
+// この関数は副作用（v の変更）を持ちます。
+func resolveDoneToday(v *validator, config UserConfig, modes map[string]string) map[string]string {
+	ids := make([]string, 0, len(modes))
+	for id := range modes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	valid := map[string]string{}
+	for _, id := range ids {
+		mode := modes[id]
+		switch {
+		case id == ActionSnooze:
+			v.add("done_today."+id, fmt.Errorf("%s は選んでも済ませたことにならないため指定できません", ActionSnooze))
+		case !isKnownAction(id) && !config.hasAction(id):
+			v.add("done_today."+id, fmt.Errorf("指定したボタンがありません（%s・%s または独自のボタンの label を指定してください）: %s", ActionOpen, ActionClose, id))
+		case mode != DoneTodaySkip && mode != DoneTodayToast:
+			v.add("done_today."+id, fmt.Errorf("%s または %s を指定してください: %s", DoneTodaySkip, DoneTodayToast, mode))
+		default:
+			valid[id] = mode
+		}
+	}
+	return valid
+}
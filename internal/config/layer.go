@@ -0,0 +1,464 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 設定の層の種類
+const (
+	// ConfigLayerDefaultは組み込みのデフォルト値の層です。
+	ConfigLayerDefault = "default"
+	// ConfigLayerPolicyはPC全体に適用する管理者の設定ファイルの層です。
+	ConfigLayerPolicy = "policy"
+	// ConfigLayerUserはユーザーごとの設定ファイル（config.yaml）の層です。
+	ConfigLayerUser = "user"
+)
+
+// ConfigLayer は設定を組み立てる層1つ（設定ファイル1つ）を表します。
+type ConfigLayer struct {
+	// Name は層の種類（ConfigLayerPolicy など）です。
+	Name string
+	Path string
+	// Policy が true の層では locked_keys でキーをロックでき、後の層はロックしたキーを上書きできません。
+	Policy bool
+	// Optional が true の層は、ファイルがなくてもエラーにしません。
+	Optional bool
+}
+
+// LayeredConfig は複数の層から組み立てた設定を保持します。
+type LayeredConfig struct {
+	Config UserConfig
+	// Layers は優先度の低い順に並べた、読み込んだ層です。
+	Layers []ConfigLayer
+	// Origins はキー（end_kinds.restart.target_url のようにドットで区切ったパス）ごとに、値を指定した層の種類です。
+	// どの層にも指定がないキーは含みません（デフォルト値を使用します）。
+	Origins map[string]string
+	// Locked はロックされたキーです。
+	Locked []string
+	// Ignored はロックされているため無視した、後の層でのキーの指定です（"user: target_url" の形式）。
+	Ignored []string
+}
+
+// OriginOf はキーの値を指定した層の種類を返します。
+// 親のキーがまとめて指定されている場合はその層を、どの層にも指定がない場合は ConfigLayerDefault を返します。
+// この関数は純粋関数です。
+func (layered LayeredConfig) OriginOf(key string) string {
+	for path := key; path != ""; path = parentKey(path) {
+		if origin, ok := layered.Origins[path]; ok {
+			return origin
+		}
+	}
+	return ConfigLayerDefault
+}
+
+// IsLocked はキー（またはその親のキー）がロックされているかを返します。
+// この関数は純粋関数です。
+func (layered LayeredConfig) IsLocked(key string) bool {
+	return isLockedKey(key, layered.Locked)
+}
+
+// StandardLayers は管理者の設定ファイルと、userPath のユーザー設定ファイルからなる層を返します。
+// 管理者の設定ファイルは省略可能です。
+// この関数は副作用（環境変数の参照）を持ちます。
+func StandardLayers(userPath string) []ConfigLayer {
+	return []ConfigLayer{
+		{Name: ConfigLayerPolicy, Path: PolicyConfigPath(), Policy: true, Optional: true},
+		{Name: ConfigLayerUser, Path: userPath},
+	}
+}
+
+// LayerPaths は層の設定ファイルのパスを優先度の低い順に返します（変更の監視用）。
+// この関数は純粋関数です。
+func LayerPaths(layers []ConfigLayer) []string {
+	paths := make([]string, len(layers))
+	for i, layer := range layers {
+		paths[i] = layer.Path
+	}
+	return paths
+}
+
+// PolicyConfigPath は管理者の設定ファイルのパスを返します。
+// Windowsは %ProgramData%\ShutdownAlert\policy.yaml、それ以外は /etc/shutdown-alert/policy.yaml です。
+// この関数は副作用（環境変数の参照）を持ちます。
+func PolicyConfigPath() string {
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("ProgramData")
+		if dir == "" {
+			dir = PolicyConfigDirWindows
+		}
+		return filepath.Join(dir, UserConfigDirNameWindows, PolicyFileName)
+	}
+	return filepath.Join(PolicyConfigDir, UserConfigDirName, PolicyFileName)
+}
+
+// LoadConfig は管理者の設定ファイルと userPath のユーザー設定ファイルを重ねて読み込みます。
+// この関数は副作用（ファイル読み込み、環境変数の参照）を持ちます。
+func LoadConfig(userPath string) (LayeredConfig, error) {
+	return LoadLayeredConfig(StandardLayers(userPath))
+}
+
+// LoadLayeredConfig は layers を優先度の低い順に重ね、デフォルト値とマージした設定を返します。
+// 後の層のキーは前の層のキーを上書きします（マッピングはキーごとに重ねます）。
+// 省略可能でない層のファイルがない場合も、残りの層から組み立てた設定とともにエラーを返します。
+// この関数は副作用（ファイル読み込み）を持ちます。
+func LoadLayeredConfig(layers []ConfigLayer) (LayeredConfig, error) {
+	documents := make([]*yaml.Node, len(layers))
+	var missing error
+	for i, layer := range layers {
+		data, err := os.ReadFile(layer.Path)
+		if err != nil {
+			if layer.Optional && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if errors.Is(err, os.ErrNotExist) && missing == nil {
+				// ファイルがない場合は残りの層で組み立てを続けます。
+				missing = err
+				continue
+			}
+			return LayeredConfig{Config: DefaultUserConfig(), Layers: layers}, err
+		}
+
+		root, err := parseConfigDocument(data)
+		if err != nil {
+			return LayeredConfig{Config: DefaultUserConfig(), Layers: layers}, withFile(err, func(string) string { return layer.Path })
+		}
+		documents[i] = root
+	}
+
+	layered, err := mergeLayers(layers, documents)
+	// カレンダーファイルの相対パスは、rules を指定した層の設定ファイルのディレクトリからのパスです。
+	rulesPath := rulesLayerPath(layered)
+	calendarErr := withFile(loadCalendars(&layered.Config, filepath.Dir(rulesPath)), func(string) string { return rulesPath })
+	if err = joinErrors(err, calendarErr); err != nil {
+		return layered, err
+	}
+	return layered, missing
+}
+
+// mergeLayers は層ごとの最上位のマッピングを重ね、デフォルト値とマージした設定を返します。
+// documents[i] は layers[i] の内容で、nil の層（ファイルがない、または空）は読み飛ばします。
+// この関数は純粋関数です。
+func mergeLayers(layers []ConfigLayer, documents []*yaml.Node) (LayeredConfig, error) {
+	layered := LayeredConfig{Config: DefaultUserConfig(), Layers: layers, Origins: map[string]string{}}
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+
+	for i, layer := range layers {
+		root := documents[i]
+		if root == nil {
+			continue
+		}
+
+		root, locked, err := extractLockedKeys(root)
+		if err != nil {
+			return layered, withFile(err, func(string) string { return layer.Path })
+		}
+		if len(locked) > 0 && !layer.Policy {
+			return layered, fmt.Errorf("%s: %s は管理者の設定ファイルでのみ指定できます", layer.Path, LockedKeysKey)
+		}
+
+		root, ignored := withoutDerivedLockedKeys(root, layered.Locked)
+		for _, path := range ignored {
+			layered.Ignored = append(layered.Ignored, layer.Name+": "+path)
+		}
+		mergeMapping(merged, root, "", layer.Name, &layered)
+		layered.Locked = append(layered.Locked, locked...)
+	}
+	sort.Strings(layered.Locked)
+
+	// 問題のあるキーは、その値を指定した層のファイルの問題として報告します。
+	config, err := resolveUserConfig(merged)
+	layered.Config = config
+	return layered, withFile(err, func(key string) string {
+		origin := layered.OriginOf(key)
+		for _, layer := range layers {
+			if layer.Name == origin {
+				return layer.Path
+			}
+		}
+		return ""
+	})
+}
+
+// extractLockedKeys は最上位のマッピングから locked_keys を取り除き、ロックするキーとともに返します。
+// root は変更せず、locked_keys を除いたコピーを返します。
+// この関数は純粋関数です。
+func extractLockedKeys(root *yaml.Node) (*yaml.Node, []string, error) {
+	rest := *root
+	rest.Content = nil
+	var locked []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value != LockedKeysKey {
+			rest.Content = append(rest.Content, key, value)
+			continue
+		}
+
+		if err := value.Decode(&locked); err != nil {
+			return nil, nil, ValidationErrors{{Line: value.Line, Column: value.Column, Key: LockedKeysKey, Message: "キーのリストで指定してください"}}
+		}
+		for _, lockedKey := range locked {
+			if !isConfigKey(lockedKey) {
+				return nil, nil, ValidationErrors{{Line: value.Line, Column: value.Column, Key: LockedKeysKey, Message: "未知のキーがあります: " + lockedKey}}
+			}
+		}
+	}
+	return &rest, locked, nil
+}
+
+// mergeMapping は src のキーを dst に重ねます。両方の値がマッピングのキーは再帰的に重ねます。
+// ロックされたキーは上書きせず、layered.Ignored に記録します。上書きしたキーの出どころは layered.Origins に記録します。
+// src は変更しません。
+// この関数は副作用（dst・layered の変更）を持ちます。
+func mergeMapping(dst, src *yaml.Node, prefix, layerName string, layered *LayeredConfig) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		path := prefix + key.Value
+		if isLockedKey(path, layered.Locked) {
+			layered.Ignored = append(layered.Ignored, layerName+": "+path)
+			continue
+		}
+
+		existing := mappingValue(dst, key.Value)
+		if existing != nil && existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeMapping(existing, value, path+".", layerName, layered)
+			continue
+		}
+
+		// 前の層に親のマッピングがない場合も、子のキーのロックを迂回して値ごと置き換えないようにします。
+		value, ignored := withoutLockedDescendants(value, path, layered.Locked)
+		for _, ignoredPath := range ignored {
+			layered.Ignored = append(layered.Ignored, layerName+": "+ignoredPath)
+		}
+		if value == nil {
+			continue
+		}
+
+		// 値をまるごと置き換えるため、子のキーの出どころは取り除きます。
+		for origin := range layered.Origins {
+			if strings.HasPrefix(origin, path+".") {
+				delete(layered.Origins, origin)
+			}
+		}
+		layered.Origins[path] = layerName
+		// 後の層で dst を変更しても元の層のノードが変わらないよう、コピーを重ねます。
+		if existing != nil {
+			*existing = *cloneNode(value)
+		} else {
+			dst.Content = append(dst.Content, cloneNode(key), cloneNode(value))
+		}
+	}
+}
+
+// withoutLockedDescendants は path に置き換える value から、ロックされた子孫のキーを取り除いた値と、取り除いたキーを返します。
+// 子孫のキーがロックされているのに value がマッピングでない場合は、置き換えるとロックされた値が消えるため nil を返します。
+// value は変更しません。
+// この関数は純粋関数です。
+func withoutLockedDescendants(value *yaml.Node, path string, locked []string) (*yaml.Node, []string) {
+	if !slices.ContainsFunc(locked, func(lockedKey string) bool { return strings.HasPrefix(lockedKey, path+".") }) {
+		return value, nil
+	}
+	if value.Kind != yaml.MappingNode {
+		return nil, []string{path}
+	}
+
+	rest := *value
+	rest.Content = nil
+	var ignored []string
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		key, child := value.Content[i], value.Content[i+1]
+		childPath := path + "." + key.Value
+		if isLockedKey(childPath, locked) {
+			ignored = append(ignored, childPath)
+			continue
+		}
+		child, dropped := withoutLockedDescendants(child, childPath, locked)
+		ignored = append(ignored, dropped...)
+		if child != nil {
+			rest.Content = append(rest.Content, key, child)
+		}
+	}
+	return &rest, ignored
+}
+
+// withoutDerivedLockedKeys は、ロックされたキーを別の場所から上書きする指定を取り除いた root のコピーと、取り除いたキーを返します。
+// リマインダーの項目（target_url・dialog_message・actions・checklist）のロックは end_kinds.*.<キー> と rules[*].<キー> にも及びます。
+// target_url のロックは、どのボタン構成の独自のボタンの url・command にも及びます（ボタンごと取り除きます）。
+// root は変更しません。
+// この関数は純粋関数です。
+func withoutDerivedLockedKeys(root *yaml.Node, locked []string) (*yaml.Node, []string) {
+	var lockedFields []string
+	for _, field := range yamlKeys(reflect.TypeOf(reminderOverride{})) {
+		if isLockedKey(field, locked) {
+			lockedFields = append(lockedFields, field)
+		}
+	}
+	if len(lockedFields) == 0 {
+		return root, nil
+	}
+
+	rest := cloneNode(root)
+	var ignored []string
+	// reminders は上書きできるリマインダー（end_kinds の各項目と rules の各ルール）のマッピングとそのキーの接頭辞です。
+	reminders := map[string]*yaml.Node{"": rest}
+	if endKinds := mappingValue(rest, "end_kinds"); endKinds != nil && endKinds.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(endKinds.Content); i += 2 {
+			reminders["end_kinds."+endKinds.Content[i].Value+"."] = endKinds.Content[i+1]
+		}
+	}
+	if rules := mappingValue(rest, "rules"); rules != nil && rules.Kind == yaml.SequenceNode {
+		for i, rule := range rules.Content {
+			reminders[fmt.Sprintf("rules[%d].", i)] = rule
+		}
+	}
+
+	prefixes := make([]string, 0, len(reminders))
+	for prefix := range reminders {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		reminder := reminders[prefix]
+		if reminder.Kind != yaml.MappingNode {
+			continue
+		}
+		if prefix != "" {
+			// 最上位のキー自体は mergeMapping がロックを確認します。
+			for _, field := range lockedFields {
+				if removeMappingKey(reminder, field) {
+					ignored = append(ignored, prefix+field)
+				}
+			}
+		}
+		if isLockedKey("target_url", locked) {
+			ignored = append(ignored, withoutLaunchingActions(mappingValue(reminder, "actions"), prefix+"actions")...)
+		}
+	}
+	return rest, ignored
+}
+
+// withoutLaunchingActions はボタン構成のリストから、url または command を指定した独自のボタンを取り除き、取り除いたボタンのキーを返します。
+// この関数は副作用（actions の変更）を持ちます。
+func withoutLaunchingActions(actions *yaml.Node, key string) []string {
+	if actions == nil || actions.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var ignored []string
+	kept := actions.Content[:0]
+	for i, action := range actions.Content {
+		if action.Kind == yaml.MappingNode && (mappingValue(action, "url") != nil || mappingValue(action, "command") != nil) {
+			ignored = append(ignored, fmt.Sprintf("%s[%d]", key, i))
+			continue
+		}
+		kept = append(kept, action)
+	}
+	actions.Content = kept
+	return ignored
+}
+
+// removeMappingKey はマッピングからキーとその値を取り除き、取り除いたかどうかを返します。
+// この関数は副作用（mapping の変更）を持ちます。
+func removeMappingKey(mapping *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// cloneNode はノードとその子を再帰的にコピーします。
+// この関数は純粋関数です。
+func cloneNode(node *yaml.Node) *yaml.Node {
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		clone.Content[i] = cloneNode(child)
+	}
+	return &clone
+}
+
+// mappingValue はマッピングのキーに対応する値のノードを返します（キーがない場合は nil）。
+// この関数は純粋関数です。
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isLockedKey はキー、またはその親のキーが locked に含まれるかを返します。
+// この関数は純粋関数です。
+func isLockedKey(key string, locked []string) bool {
+	for _, lockedKey := range locked {
+		if key == lockedKey || strings.HasPrefix(key, lockedKey+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// parentKey はドットで区切ったキーの親のキーを返します（最上位のキーの場合は空文字列）。
+// actions[0] のようなリストの要素の親はリストのキーです。
+// この関数は純粋関数です。
+func parentKey(key string) string {
+	if i := strings.LastIndexAny(key, ".["); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// isConfigKey はキーの最上位の部分が設定ファイルで指定できるキーかを返します。
+// この関数は純粋関数です。
+func isConfigKey(key string) bool {
+	top := key
+	if i := strings.Index(key, "."); i >= 0 {
+		top = key[:i]
+	}
+	for _, name := range ConfigKeys() {
+		if name == top {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigKeys は設定ファイルの最上位で指定できるキーを、UserConfig の定義順に返します。
+// この関数は純粋関数です。
+func ConfigKeys() []string {
+	var keys []string
+	configType := reflect.TypeOf(UserConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		name, _, _ := strings.Cut(configType.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// ConfigLayerLabel は層の種類の表示名を返します。
+// この関数は純粋関数です。
+func ConfigLayerLabel(name string) string {
+	switch name {
+	case ConfigLayerDefault:
+		return ConfigLayerLabelDefault
+	case ConfigLayerPolicy:
+		return ConfigLayerLabelPolicy
+	case ConfigLayerUser:
+		return ConfigLayerLabelUser
+	}
+	return name
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/session"
+)
+
+// writeLayers はテスト用の管理者の設定とユーザー設定を書き込み、その層を返します。
+func writeLayers(t *testing.T, policy, user string) []ConfigLayer {
+	t.Helper()
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, PolicyFileName)
+	userPath := filepath.Join(dir, ConfigFileName)
+	if err := os.WriteFile(policyPath, []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(userPath, []byte(user), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return []ConfigLayer{
+		{Name: ConfigLayerPolicy, Path: policyPath, Policy: true},
+		{Name: ConfigLayerUser, Path: userPath},
+	}
+}
+
+func TestLockedKeyCoversDerivedKeys(t *testing.T) {
+	const lockedURL = "https://attendance.example.com"
+	policy := `
+target_url: "` + lockedURL + `"
+locked_keys: [target_url]
+`
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		user    string
+		ignored string
+	}{
+		{
+			name:    "トップレベル",
+			user:    "target_url: \"https://user.example.com\"\n",
+			ignored: "user: target_url",
+		},
+		{
+			name: "end_kinds",
+			user: `
+end_kinds:
+  shutdown:
+    target_url: "https://user.example.com"
+`,
+			ignored: "user: end_kinds.shutdown.target_url",
+		},
+		{
+			name: "rules",
+			user: `
+rules:
+  - when:
+      weekdays: [fri]
+    target_url: "https://user.example.com"
+`,
+			ignored: "user: rules[0].target_url",
+		},
+		{
+			name: "独自のボタンの url",
+			user: `
+actions:
+  - open
+  - label: 私用
+    url: "https://user.example.com"
+  - close
+`,
+			ignored: "user: actions[1]",
+		},
+		{
+			name: "rules の独自のボタンの command",
+			user: `
+rules:
+  - actions:
+      - label: 私用
+        command: [calc.exe]
+      - close
+`,
+			ignored: "user: rules[0].actions[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layered, err := LoadLayeredConfig(writeLayers(t, policy, tt.user))
+			if err != nil {
+				t.Fatalf("LoadLayeredConfig: %v", err)
+			}
+			if !slices.Contains(layered.Ignored, tt.ignored) {
+				t.Errorf("Ignored = %v, %q を含みません", layered.Ignored, tt.ignored)
+			}
+
+			reminders := []Reminder{
+				layered.Config.ReminderFor(session.EndKindShutdown),
+				layered.Config.ReminderAt(TriggerSessionEnd, session.EndKindShutdown, now),
+			}
+			for _, reminder := range reminders {
+				if reminder.TargetURL != lockedURL {
+					t.Errorf("TargetURL = %q, want %q", reminder.TargetURL, lockedURL)
+				}
+				for _, action := range reminder.Actions {
+					if action.URL != "" || len(action.Command) > 0 {
+						t.Errorf("ロックを迂回するボタンが残っています: %+v", action)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLockedReminderFieldCoversEndKindsAndRules(t *testing.T) {
+	policy := `
+dialog_message: "打刻してください"
+checklist:
+  - label: 日報
+locked_keys: [dialog_message, checklist]
+`
+	user := `
+end_kinds:
+  restart:
+    dialog_message: "再起動します"
+    checklist: []
+rules:
+  - dialog_message: "お疲れさまでした"
+`
+	layered, err := LoadLayeredConfig(writeLayers(t, policy, user))
+	if err != nil {
+		t.Fatalf("LoadLayeredConfig: %v", err)
+	}
+
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+	for _, kind := range session.EndKinds() {
+		reminder := layered.Config.ReminderAt(TriggerSessionEnd, kind, now)
+		if reminder.DialogMessage != "打刻してください" {
+			t.Errorf("%s: DialogMessage = %q", kind, reminder.DialogMessage)
+		}
+		if len(reminder.Checklist) != 1 {
+			t.Errorf("%s: Checklist = %v", kind, reminder.Checklist)
+		}
+	}
+	for _, key := range []string{"user: end_kinds.restart.dialog_message", "user: end_kinds.restart.checklist", "user: rules[0].dialog_message"} {
+		if !slices.Contains(layered.Ignored, key) {
+			t.Errorf("Ignored = %v, %q を含みません", layered.Ignored, key)
+		}
+	}
+}
+
+func TestUnlockedKeysAreMerged(t *testing.T) {
+	policy := `
+target_url: "https://policy.example.com"
+`
+	user := `
+end_kinds:
+  shutdown:
+    target_url: "https://user.example.com"
+`
+	layered, err := LoadLayeredConfig(writeLayers(t, policy, user))
+	if err != nil {
+		t.Fatalf("LoadLayeredConfig: %v", err)
+	}
+	if got := layered.Config.ReminderFor(session.EndKindShutdown).TargetURL; got != "https://user.example.com" {
+		t.Errorf("TargetURL = %q", got)
+	}
+	if len(layered.Ignored) != 0 {
+		t.Errorf("Ignored = %v", layered.Ignored)
+	}
+}
+
+func TestNestedLockedKeyWithoutParentInPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		user    string
+		check   func(t *testing.T, config UserConfig)
+		ignored []string
+	}{
+		{
+			name:   "countdown.seconds",
+			policy: "locked_keys: [countdown.seconds]\n",
+			user:   "countdown:\n  seconds: 30\n  action: snooze\n",
+			check: func(t *testing.T, config UserConfig) {
+				want := DefaultUserConfig().Countdown.Seconds
+				if config.Countdown.Seconds != want {
+					t.Errorf("Countdown.Seconds = %d, want %d", config.Countdown.Seconds, want)
+				}
+				if config.Countdown.Action != ActionSnooze {
+					t.Errorf("Countdown.Action = %q, want %q（ロックされていない兄弟のキーは重ねます）", config.Countdown.Action, ActionSnooze)
+				}
+			},
+			ignored: []string{"user: countdown.seconds"},
+		},
+		{
+			name:   "end_kinds.restart.target_url",
+			policy: "locked_keys: [end_kinds.restart.target_url]\n",
+			user: `
+end_kinds:
+  restart:
+    target_url: "https://user.example.com"
+    dialog_message: "再起動します"
+`,
+			check: func(t *testing.T, config UserConfig) {
+				reminder := config.ReminderFor(session.EndKindRestart)
+				if reminder.TargetURL != DefaultUserConfig().TargetURL {
+					t.Errorf("TargetURL = %q, want the default", reminder.TargetURL)
+				}
+				if reminder.DialogMessage != "再起動します" {
+					t.Errorf("DialogMessage = %q", reminder.DialogMessage)
+				}
+			},
+			ignored: []string{"user: end_kinds.restart.target_url"},
+		},
+		{
+			name:   "親のキーをマッピング以外で置き換える",
+			policy: "locked_keys: [end_kinds.restart.target_url]\n",
+			user:   "end_kinds:\n  restart: null\n",
+			check: func(t *testing.T, config UserConfig) {
+				if got := config.ReminderFor(session.EndKindRestart).TargetURL; got != DefaultUserConfig().TargetURL {
+					t.Errorf("TargetURL = %q, want the default", got)
+				}
+			},
+			ignored: []string{"user: end_kinds.restart"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layered, err := LoadLayeredConfig(writeLayers(t, tt.policy, tt.user))
+			if err != nil {
+				t.Fatalf("LoadLayeredConfig: %v", err)
+			}
+			tt.check(t, layered.Config)
+			if !slices.Equal(layered.Ignored, tt.ignored) {
+				t.Errorf("Ignored = %v, want %v", layered.Ignored, tt.ignored)
+			}
+		})
+	}
+}
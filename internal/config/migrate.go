@@ -0,0 +1,149 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configMigration は設定ファイルを1つ新しいバージョンの形式に変換する関数です。
+// 最上位のマッピングを受け取り、変換後のマッピングを返します（受け取ったノードは変更しません）。
+// version キーは呼び出し元（MigrateConfig）が更新します。
+type configMigration func(root *yaml.Node) (*yaml.Node, error)
+
+// configMigrations は変換元のバージョンごとのマイグレーションを返します。
+// 形式を変更するときは CurrentConfigVersion を1つ上げ、1つ前のバージョンからの変換をここに追加します。
+// この関数は純粋関数です。
+func configMigrations() map[int]configMigration {
+	return map[int]configMigration{
+		// version キーを導入する前の設定ファイルです。形式は version 1 と同じです。
+		0: func(root *yaml.Node) (*yaml.Node, error) { return cloneNode(root), nil },
+	}
+}
+
+// ConfigVersion は最上位のマッピングの version キーの値を返します。
+// version キーがない場合は 0（バージョンを導入する前の形式）を返します。
+// この関数は純粋関数です。
+func ConfigVersion(root *yaml.Node) (int, error) {
+	value := mappingValue(root, VersionKey)
+	if value == nil {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || value.Kind != yaml.ScalarNode || version < 1 {
+		return 0, ValidationErrors{{Line: value.Line, Column: value.Column, Key: VersionKey, Message: "1以上の整数で指定してください: " + value.Value}}
+	}
+	return version, nil
+}
+
+// MigrateConfig は最上位のマッピングを CurrentConfigVersion の形式に変換し、変換前のバージョンとともに返します。
+// すでに最新の形式の場合は root をそのまま返します。root は変更しません。
+// この関数は純粋関数です。
+func MigrateConfig(root *yaml.Node) (*yaml.Node, int, error) {
+	return migrateConfig(root, CurrentConfigVersion, configMigrations())
+}
+
+// migrateConfig は migrations を順に適用して、root を target の形式に変換します。
+// この関数は純粋関数です。
+func migrateConfig(root *yaml.Node, target int, migrations map[int]configMigration) (*yaml.Node, int, error) {
+	from, err := ConfigVersion(root)
+	if err != nil {
+		return root, 0, err
+	}
+	if from > target {
+		value := mappingValue(root, VersionKey)
+		return root, from, ValidationErrors{{Line: value.Line, Column: value.Column, Key: VersionKey,
+			Message: fmt.Sprintf("%d の設定ファイルはこのバージョンのアプリケーションでは読み込めません（%d まで対応）", from, target)}}
+	}
+
+	migrated := root
+	for version := from; version < target; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return root, from, fmt.Errorf("%s %d から %d への変換がありません", VersionKey, version, version+1)
+		}
+		migrated, err = migrate(migrated)
+		if err != nil {
+			return root, from, fmt.Errorf("%s %d から %d への変換に失敗しました: %w", VersionKey, version, version+1, err)
+		}
+		migrated = withVersion(migrated, version+1)
+	}
+	return migrated, from, nil
+}
+
+// withVersion は version キーを version に設定したマッピングのコピーを返します。
+// version キーがない場合は先頭に追加します。ただし先頭のキーにコメントがある場合は、
+// ファイルの先頭のコメントとキーのコメントの区別がつかないため、先頭のキーの直後に追加します。
+// この関数は純粋関数です。
+func withVersion(root *yaml.Node, version int) *yaml.Node {
+	updated := cloneNode(root)
+	if value := mappingValue(updated, VersionKey); value != nil {
+		value.Value = strconv.Itoa(version)
+		value.Tag = "!!int"
+		value.Style = 0
+		return updated
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: VersionKey}
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	position := 0
+	if len(updated.Content) >= 2 && updated.Content[0].HeadComment != "" {
+		position = 2
+	}
+	updated.Content = slices.Insert(updated.Content, position, key, value)
+	return updated
+}
+
+// MigrateConfigFile は設定ファイルを最新の形式に変換して書き換え、変換前のバージョンを返します。
+// コメントを保持するため yaml.Node のまま変換し、元のファイルは path + ".bak" に残します。
+// すでに最新の形式の場合（空のファイルを含む）は書き換えません。
+// この関数は副作用（ファイルの読み書き）を持ちます。
+func MigrateConfigFile(path string) (from int, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return 0, err
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return CurrentConfigVersion, nil
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		root := document.Content[0]
+		return 0, ValidationErrors{{Line: root.Line, Column: root.Column, Message: "設定ファイルの最上位は「キー: 値」の形式で記述してください"}}
+	}
+
+	migrated, from, err := MigrateConfig(document.Content[0])
+	if err != nil || from == CurrentConfigVersion {
+		return from, err
+	}
+	document.Content[0] = migrated
+
+	// config.yaml.example と同じく2文字で字下げします。
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return from, err
+	}
+	if err := encoder.Close(); err != nil {
+		return from, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return from, err
+	}
+	if err := os.WriteFile(path+ConfigBackupSuffix, data, info.Mode().Perm()); err != nil {
+		return from, fmt.Errorf("元のファイルを残せませんでした: %w", err)
+	}
+	return from, os.WriteFile(path, buf.Bytes(), info.Mode().Perm())
+}
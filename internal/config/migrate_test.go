@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseMapping は YAML を最上位のマッピングのノードに変換します。
+func parseMapping(t *testing.T, source string) *yaml.Node {
+	t.Helper()
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &document); err != nil {
+		t.Fatal(err)
+	}
+	return document.Content[0]
+}
+
+func TestConfigVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    int
+		wantErr bool
+	}{
+		{name: "version なし", source: "url: https://example.com\n", want: 0},
+		{name: "version 1", source: "version: 1\n", want: 1},
+		{name: "version 2", source: "version: 2\n", want: 2},
+		{name: "0 は不正", source: "version: 0\n", wantErr: true},
+		{name: "文字列は不正", source: "version: one\n", wantErr: true},
+		{name: "リストは不正", source: "version: [1]\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfigVersion(parseMapping(t, tt.source))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConfigVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ConfigVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	// renameURL は version 1 の url キーを link に改名する、テスト用のマイグレーションです。
+	renameURL := func(root *yaml.Node) (*yaml.Node, error) {
+		updated := cloneNode(root)
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			if updated.Content[i].Value == "url" {
+				updated.Content[i].Value = "link"
+			}
+		}
+		return updated, nil
+	}
+	failing := func(*yaml.Node) (*yaml.Node, error) { return nil, errors.New("変換できません") }
+
+	tests := []struct {
+		name       string
+		source     string
+		target     int
+		migrations map[int]configMigration
+		wantFrom   int
+		wantKeys   []string
+		wantErr    bool
+	}{
+		{
+			name:       "0 から 1 へ version を追加",
+			source:     "url: https://example.com\n",
+			target:     1,
+			migrations: configMigrations(),
+			wantFrom:   0,
+			wantKeys:   []string{"version: 1", "url: https://example.com"},
+		},
+		{
+			name:       "最新の形式は変更しない",
+			source:     "version: 1\nurl: https://example.com\n",
+			target:     1,
+			migrations: configMigrations(),
+			wantFrom:   1,
+			wantKeys:   []string{"version: 1", "url: https://example.com"},
+		},
+		{
+			name:       "0 から 2 へ順に変換",
+			source:     "url: https://example.com\n",
+			target:     2,
+			migrations: map[int]configMigration{0: configMigrations()[0], 1: renameURL},
+			wantFrom:   0,
+			wantKeys:   []string{"version: 2", "link: https://example.com"},
+		},
+		{
+			name:       "変換がない",
+			source:     "version: 1\n",
+			target:     2,
+			migrations: configMigrations(),
+			wantFrom:   1,
+			wantErr:    true,
+		},
+		{
+			name:       "変換に失敗",
+			source:     "version: 1\n",
+			target:     2,
+			migrations: map[int]configMigration{1: failing},
+			wantFrom:   1,
+			wantErr:    true,
+		},
+		{
+			name:       "新しいバージョンの設定ファイル",
+			source:     "version: 3\n",
+			target:     1,
+			migrations: configMigrations(),
+			wantFrom:   3,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := parseMapping(t, tt.source)
+			migrated, from, err := migrateConfig(root, tt.target, tt.migrations)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if from != tt.wantFrom {
+				t.Errorf("migrateConfig() from = %d, want %d", from, tt.wantFrom)
+			}
+			if err != nil {
+				if migrated != root {
+					t.Error("失敗した場合は元のノードを返してください")
+				}
+				return
+			}
+
+			var got []string
+			for i := 0; i+1 < len(migrated.Content); i += 2 {
+				got = append(got, migrated.Content[i].Value+": "+migrated.Content[i+1].Value)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.wantKeys, "\n") {
+				t.Errorf("keys = %q, want %q", got, tt.wantKeys)
+			}
+			if version, _ := ConfigVersion(root); version != tt.wantFrom {
+				t.Errorf("元のノードが変更されました: version %d", version)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "先頭のコメントの直後にキー",
+			source: "# shutdown-alert の設定\n# 打刻ページ\nurl: https://example.com\n",
+			want:   "# shutdown-alert の設定\n# 打刻ページ\nurl: https://example.com\nversion: 1\n",
+		},
+		{
+			name:   "先頭のコメントと空行",
+			source: "# shutdown-alert の設定\n\n# 打刻ページ\nurl: https://example.com\n",
+			want:   "# shutdown-alert の設定\n\n# 打刻ページ\nurl: https://example.com\nversion: 1\n",
+		},
+		{
+			name:   "コメントなし",
+			source: "url: https://example.com\n",
+			want:   "version: 1\nurl: https://example.com\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ConfigFileName)
+			if err := os.WriteFile(path, []byte(tt.source), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			from, err := MigrateConfigFile(path)
+			if err != nil || from != 0 {
+				t.Fatalf("MigrateConfigFile() = %d, %v", from, err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("migrated file =\n%s\nwant\n%s", got, tt.want)
+			}
+			backup, err := os.ReadFile(path + ConfigBackupSuffix)
+			if err != nil || string(backup) != tt.source {
+				t.Errorf("backup = %q, %v, want %q", backup, err, tt.source)
+			}
+		})
+	}
+
+	t.Run("最新の形式は書き換えない", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFileName)
+		if err := os.WriteFile(path, []byte("version: 1\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if from, err := MigrateConfigFile(path); err != nil || from != CurrentConfigVersion {
+			t.Fatalf("MigrateConfigFile() = %d, %v", from, err)
+		}
+		if _, err := os.Stat(path + ConfigBackupSuffix); !os.IsNotExist(err) {
+			t.Errorf("backup exists: %v", err)
+		}
+	})
+}
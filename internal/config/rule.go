@@ -0,0 +1,404 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"shutdown-alert/internal/holiday"
+	"shutdown-alert/internal/session"
+)
+
+// weekdayNames は when.weekdays に指定できる曜日の名前です（time.Weekday の順）。
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// triggerNames は when.triggers に指定できる表示のきっかけです。
+var triggerNames = []string{TriggerSessionEnd, TriggerSchedule, TriggerLock, TriggerSuspend}
+
+// defaultTriggers は when.triggers を省略したルールが一致する表示のきっかけです。
+// ここに含まれないきっかけ（ロック・スリープ）は、それを指定したルールが一致したときだけダイアログを表示します。
+var defaultTriggers = []string{TriggerSessionEnd, TriggerSchedule}
+
+// Rule はリマインダーを切り替えるルール1つを保持します。
+// rules は上から順に評価し、条件（when）に最初に一致したルールを適用します。
+type Rule struct {
+	// Name はルールの表示名です（省略時は rules[0] のような位置で表示します）。
+	Name string `yaml:"name,omitempty"`
+	// When はルールを適用する条件です。省略した条件は常に一致します。
+	When RuleCondition `yaml:"when,omitempty"`
+	// Skip が true の場合、確認ダイアログを表示せずにセッション終了を許可します（ロックなどでは何もしません）。
+	Skip bool `yaml:"skip,omitempty"`
+	// 以下は指定した項目だけ、終了理由に対応するリマインダーを上書きします（nil は上書きしない）。
+	TargetURL     *string          `yaml:"target_url,omitempty"`
+	DialogMessage *string          `yaml:"dialog_message,omitempty"`
+	Actions       []ReminderAction `yaml:"actions,omitempty"`
+	Checklist     []ChecklistItem  `yaml:"checklist,omitempty"`
+}
+
+// RuleCondition はルールを適用する条件を保持します。指定した条件がすべて一致したときにルールを適用します。
+type RuleCondition struct {
+	// Time は "17:00-24:00" の形式の時間帯です（開始を含み、終了を含みません）。開始が終了より遅い場合は日をまたぎます。
+	Time string `yaml:"time,omitempty"`
+	// Weekdays は曜日（sun・mon・tue・wed・thu・fri・sat）のいずれかに一致します。
+	Weekdays []string `yaml:"weekdays,omitempty"`
+	// Dates は "2025-12-29" または "2025-12-29/2026-01-03"（両端を含む期間）のいずれかに一致します。
+	Dates []string `yaml:"dates,omitempty"`
+	// Holidays は休日のカレンダーのいずれかで、今日が休日のときに一致します。
+	// 組み込みの日本の祝日（holiday.JapanCalendarName）か、カレンダーファイル（.csv・.ics）のパスを指定します。
+	// 相対パスは、rules を指定した設定ファイルのディレクトリからのパスです。
+	Holidays []string `yaml:"holidays,omitempty"`
+	// EndKinds は終了理由（session.EndKind の名前）のいずれかに一致します。
+	EndKinds []string `yaml:"end_kinds,omitempty"`
+	// Triggers は表示のきっかけ（session_end・schedule・lock・suspend）のいずれかに一致します。
+	// 省略時は defaultTriggers に一致します。
+	Triggers []string `yaml:"triggers,omitempty"`
+}
+
+// RuleLabel はルールの表示名を返します。名前がない場合は rules[index] を返します。
+// この関数は純粋関数です。
+func RuleLabel(rule Rule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("rules[%d]", index)
+}
+
+// MatchRule は rules のうち、表示のきっかけ・終了理由・時刻 now に最初に一致したルールとその位置を返します。
+// 一致するルールがない場合は ok に false を返します。
+// calendars は when.holidays に指定したカレンダーです（読み込めなかったカレンダーは一致しません）。
+// この関数は純粋関数です。
+func MatchRule(rules []Rule, calendars map[string]holiday.Calendar, trigger string, kind session.EndKind, now time.Time) (rule Rule, index int, ok bool) {
+	for i, candidate := range rules {
+		if candidate.When.Matches(calendars, trigger, kind, now) {
+			return candidate, i, true
+		}
+	}
+	return Rule{}, -1, false
+}
+
+// Matches は条件が表示のきっかけ・終了理由・時刻 now に一致するかを返します。
+// 読み込み時に検証済みのため、形式の誤りがある条件は一致しないものとして扱います。
+// この関数は純粋関数です。
+func (condition RuleCondition) Matches(calendars map[string]holiday.Calendar, trigger string, kind session.EndKind, now time.Time) bool {
+	triggers := condition.Triggers
+	if len(triggers) == 0 {
+		triggers = defaultTriggers
+	}
+	if !slices.Contains(triggers, trigger) {
+		return false
+	}
+	if condition.Time != "" {
+		start, end, err := parseTimeWindow(condition.Time)
+		minutes := now.Hour()*60 + now.Minute()
+		if err != nil || !inTimeWindow(minutes, start, end) {
+			return false
+		}
+	}
+	if len(condition.Weekdays) > 0 && !slices.Contains(condition.Weekdays, weekdayNames[now.Weekday()]) {
+		return false
+	}
+	if len(condition.Dates) > 0 && !slices.ContainsFunc(condition.Dates, func(dates string) bool {
+		first, last, err := parseDateRange(dates)
+		today := now.Format(holiday.DateFormat)
+		return err == nil && first <= today && today <= last
+	}) {
+		return false
+	}
+	if len(condition.Holidays) > 0 && !slices.ContainsFunc(condition.Holidays, func(name string) bool {
+		calendar, ok := calendars[name]
+		if !ok {
+			return false
+		}
+		_, isHoliday := calendar.Holiday(now)
+		return isHoliday
+	}) {
+		return false
+	}
+	if len(condition.EndKinds) > 0 && !slices.Contains(condition.EndKinds, kind.String()) {
+		return false
+	}
+	return true
+}
+
+// apply はルールで指定した項目を reminder に上書きして返します。
+// この関数は純粋関数です。
+func (rule Rule) apply(reminder Reminder) Reminder {
+	if rule.TargetURL != nil {
+		reminder.TargetURL = *rule.TargetURL
+	}
+	if rule.DialogMessage != nil {
+		reminder.DialogMessage = *rule.DialogMessage
+	}
+	if rule.Actions != nil {
+		reminder.Actions = rule.Actions
+	}
+	if rule.Checklist != nil {
+		reminder.Checklist = rule.Checklist
+	}
+	return reminder
+}
+
+// RuleFor は表示のきっかけ・終了理由・時刻 now に一致するルールとその位置を返します（一致しない場合は ok に false）。
+// この関数は純粋関数です。
+func (userConfig UserConfig) RuleFor(trigger string, kind session.EndKind, now time.Time) (rule Rule, index int, ok bool) {
+	return MatchRule(userConfig.Rules, userConfig.Calendars, trigger, kind, now)
+}
+
+// ReminderAt は終了理由に対応するリマインダーに、表示のきっかけと時刻 now に一致するルールを適用して返します。
+// この関数は純粋関数です。
+func (userConfig UserConfig) ReminderAt(trigger string, kind session.EndKind, now time.Time) Reminder {
+	reminder := userConfig.ReminderFor(kind)
+	if rule, _, ok := userConfig.RuleFor(trigger, kind, now); ok {
+		return rule.apply(reminder)
+	}
+	return reminder
+}
+
+// Skips は表示のきっかけと時刻 now で、確認ダイアログを表示しないかを返します。
+// 一致したルールが skip の場合に加え、ロック・スリープのように既定で無効なきっかけで一致するルールがない場合も表示しません。
+// この関数は純粋関数です。
+func (userConfig UserConfig) Skips(trigger string, kind session.EndKind, now time.Time) bool {
+	rule, _, ok := userConfig.RuleFor(trigger, kind, now)
+	if !ok {
+		return !TriggerEnabledByDefault(trigger)
+	}
+	return rule.Skip
+}
+
+// TriggerForEndKind は、セッションイベントの終了理由 kind に対する表示のきっかけを返します。
+// ロックは lock、スリープは（OSが問い合わせるか通知するだけかによらず）suspend、それ以外は session_end です。
+// この関数は純粋関数です。
+func TriggerForEndKind(kind session.EndKind) string {
+	switch kind {
+	case session.EndKindLock:
+		return TriggerLock
+	case session.EndKindSleep:
+		return TriggerSuspend
+	}
+	return TriggerSessionEnd
+}
+
+// TriggerEnabledByDefault は、一致するルールがなくてもダイアログを表示する表示のきっかけかを返します。
+// この関数は純粋関数です。
+func TriggerEnabledByDefault(trigger string) bool {
+	return slices.Contains(defaultTriggers, trigger)
+}
+
+// resolveRules はルールを検証して返します。
+// ルールは順序に意味があり、1つを除くと別のルールが一致してしまうため、問題が1つでもあればルールをすべて使用しません。
+// この関数は副作用（v の変更）を持ちます。
+func resolveRules(v *validator, rules []Rule) []Rule {
+	problems := len(v.errors)
+	for i, rule := range rules {
+		prefix := fmt.Sprintf("rules[%d].", i)
+		validateCondition(v, prefix+"when.", rule.When)
+		if rule.TargetURL != nil {
+			if err := validateTargetURL(*rule.TargetURL); err != nil {
+				v.add(prefix+"target_url", err)
+			}
+		}
+		if rule.DialogMessage != nil {
+			if err := validateDialogMessage(*rule.DialogMessage); err != nil {
+				v.add(prefix+"dialog_message", err)
+			}
+		}
+		if rule.Actions != nil {
+			if err := validateActions(rule.Actions); err != nil {
+				v.add(prefix+"actions", err)
+			}
+		}
+		if rule.Checklist != nil {
+			if err := validateChecklist(rule.Checklist); err != nil {
+				v.add(prefix+"checklist", err)
+			}
+		}
+	}
+
+	if len(v.errors) > problems {
+		return nil
+	}
+	return rules
+}
+
+// validateCondition はルールの条件の形式を検証し、問題を v に記録します。
+// この関数は副作用（v の変更）を持ちます。
+func validateCondition(v *validator, prefix string, condition RuleCondition) {
+	if condition.Time != "" {
+		if _, _, err := parseTimeWindow(condition.Time); err != nil {
+			v.add(prefix+"time", err)
+		}
+	}
+	for i, weekday := range condition.Weekdays {
+		if !slices.Contains(weekdayNames, weekday) {
+			v.add(fmt.Sprintf("%sweekdays[%d]", prefix, i), fmt.Errorf("%s のいずれかを指定してください: %s", strings.Join(weekdayNames, "・"), weekday))
+		}
+	}
+	for i, dates := range condition.Dates {
+		if _, _, err := parseDateRange(dates); err != nil {
+			v.add(fmt.Sprintf("%sdates[%d]", prefix, i), err)
+		}
+	}
+	for i, name := range condition.Holidays {
+		if name == "" {
+			v.add(fmt.Sprintf("%sholidays[%d]", prefix, i), fmt.Errorf("%s またはカレンダーファイルのパスを指定してください", holiday.JapanCalendarName))
+		}
+	}
+	for i, name := range condition.EndKinds {
+		if _, ok := session.ParseEndKind(name); !ok {
+			v.add(fmt.Sprintf("%send_kinds[%d]", prefix, i), fmt.Errorf("未知の終了理由です: %s", name))
+		}
+	}
+	for i, trigger := range condition.Triggers {
+		if !slices.Contains(triggerNames, trigger) {
+			v.add(fmt.Sprintf("%striggers[%d]", prefix, i), fmt.Errorf("%s のいずれかを指定してください: %s", strings.Join(triggerNames, "・"), trigger))
+		}
+	}
+}
+
+// parseTimeWindow は "17:00-24:00" の形式の時間帯を、0時からの分数の開始と終了に変換します。
+// この関数は純粋関数です。
+func parseTimeWindow(window string) (start, end int, err error) {
+	first, last, ok := strings.Cut(window, "-")
+	if ok {
+		start, err = parseClock(first)
+	}
+	if ok && err == nil {
+		end, err = parseClock(last)
+	}
+	if !ok || err != nil {
+		return 0, 0, fmt.Errorf("時間帯は 17:00-24:00 の形式で指定してください: %s", window)
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("開始と終了が同じ時刻です: %s", window)
+	}
+	return start, end, nil
+}
+
+// parseClock は "17:00" の形式の時刻を0時からの分数に変換します（24:00 も指定できます）。
+// この関数は純粋関数です。
+func parseClock(clock string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(clock), "%d:%d", &hour, &minute); err != nil {
+		return 0, err
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("範囲外の時刻です: %s", clock)
+	}
+	return hour*60 + minute, nil
+}
+
+// inTimeWindow は0時からの分数 minutes が時間帯に含まれるかを返します。
+// 開始が終了より遅い時間帯（22:00-06:00 など）は日をまたぐものとして扱います。
+// この関数は純粋関数です。
+func inTimeWindow(minutes, start, end int) bool {
+	if start < end {
+		return start <= minutes && minutes < end
+	}
+	return minutes >= start || minutes < end
+}
+
+// parseDateRange は "2025-12-29" または "2025-12-29/2026-01-03" の形式の期間を、
+// 最初と最後の日付（holiday.DateFormat の文字列）に変換します。
+// この関数は純粋関数です。
+func parseDateRange(dates string) (first, last string, err error) {
+	first, last, isRange := strings.Cut(dates, "/")
+	if !isRange {
+		last = first
+	}
+	for _, date := range []string{first, last} {
+		if _, err := time.Parse(holiday.DateFormat, date); err != nil {
+			return "", "", fmt.Errorf("日付は 2025-12-29 または 2025-12-29/2026-01-03 の形式で指定してください: %s", dates)
+		}
+	}
+	if first > last {
+		return "", "", fmt.Errorf("期間の開始が終了より後です: %s", dates)
+	}
+	return first, last, nil
+}
+
+// checkRuleKeys は rules の各項目と when の未知のキーを記録します。
+// この関数は副作用（v の変更）を持ちます。
+func checkRuleKeys(v *validator, rules *yaml.Node, checkItems func(list *yaml.Node, key string, known []string)) {
+	if rules == nil || rules.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, rule := range rules.Content {
+		prefix := fmt.Sprintf("rules[%d]", i)
+		v.checkKeys(rule, prefix+".", yamlKeys(reflect.TypeOf(Rule{})))
+		if rule.Kind != yaml.MappingNode {
+			continue
+		}
+		v.checkKeys(mappingValue(rule, "when"), prefix+".when.", yamlKeys(reflect.TypeOf(RuleCondition{})))
+		checkItems(mappingValue(rule, "actions"), prefix+".actions", yamlKeys(reflect.TypeOf(ReminderAction{})))
+		checkItems(mappingValue(rule, "checklist"), prefix+".checklist", yamlKeys(reflect.TypeOf(ChecklistItem{})))
+	}
+}
+
+// loadCalendars は rules の when.holidays に指定したカレンダー（組み込みのカレンダーまたはファイル）を読み込み、userConfig.Calendars に設定します。
+// 相対パスは dir からのパスとして読み込みます。読み込めないカレンダーは記録し、そのカレンダーの条件は一致しなくなります。
+// この関数は副作用（ファイルの読み取り、userConfig の変更）を持ちます。
+func loadCalendars(userConfig *UserConfig, dir string) error {
+	calendars := map[string]holiday.Calendar{}
+	var errs ValidationErrors
+	for i, rule := range userConfig.Rules {
+		for j, name := range rule.When.Holidays {
+			if _, loaded := calendars[name]; loaded {
+				continue
+			}
+			if calendar, ok := holiday.BuiltIn(name); ok {
+				calendars[name] = calendar
+				continue
+			}
+			path := name
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			calendar, err := holiday.LoadFile(path)
+			if err != nil {
+				errs = append(errs, ValidationError{Key: fmt.Sprintf("rules[%d].when.holidays[%d]", i, j), Message: "カレンダーを読み込めませんでした: " + err.Error()})
+				continue
+			}
+			calendars[name] = calendar
+		}
+	}
+
+	userConfig.Calendars = calendars
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// joinErrors は設定の問題 err に more を続けて返します。どちらかが nil の場合はもう一方を返します。
+// この関数は純粋関数です。
+func joinErrors(err, more error) error {
+	if err == nil {
+		return more
+	}
+	if more == nil {
+		return err
+	}
+	var errs, moreErrs ValidationErrors
+	if errors.As(err, &errs) && errors.As(more, &moreErrs) {
+		return append(slices.Clone(errs), moreErrs...)
+	}
+	return errors.Join(err, more)
+}
+
+// rulesLayerPath は rules を指定した層の設定ファイルのパスを返します（どの層にも指定がない場合は空文字列）。
+// この関数は純粋関数です。
+func rulesLayerPath(layered LayeredConfig) string {
+	origin := layered.OriginOf("rules")
+	for _, layer := range layered.Layers {
+		if layer.Name == origin {
+			return layer.Path
+		}
+	}
+	return ""
+}
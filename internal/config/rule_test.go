@@ -0,0 +1,234 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/holiday"
+	"shutdown-alert/internal/session"
+)
+
+// ruleTime は2026年10月 day 日の hour 時 minute 分を返します（16日は金曜日）。
+func ruleTime(day, hour, minute int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+}
+
+// ruleCalendars はテスト用の休日のカレンダーです（10月17日・18日が休日）。
+var ruleCalendars = map[string]holiday.Calendar{
+	"company.csv": holiday.DateList{"2026-10-17": "創立記念日", "2026-10-18": "創立記念日"},
+}
+
+func TestConditionMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition RuleCondition
+		trigger   string
+		kind      session.EndKind
+		now       time.Time
+		want      bool
+	}{
+		{name: "条件なし", trigger: TriggerSessionEnd, now: ruleTime(16, 12, 0), want: true},
+		{name: "条件なしは schedule にも一致", trigger: TriggerSchedule, now: ruleTime(16, 12, 0), want: true},
+		{name: "条件なしは lock に一致しない", trigger: TriggerLock, now: ruleTime(16, 12, 0)},
+		{name: "条件なしは suspend に一致しない", trigger: TriggerSuspend, now: ruleTime(16, 12, 0)},
+		{name: "triggers に lock", condition: RuleCondition{Triggers: []string{TriggerLock}}, trigger: TriggerLock, now: ruleTime(16, 12, 0), want: true},
+		{name: "triggers が異なる", condition: RuleCondition{Triggers: []string{TriggerLock}}, trigger: TriggerSessionEnd, now: ruleTime(16, 12, 0)},
+
+		{name: "時間帯の開始を含む", condition: RuleCondition{Time: "17:00-24:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 17, 0), want: true},
+		{name: "時間帯の直前", condition: RuleCondition{Time: "17:00-24:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 16, 59)},
+		{name: "24:00 までは 23:59 を含む", condition: RuleCondition{Time: "17:00-24:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 23, 59), want: true},
+		{name: "時間帯の終了を含まない", condition: RuleCondition{Time: "09:00-17:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 17, 0)},
+		{name: "日をまたぐ時間帯（夜）", condition: RuleCondition{Time: "22:00-06:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 23, 0), want: true},
+		{name: "日をまたぐ時間帯（朝）", condition: RuleCondition{Time: "22:00-06:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 5, 59), want: true},
+		{name: "日をまたぐ時間帯（昼）", condition: RuleCondition{Time: "22:00-06:00"}, trigger: TriggerSessionEnd, now: ruleTime(16, 12, 0)},
+		{name: "形式の誤りは一致しない", condition: RuleCondition{Time: "17時から"}, trigger: TriggerSessionEnd, now: ruleTime(16, 18, 0)},
+
+		{name: "曜日（金）", condition: RuleCondition{Weekdays: []string{"mon", "fri"}}, trigger: TriggerSessionEnd, now: ruleTime(16, 12, 0), want: true},
+		{name: "曜日（土）", condition: RuleCondition{Weekdays: []string{"mon", "fri"}}, trigger: TriggerSessionEnd, now: ruleTime(17, 12, 0)},
+
+		{name: "日付", condition: RuleCondition{Dates: []string{"2026-10-16"}}, trigger: TriggerSessionEnd, now: ruleTime(16, 23, 59), want: true},
+		{name: "期間の初日", condition: RuleCondition{Dates: []string{"2026-10-16/2026-10-18"}}, trigger: TriggerSessionEnd, now: ruleTime(16, 0, 0), want: true},
+		{name: "期間の最終日", condition: RuleCondition{Dates: []string{"2026-10-16/2026-10-18"}}, trigger: TriggerSessionEnd, now: ruleTime(18, 23, 59), want: true},
+		{name: "期間の翌日", condition: RuleCondition{Dates: []string{"2026-10-16/2026-10-18"}}, trigger: TriggerSessionEnd, now: ruleTime(19, 0, 0)},
+		{name: "いずれかの日付", condition: RuleCondition{Dates: []string{"2026-01-01", "2026-10-19"}}, trigger: TriggerSessionEnd, now: ruleTime(19, 9, 0), want: true},
+
+		{name: "休日", condition: RuleCondition{Holidays: []string{"company.csv"}}, trigger: TriggerSessionEnd, now: ruleTime(17, 12, 0), want: true},
+		{name: "平日", condition: RuleCondition{Holidays: []string{"company.csv"}}, trigger: TriggerSessionEnd, now: ruleTime(16, 12, 0)},
+		{name: "読み込めなかったカレンダー", condition: RuleCondition{Holidays: []string{"missing.ics"}}, trigger: TriggerSessionEnd, now: ruleTime(17, 12, 0)},
+
+		{name: "終了理由", condition: RuleCondition{EndKinds: []string{"restart"}}, trigger: TriggerSessionEnd, kind: session.EndKindRestart, now: ruleTime(16, 12, 0), want: true},
+		{name: "終了理由が異なる", condition: RuleCondition{EndKinds: []string{"restart"}}, trigger: TriggerSessionEnd, kind: session.EndKindShutdown, now: ruleTime(16, 12, 0)},
+
+		{
+			name:      "すべての条件に一致",
+			condition: RuleCondition{Time: "17:00-24:00", Weekdays: []string{"fri"}, EndKinds: []string{"shutdown"}},
+			trigger:   TriggerSessionEnd,
+			kind:      session.EndKindShutdown,
+			now:       ruleTime(16, 18, 0),
+			want:      true,
+		},
+		{
+			name:      "1つでも一致しなければ一致しない",
+			condition: RuleCondition{Time: "17:00-24:00", Weekdays: []string{"fri"}, EndKinds: []string{"shutdown"}},
+			trigger:   TriggerSessionEnd,
+			kind:      session.EndKindShutdown,
+			now:       ruleTime(16, 16, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.condition.Matches(ruleCalendars, tt.trigger, tt.kind, tt.now); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRule(t *testing.T) {
+	rules := []Rule{
+		{Name: "祝日", When: RuleCondition{Holidays: []string{"company.csv"}}, Skip: true},
+		{Name: "退勤", When: RuleCondition{Time: "17:00-24:00", Weekdays: []string{"mon", "tue", "wed", "thu", "fri"}}},
+		{When: RuleCondition{Weekdays: []string{"sat", "sun"}}},
+	}
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		wantOK    bool
+		wantIndex int
+		wantLabel string
+	}{
+		{name: "平日の夕方", now: ruleTime(16, 18, 0), wantOK: true, wantIndex: 1, wantLabel: "退勤"},
+		{name: "平日の昼", now: ruleTime(16, 12, 0), wantIndex: -1},
+		{name: "休日の土曜は先のルール", now: ruleTime(17, 18, 0), wantOK: true, wantIndex: 0, wantLabel: "祝日"},
+		{name: "休日でない土曜", now: ruleTime(24, 18, 0), wantOK: true, wantIndex: 2, wantLabel: "rules[2]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, index, ok := MatchRule(rules, ruleCalendars, TriggerSessionEnd, session.EndKindShutdown, tt.now)
+			if ok != tt.wantOK || index != tt.wantIndex {
+				t.Fatalf("MatchRule() = %d, %v, want %d, %v", index, ok, tt.wantIndex, tt.wantOK)
+			}
+			if ok && RuleLabel(rule, index) != tt.wantLabel {
+				t.Errorf("RuleLabel() = %s, want %s", RuleLabel(rule, index), tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestReminderAtAndSkips(t *testing.T) {
+	weekendMessage := "週末もお疲れさまでした"
+	noURL := ""
+	userConfig := DefaultUserConfig()
+	userConfig.Calendars = ruleCalendars
+	userConfig.Rules = []Rule{
+		{When: RuleCondition{Holidays: []string{"company.csv"}}, Skip: true},
+		{When: RuleCondition{Weekdays: []string{"sat", "sun"}}, DialogMessage: &weekendMessage, TargetURL: &noURL},
+		{When: RuleCondition{Triggers: []string{TriggerLock}, Time: "17:00-24:00"}},
+	}
+	base := userConfig.ReminderFor(session.EndKindShutdown)
+
+	tests := []struct {
+		name        string
+		trigger     string
+		now         time.Time
+		wantSkip    bool
+		wantMessage string
+		wantURL     string
+	}{
+		{name: "ルールに一致しない平日", trigger: TriggerSessionEnd, now: ruleTime(16, 18, 0), wantMessage: base.DialogMessage, wantURL: base.TargetURL},
+		{name: "skip のルール", trigger: TriggerSessionEnd, now: ruleTime(17, 18, 0), wantSkip: true, wantMessage: base.DialogMessage, wantURL: base.TargetURL},
+		{name: "上書きするルール", trigger: TriggerSessionEnd, now: ruleTime(24, 18, 0), wantMessage: weekendMessage},
+		{name: "lock は一致するルールがあれば表示", trigger: TriggerLock, now: ruleTime(16, 18, 0), wantMessage: base.DialogMessage, wantURL: base.TargetURL},
+		{name: "lock は一致するルールがなければ表示しない", trigger: TriggerLock, now: ruleTime(16, 12, 0), wantSkip: true, wantMessage: base.DialogMessage, wantURL: base.TargetURL},
+		{name: "suspend はルールがなければ表示しない", trigger: TriggerSuspend, now: ruleTime(16, 18, 0), wantSkip: true, wantMessage: base.DialogMessage, wantURL: base.TargetURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userConfig.Skips(tt.trigger, session.EndKindShutdown, tt.now); got != tt.wantSkip {
+				t.Errorf("Skips() = %v, want %v", got, tt.wantSkip)
+			}
+			reminder := userConfig.ReminderAt(tt.trigger, session.EndKindShutdown, tt.now)
+			if reminder.DialogMessage != tt.wantMessage || reminder.TargetURL != tt.wantURL {
+				t.Errorf("ReminderAt() = %q, %q, want %q, %q", reminder.DialogMessage, reminder.TargetURL, tt.wantMessage, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestTriggerForEndKind(t *testing.T) {
+	tests := []struct {
+		kind session.EndKind
+		want string
+	}{
+		{kind: session.EndKindShutdown, want: TriggerSessionEnd},
+		{kind: session.EndKindRestart, want: TriggerSessionEnd},
+		{kind: session.EndKindLogoff, want: TriggerSessionEnd},
+		{kind: session.EndKindLock, want: TriggerLock},
+		{kind: session.EndKindSleep, want: TriggerSuspend},
+	}
+
+	for _, tt := range tests {
+		if got := TriggerForEndKind(tt.kind); got != tt.want {
+			t.Errorf("TriggerForEndKind(%v) = %s, want %s", tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := []struct {
+		window    string
+		wantStart int
+		wantEnd   int
+		wantErr   bool
+	}{
+		{window: "17:00-24:00", wantStart: 17 * 60, wantEnd: 24 * 60},
+		{window: "9:30 - 17:45", wantStart: 9*60 + 30, wantEnd: 17*60 + 45},
+		{window: "22:00-06:00", wantStart: 22 * 60, wantEnd: 6 * 60},
+		{window: "17:00-17:00", wantErr: true},
+		{window: "17:00-24:01", wantErr: true},
+		{window: "17:60-18:00", wantErr: true},
+		{window: "17:00", wantErr: true},
+		{window: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		start, end, err := parseTimeWindow(tt.window)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTimeWindow(%q) error = %v, wantErr %v", tt.window, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (start != tt.wantStart || end != tt.wantEnd) {
+			t.Errorf("parseTimeWindow(%q) = %d, %d, want %d, %d", tt.window, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		dates     string
+		wantFirst string
+		wantLast  string
+		wantErr   bool
+	}{
+		{dates: "2025-12-29", wantFirst: "2025-12-29", wantLast: "2025-12-29"},
+		{dates: "2025-12-29/2026-01-03", wantFirst: "2025-12-29", wantLast: "2026-01-03"},
+		{dates: "2026-01-03/2025-12-29", wantErr: true},
+		{dates: "2026-02-30", wantErr: true},
+		{dates: "2026/01/03", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		first, last, err := parseDateRange(tt.dates)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDateRange(%q) error = %v, wantErr %v", tt.dates, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (first != tt.wantFirst || last != tt.wantLast) {
+			t.Errorf("parseDateRange(%q) = %s, %s, want %s, %s", tt.dates, first, last, tt.wantFirst, tt.wantLast)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Schedule はセッション終了を待たずにリマインダーを表示する時刻1つを保持します。
+// at と after_minutes のどちらか一方を指定します。
+type Schedule struct {
+	// Name はスケジュールの表示名です（省略時は schedules[0] のような位置で表示します）。
+	Name string `yaml:"name,omitempty"`
+	// At は "18:00" の形式の時刻です。
+	At string `yaml:"at,omitempty"`
+	// AfterMinutes は今日の勤務の開始（その日の最初の記録）からの分数です。
+	AfterMinutes int `yaml:"after_minutes,omitempty"`
+	// Weekdays は表示する曜日（sun・mon・tue・wed・thu・fri・sat）です。省略時は毎日表示します。
+	Weekdays []string `yaml:"weekdays,omitempty"`
+}
+
+// ScheduleLabel はスケジュールの表示名を返します。名前がない場合は schedules[index] を返します。
+// この関数は純粋関数です。
+func ScheduleLabel(schedule Schedule, index int) string {
+	if schedule.Name != "" {
+		return schedule.Name
+	}
+	return fmt.Sprintf("schedules[%d]", index)
+}
+
+// DueSchedules は from より後、to まで（from を含まず to を含む）に時刻を迎えたスケジュールの位置を返します。
+// to の日の時刻だけを対象にするため、スリープからの復帰などで from が前日の場合も、前日の時刻はさかのぼって表示しません。
+// workStart は今日の勤務の開始時刻で、ゼロ値の場合 after_minutes のスケジュールは時刻を迎えません。
+// 定期的に前回の確認時刻と現在時刻を渡して呼び出すことで、各スケジュールを1日に1回ずつ表示できます。
+// この関数は純粋関数です。
+func DueSchedules(schedules []Schedule, from, to, workStart time.Time) []int {
+	var due []int
+	for i, schedule := range schedules {
+		if at, ok := schedule.timeOn(to, workStart); ok && at.After(from) && !at.After(to) {
+			due = append(due, i)
+		}
+	}
+	return due
+}
+
+// timeOn は day の日のスケジュールの時刻を返します。
+// 曜日が一致しない日と、after_minutes の時刻が day の日に収まらない場合は ok に false を返します。
+// この関数は純粋関数です。
+func (schedule Schedule) timeOn(day, workStart time.Time) (at time.Time, ok bool) {
+	if len(schedule.Weekdays) > 0 && !slices.Contains(schedule.Weekdays, weekdayNames[day.Weekday()]) {
+		return time.Time{}, false
+	}
+
+	if schedule.At != "" {
+		minutes, err := parseClock(schedule.At)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, day.Location()), true
+	}
+	if schedule.AfterMinutes <= 0 || workStart.IsZero() {
+		return time.Time{}, false
+	}
+	at = workStart.Add(time.Duration(schedule.AfterMinutes) * time.Minute)
+	atYear, atMonth, atDate := at.In(day.Location()).Date()
+	year, month, date := day.Date()
+	return at, atYear == year && atMonth == month && atDate == date
+}
+
+// resolveSchedules はスケジュールを検証して返します。
+// 問題のあるスケジュールは記録して除き、残りのスケジュールを返します。
+// この関数は副作用（v の変更）を持ちます。
+func resolveSchedules(v *validator, schedules []Schedule) []Schedule {
+	var valid []Schedule
+	for i, schedule := range schedules {
+		prefix := fmt.Sprintf("schedules[%d].", i)
+		problems := len(v.errors)
+
+		switch {
+		case (schedule.At == "") == (schedule.AfterMinutes == 0):
+			v.add(fmt.Sprintf("schedules[%d]", i), fmt.Errorf("at または after_minutes のどちらか一方を指定してください"))
+		case schedule.At != "":
+			if minutes, err := parseClock(schedule.At); err != nil || minutes >= 24*60 {
+				v.add(prefix+"at", fmt.Errorf("時刻は 00:00 から 23:59 の範囲で、18:00 の形式で指定してください: %s", schedule.At))
+			}
+		case schedule.AfterMinutes < 1 || schedule.AfterMinutes > MaxScheduleAfterMinutes:
+			v.add(prefix+"after_minutes", fmt.Errorf("1 から %d の範囲で指定してください: %d", MaxScheduleAfterMinutes, schedule.AfterMinutes))
+		}
+		for j, weekday := range schedule.Weekdays {
+			if !slices.Contains(weekdayNames, weekday) {
+				v.add(fmt.Sprintf("%sweekdays[%d]", prefix, j), fmt.Errorf("%s のいずれかを指定してください: %s", strings.Join(weekdayNames, "・"), weekday))
+			}
+		}
+
+		if len(v.errors) == problems {
+			valid = append(valid, schedule)
+		}
+	}
+	return valid
+}
+
+// checkScheduleKeys は schedules の各項目の未知のキーを記録します。
+// この関数は副作用（v の変更）を持ちます。
+func checkScheduleKeys(v *validator, schedules *yaml.Node) {
+	if schedules == nil || schedules.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, schedule := range schedules.Content {
+		v.checkKeys(schedule, fmt.Sprintf("schedules[%d].", i), yamlKeys(reflect.TypeOf(Schedule{})))
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// scheduleDay はスケジュールのテストで使う2026年10月 day 日の時刻を返します（16日は金曜日）。
+func scheduleDay(day, hour, minute, second int) time.Time {
+	return time.Date(2026, 10, day, hour, minute, second, 0, time.Local)
+}
+
+func TestDueSchedules(t *testing.T) {
+	evening := Schedule{At: "18:00"}
+	workStart := scheduleDay(16, 9, 0, 0)
+
+	tests := []struct {
+		name      string
+		schedules []Schedule
+		from      time.Time
+		to        time.Time
+		workStart time.Time
+		want      []int
+	}{
+		{
+			name:      "at の時刻を迎えた",
+			schedules: []Schedule{evening},
+			from:      scheduleDay(16, 17, 59, 30),
+			to:        scheduleDay(16, 18, 0, 0),
+			want:      []int{0},
+		},
+		{
+			name:      "前回の確認時刻ちょうどは含めない",
+			schedules: []Schedule{evening},
+			from:      scheduleDay(16, 18, 0, 0),
+			to:        scheduleDay(16, 18, 0, 30),
+		},
+		{
+			name:      "まだ迎えていない",
+			schedules: []Schedule{evening},
+			from:      scheduleDay(16, 17, 59, 0),
+			to:        scheduleDay(16, 17, 59, 30),
+		},
+		{
+			name:      "after_minutes は勤務の開始から数える",
+			schedules: []Schedule{{AfterMinutes: 480}},
+			from:      scheduleDay(16, 16, 59, 30),
+			to:        scheduleDay(16, 17, 0, 0),
+			workStart: workStart,
+			want:      []int{0},
+		},
+		{
+			name:      "勤務の開始がない場合 after_minutes は迎えない",
+			schedules: []Schedule{{AfterMinutes: 480}},
+			from:      scheduleDay(16, 16, 59, 30),
+			to:        scheduleDay(16, 17, 0, 0),
+		},
+		{
+			name:      "日付をまたぐ after_minutes",
+			schedules: []Schedule{{AfterMinutes: 300}},
+			from:      scheduleDay(17, 0, 59, 30),
+			to:        scheduleDay(17, 1, 0, 0),
+			workStart: scheduleDay(16, 20, 0, 0),
+			want:      []int{0},
+		},
+		{
+			name:      "曜日が一致する",
+			schedules: []Schedule{{At: "18:00", Weekdays: []string{"mon", "fri"}}},
+			from:      scheduleDay(16, 17, 59, 30),
+			to:        scheduleDay(16, 18, 0, 0),
+			want:      []int{0},
+		},
+		{
+			name:      "曜日が一致しない",
+			schedules: []Schedule{{At: "18:00", Weekdays: []string{"sat", "sun"}}, {AfterMinutes: 540, Weekdays: []string{"sat"}}},
+			from:      scheduleDay(16, 17, 59, 30),
+			to:        scheduleDay(16, 18, 0, 0),
+			workStart: workStart,
+		},
+		{
+			name:      "日付をまたいだ確認では新しい日の時刻を迎える",
+			schedules: []Schedule{{At: "00:00"}, {At: "23:59"}},
+			from:      scheduleDay(16, 23, 59, 45),
+			to:        scheduleDay(17, 0, 0, 15),
+			want:      []int{0},
+		},
+		{
+			name:      "スリープからの復帰で前日の時刻はさかのぼらない",
+			schedules: []Schedule{evening},
+			from:      scheduleDay(16, 17, 0, 0),
+			to:        scheduleDay(17, 9, 0, 0),
+		},
+		{
+			name:      "スリープからの復帰で前日の after_minutes はさかのぼらない",
+			schedules: []Schedule{{AfterMinutes: 480}},
+			from:      scheduleDay(16, 16, 0, 0),
+			to:        scheduleDay(17, 9, 0, 0),
+			workStart: workStart,
+		},
+		{
+			name:      "スリープからの復帰で同じ日の過ぎた時刻は表示する",
+			schedules: []Schedule{evening, {At: "08:30"}},
+			from:      scheduleDay(16, 17, 0, 0),
+			to:        scheduleDay(17, 9, 0, 0),
+			want:      []int{1},
+		},
+		{
+			name:      "迎えたスケジュールの位置だけを返す",
+			schedules: []Schedule{evening, {At: "12:00"}, {AfterMinutes: 540}},
+			from:      scheduleDay(16, 17, 59, 30),
+			to:        scheduleDay(16, 18, 0, 0),
+			workStart: workStart,
+			want:      []int{0, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DueSchedules(tt.schedules, tt.from, tt.to, tt.workStart)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DueSchedules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"shutdown-alert/internal/session"
+)
+
+// schemaObject はJSON Schemaの1つのスキーマです。
+type schemaObject = map[string]interface{}
+
+// urlPattern は http または https のURL（空文字列はアラートモード）に一致する正規表現です。
+// validateURL と同じく、スキームの大文字・小文字は区別しません。
+// ホスト名やパスにはテンプレート変数（{{.Hostname}} など）を使えます。
+const urlPattern = `^([Hh][Tt][Tt][Pp][Ss]?://[^/?#]+.*)?$`
+
+// ConfigSchema は config.yaml のJSON Schema（draft-07）を返します。
+// 範囲などの制約はバリデーションと同じ定数から組み立てます。
+// 設定ファイルで指定できるキー（構造体の yaml タグ）とスキーマのキーが一致しない場合はエラーを返します（食い違いを防ぐため）。
+// この関数は純粋関数です。
+func ConfigSchema() ([]byte, error) {
+	properties := configSchemaProperties()
+	if err := checkSchemaKeys("", reflect.TypeOf(UserConfig{}), properties); err != nil {
+		return nil, err
+	}
+	if err := checkSchemaKeys("end_kinds.*.", reflect.TypeOf(reminderOverride{}), reminderSchema()["properties"].(schemaObject)); err != nil {
+		return nil, err
+	}
+	if err := checkSchemaKeys("countdown.", reflect.TypeOf(Countdown{}), properties["countdown"].(schemaObject)["properties"].(schemaObject)); err != nil {
+		return nil, err
+	}
+	customAction := actionsSchema()["items"].(schemaObject)["oneOf"].([]schemaObject)[1]
+	if err := checkSchemaKeys("actions[].", reflect.TypeOf(ReminderAction{}), customAction["properties"].(schemaObject)); err != nil {
+		return nil, err
+	}
+	checklistItem := checklistSchema()["items"].(schemaObject)
+	if err := checkSchemaKeys("checklist[].", reflect.TypeOf(ChecklistItem{}), checklistItem["properties"].(schemaObject)); err != nil {
+		return nil, err
+	}
+	rule := rulesSchema()["items"].(schemaObject)["properties"].(schemaObject)
+	if err := checkSchemaKeys("rules[].", reflect.TypeOf(Rule{}), rule); err != nil {
+		return nil, err
+	}
+	if err := checkSchemaKeys("rules[].when.", reflect.TypeOf(RuleCondition{}), rule["when"].(schemaObject)["properties"].(schemaObject)); err != nil {
+		return nil, err
+	}
+	schedule := schedulesSchema()["items"].(schemaObject)["properties"].(schemaObject)
+	if err := checkSchemaKeys("schedules[].", reflect.TypeOf(Schedule{}), schedule); err != nil {
+		return nil, err
+	}
+
+	schema := schemaObject{
+		"$schema":              "http://json-schema.org/draft-07/schema#",
+		"title":                DialogTitle + " config.yaml",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// checkSchemaKeys は構造体の yaml のキーとスキーマの properties が一致することを確認します。
+// この関数は純粋関数です。
+func checkSchemaKeys(prefix string, structType reflect.Type, properties schemaObject) error {
+	keys := yamlKeys(structType)
+	for _, key := range keys {
+		if _, ok := properties[key]; !ok {
+			return fmt.Errorf("%s%s のスキーマがありません", prefix, key)
+		}
+	}
+	if len(properties) != len(keys) {
+		return fmt.Errorf("%s のスキーマに設定ファイルで指定できないキーがあります", prefix+"*")
+	}
+	return nil
+}
+
+// configSchemaProperties は設定ファイルの最上位のキーごとのスキーマを返します。
+// この関数は純粋関数です。
+func configSchemaProperties() schemaObject {
+	return schemaObject{
+		"version": schemaObject{
+			"description": "設定ファイルの形式のバージョン",
+			"type":        "integer",
+			"minimum":     1,
+			"maximum":     CurrentConfigVersion,
+		},
+		"target_url":     targetURLSchema(),
+		"dialog_width":   integerSchema("確認ダイアログの幅（ピクセル）", 0, MaxDialogSize),
+		"dialog_height":  integerSchema("確認ダイアログの高さ（ピクセル）", 0, MaxDialogSize),
+		"dialog_message": dialogMessageSchema(),
+		"actions":        actionsSchema(),
+		"checklist":      checklistSchema(),
+		"snooze_minutes": integerSchema("「後で」を選んでから再表示するまでの分数", 1, MaxSnoozeMinutes),
+		"countdown": schemaObject{
+			"description": "確認ダイアログの自動応答",
+			"type":        "object",
+			"properties": schemaObject{
+				"seconds": integerSchema("自動で応答するまでの秒数（0は無効）", 0, MaxCountdownSeconds),
+				"action": schemaObject{
+					"description": "0秒になったときに選ぶボタン（open・snooze・close または独自のボタンの label）",
+					"type":        "string",
+					"minLength":   1,
+				},
+			},
+			"additionalProperties": false,
+		},
+		"lifecycle": schemaObject{
+			"description": "応答後にアプリケーションを終了するか、常駐を続けるか",
+			"enum":        []string{LifecycleExit, LifecycleResident},
+		},
+		"resume_session": schemaObject{
+			"description": "応答後に中断したシャットダウン・再起動・ログオフを再開するか",
+			"type":        "boolean",
+		},
+		"resume_grace_seconds": integerSchema("URLを開いてから再開するまでの猶予（秒）", 0, MaxResumeGraceSeconds),
+		"end_kinds":            endKindsSchema(),
+		"rules":                rulesSchema(),
+		"schedules":            schedulesSchema(),
+		"done_today": schemaObject{
+			"description": "ボタン（open・close または独自のボタンの label）ごとに、選んだ日のそれ以降の表示を抑える動作（skip: 表示しない、toast: 通知だけ表示）",
+			"type":        "object",
+			"propertyNames": schemaObject{
+				"not": schemaObject{"const": ActionSnooze},
+			},
+			"additionalProperties": schemaObject{"enum": []string{DoneTodaySkip, DoneTodayToast}},
+		},
+	}
+}
+
+// integerSchema は minimum から maximum までの整数のスキーマを返します。
+// この関数は純粋関数です。
+func integerSchema(description string, minimum, maximum int) schemaObject {
+	return schemaObject{
+		"description": description,
+		"type":        "integer",
+		"minimum":     minimum,
+		"maximum":     maximum,
+	}
+}
+
+// targetURLSchema は target_url のスキーマを返します（validateTargetURL に対応）。
+// この関数は純粋関数です。
+func targetURLSchema() schemaObject {
+	return schemaObject{
+		"description": "開くURL（http または https）。空文字列はURLを開かないアラートモード",
+		"type":        "string",
+		"pattern":     urlPattern,
+	}
+}
+
+// dialogMessageSchema は dialog_message のスキーマを返します（validateDialogMessage に対応）。
+// この関数は純粋関数です。
+func dialogMessageSchema() schemaObject {
+	return schemaObject{
+		"description": "確認ダイアログのメッセージ（{{.URL}} などのテンプレート変数を使えます）",
+		"type":        "string",
+		"minLength":   1,
+	}
+}
+
+// actionsSchema は actions のスキーマを返します（validateActions・validateAction に対応）。
+// ボタンの重複と default: true の数はJSON Schemaでは表せないため、バリデーションだけで検証します。
+// この関数は純粋関数です。
+func actionsSchema() schemaObject {
+	custom := schemaObject{
+		"type": "object",
+		"properties": schemaObject{
+			"label": schemaObject{
+				"description": "ボタンの表示名",
+				"type":        "string",
+				"minLength":   1,
+				"not":         schemaObject{"enum": []string{ActionOpen, ActionSnooze, ActionClose}},
+			},
+			"key": schemaObject{
+				"description": "アクセラレータキー（英数字1文字）",
+				"type":        "string",
+				"pattern":     "^[A-Za-z0-9]$",
+			},
+			"url": schemaObject{
+				"description": "開くURL",
+				"type":        "string",
+				"pattern":     urlPattern,
+				"minLength":   1,
+			},
+			"command": schemaObject{
+				"description": "起動するコマンドと引数",
+				"type":        "array",
+				"items":       schemaObject{"type": "string"},
+				"minItems":    1,
+			},
+			"default": schemaObject{
+				"description": "Enterキーで選ばれるボタンにするか",
+				"type":        "boolean",
+			},
+		},
+		"required":             []string{"label"},
+		"oneOf":                []schemaObject{{"required": []string{"url"}}, {"required": []string{"command"}}},
+		"additionalProperties": false,
+	}
+
+	return schemaObject{
+		"description": "ダイアログに表示するボタン（close は必須）",
+		"type":        "array",
+		"items": schemaObject{
+			"oneOf": []schemaObject{
+				{"enum": []string{ActionOpen, ActionSnooze, ActionClose}},
+				custom,
+			},
+		},
+		"contains": schemaObject{"const": ActionClose},
+	}
+}
+
+// checklistSchema は checklist のスキーマを返します（validateChecklist に対応）。
+// この関数は純粋関数です。
+func checklistSchema() schemaObject {
+	return schemaObject{
+		"description": "ダイアログに表示するチェックリスト",
+		"type":        "array",
+		"items": schemaObject{
+			"type": "object",
+			"properties": schemaObject{
+				"label":    schemaObject{"type": "string", "minLength": 1},
+				"required": schemaObject{"type": "boolean"},
+			},
+			"required":             []string{"label"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// reminderSchema は end_kinds の各項目のスキーマを返します。
+// この関数は純粋関数です。
+func reminderSchema() schemaObject {
+	return schemaObject{
+		"type": "object",
+		"properties": schemaObject{
+			"target_url":     targetURLSchema(),
+			"dialog_message": dialogMessageSchema(),
+			"actions":        actionsSchema(),
+			"checklist":      checklistSchema(),
+		},
+		"additionalProperties": false,
+	}
+}
+
+// endKindsSchema は end_kinds のスキーマを返します（終了理由の名前だけをキーにできます）。
+// この関数は純粋関数です。
+func endKindsSchema() schemaObject {
+	properties := schemaObject{}
+	for _, kind := range session.EndKinds() {
+		properties[kind.String()] = reminderSchema()
+	}
+	return schemaObject{
+		"description":          "終了理由ごとのリマインダー",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// rulesSchema は rules のスキーマを返します（resolveRules・validateCondition に対応）。
+// 日付が実在するかと、期間の開始と終了の順序はJSON Schemaでは表せないため、バリデーションだけで検証します。
+// この関数は純粋関数です。
+func rulesSchema() schemaObject {
+	endKinds := make([]string, 0, len(session.EndKinds()))
+	for _, kind := range session.EndKinds() {
+		endKinds = append(endKinds, kind.String())
+	}
+	stringList := func(description string, items schemaObject) schemaObject {
+		return schemaObject{"description": description, "type": "array", "items": items}
+	}
+
+	return schemaObject{
+		"description": "条件でリマインダーを切り替えるルール（上から順に評価し、最初に一致したルールを適用）",
+		"type":        "array",
+		"items": schemaObject{
+			"type": "object",
+			"properties": schemaObject{
+				"name": schemaObject{"description": "ルールの表示名", "type": "string"},
+				"when": schemaObject{
+					"description": "ルールを適用する条件（指定した条件がすべて一致したときに適用）",
+					"type":        "object",
+					"properties": schemaObject{
+						"time": schemaObject{
+							"description": "時間帯（17:00-24:00 の形式。開始が終了より遅い場合は日をまたぐ）",
+							"type":        "string",
+							"pattern":     `^\d{1,2}:\d{2}-\d{1,2}:\d{2}$`,
+						},
+						"weekdays": weekdaysSchema("曜日のいずれか"),
+						"dates": stringList("日付または期間（2025-12-29 または 2025-12-29/2026-01-03）のいずれか",
+							schemaObject{"type": "string", "pattern": `^\d{4}-\d{2}-\d{2}(/\d{4}-\d{2}-\d{2})?$`}),
+						"holidays":  stringList("休日のカレンダー（japan: 日本の祝日、または .csv・.ics のパス）のいずれかで休日", schemaObject{"type": "string", "minLength": 1}),
+						"end_kinds": stringList("終了理由のいずれか", schemaObject{"enum": endKinds}),
+						"triggers": stringList("表示のきっかけのいずれか（省略時は session_end と schedule。lock・suspend は指定したルールが一致したときだけ表示）",
+							schemaObject{"enum": triggerNames}),
+					},
+					"additionalProperties": false,
+				},
+				"skip": schemaObject{
+					"description": "確認ダイアログを表示せずにセッション終了を許可するか",
+					"type":        "boolean",
+				},
+				"target_url":     targetURLSchema(),
+				"dialog_message": dialogMessageSchema(),
+				"actions":        actionsSchema(),
+				"checklist":      checklistSchema(),
+			},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// weekdaysSchema は曜日のリストのスキーマを返します。
+// この関数は純粋関数です。
+func weekdaysSchema(description string) schemaObject {
+	return schemaObject{"description": description, "type": "array", "items": schemaObject{"enum": weekdayNames}}
+}
+
+// schedulesSchema は schedules のスキーマを返します（resolveSchedules に対応）。
+// この関数は純粋関数です。
+func schedulesSchema() schemaObject {
+	return schemaObject{
+		"description": "セッション終了を待たずに確認ダイアログを表示する時刻",
+		"type":        "array",
+		"items": schemaObject{
+			"type": "object",
+			"properties": schemaObject{
+				"name": schemaObject{"description": "スケジュールの表示名", "type": "string"},
+				"at": schemaObject{
+					"description": "表示する時刻（18:00 の形式）",
+					"type":        "string",
+					"pattern":     `^([01]?\d|2[0-3]):[0-5]\d$`,
+				},
+				"after_minutes": integerSchema("今日の勤務の開始から表示するまでの分数", 1, MaxScheduleAfterMinutes),
+				"weekdays":      weekdaysSchema("表示する曜日（省略時は毎日）"),
+			},
+			"oneOf":                []schemaObject{{"required": []string{"at"}}, {"required": []string{"after_minutes"}}},
+			"additionalProperties": false,
+		},
+	}
+}
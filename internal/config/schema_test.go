@@ -0,0 +1,251 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+// decodedSchema は ConfigSchema が返すJSON Schemaをデコードして返します。
+func decodedSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := ConfigSchema()
+	if err != nil {
+		t.Fatalf("ConfigSchema() error = %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("ConfigSchema() はJSONではありません: %v", err)
+	}
+	return schema
+}
+
+// schemaAt は schema から keys の順にたどったスキーマを返します。数字のキーは配列の添字として扱います。
+func schemaAt(t *testing.T, schema map[string]interface{}, keys ...string) map[string]interface{} {
+	t.Helper()
+	var current interface{} = schema
+	for _, key := range keys {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index >= len(node) {
+				t.Fatalf("スキーマに %v がありません", keys)
+			}
+			current = node[index]
+		}
+	}
+	property, ok := current.(map[string]interface{})
+	if !ok {
+		t.Fatalf("スキーマに %v がありません", keys)
+	}
+	return property
+}
+
+// schemaEnum は enum のスキーマの値を返します。
+func schemaEnum(t *testing.T, schema map[string]interface{}) []string {
+	t.Helper()
+	values, ok := schema["enum"].([]interface{})
+	if !ok {
+		t.Fatalf("enum ではありません: %v", schema)
+	}
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.(string))
+	}
+	return names
+}
+
+// validates は source をバリデーションが受け付けるかを返します。
+func validates(source string) bool {
+	root, err := parseConfigDocument([]byte(source))
+	if err != nil {
+		return false
+	}
+	_, err = resolveUserConfig(root)
+	return err == nil
+}
+
+func TestSchemaRangesMatchValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   []string
+		format string
+	}{
+		{name: "version", path: []string{"properties", "version"}, format: "version: %d\n"},
+		{name: "dialog_width", path: []string{"properties", "dialog_width"}, format: "dialog_width: %d\n"},
+		{name: "dialog_height", path: []string{"properties", "dialog_height"}, format: "dialog_height: %d\n"},
+		{name: "snooze_minutes", path: []string{"properties", "snooze_minutes"}, format: "snooze_minutes: %d\n"},
+		{name: "resume_grace_seconds", path: []string{"properties", "resume_grace_seconds"}, format: "resume_grace_seconds: %d\n"},
+		{
+			name:   "countdown.seconds",
+			path:   []string{"properties", "countdown", "properties", "seconds"},
+			format: "countdown:\n  seconds: %d\n  action: close\n",
+		},
+		{
+			name:   "schedules[].after_minutes",
+			path:   []string{"properties", "schedules", "items", "properties", "after_minutes"},
+			format: "schedules:\n  - after_minutes: %d\n",
+		},
+	}
+
+	schema := decodedSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			property := schemaAt(t, schema, tt.path...)
+			minimum, maximum := int(property["minimum"].(float64)), int(property["maximum"].(float64))
+
+			for _, value := range []struct {
+				value int
+				want  bool
+			}{
+				{value: minimum, want: true},
+				{value: maximum, want: true},
+				{value: minimum - 1, want: false},
+				{value: maximum + 1, want: false},
+			} {
+				if got := validates(fmt.Sprintf(tt.format, value.value)); got != value.want {
+					t.Errorf("%s: %d はスキーマで %v、バリデーションで %v", tt.name, value.value, value.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaURLPatternMatchesValidation(t *testing.T) {
+	urls := []string{
+		"",
+		"https://attendance.example.com",
+		"http://attendance.example.com/punch?out=1",
+		"HTTPS://attendance.example.com",
+		"https://{{.Hostname}}.example.com/{{.EndKind}}",
+		"ftp://attendance.example.com",
+		"javascript:alert(1)",
+		"file:///C:/Windows",
+		"attendance.example.com",
+		"https://",
+		"https:///path",
+	}
+
+	schema := decodedSchema(t)
+	pattern := regexp.MustCompile(schemaAt(t, schema, "properties", "target_url")["pattern"].(string))
+	for _, targetURL := range urls {
+		schemaAccepts := pattern.MatchString(targetURL)
+		validatorAccepts := validates(fmt.Sprintf("target_url: %q\n", targetURL))
+		if schemaAccepts != validatorAccepts {
+			t.Errorf("target_url %q: スキーマで %v、バリデーションで %v", targetURL, schemaAccepts, validatorAccepts)
+		}
+	}
+}
+
+func TestSchemaEnumsMatchValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   []string
+		format string
+	}{
+		{name: "lifecycle", path: []string{"properties", "lifecycle"}, format: "lifecycle: %s\n"},
+		{
+			name:   "rules[].when.triggers",
+			path:   []string{"properties", "rules", "items", "properties", "when", "properties", "triggers", "items"},
+			format: "rules:\n  - when:\n      triggers: [%s]\n",
+		},
+		{
+			name:   "rules[].when.weekdays",
+			path:   []string{"properties", "rules", "items", "properties", "when", "properties", "weekdays", "items"},
+			format: "rules:\n  - when:\n      weekdays: [%s]\n",
+		},
+		{
+			name:   "rules[].when.end_kinds",
+			path:   []string{"properties", "rules", "items", "properties", "when", "properties", "end_kinds", "items"},
+			format: "rules:\n  - when:\n      end_kinds: [%s]\n",
+		},
+		{
+			name:   "schedules[].weekdays",
+			path:   []string{"properties", "schedules", "items", "properties", "weekdays", "items"},
+			format: "schedules:\n  - at: \"18:00\"\n    weekdays: [%s]\n",
+		},
+		{
+			name:   "done_today",
+			path:   []string{"properties", "done_today", "additionalProperties"},
+			format: "done_today:\n  open: %s\n",
+		},
+	}
+
+	schema := decodedSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, value := range schemaEnum(t, schemaAt(t, schema, tt.path...)) {
+				if !validates(fmt.Sprintf(tt.format, value)) {
+					t.Errorf("%s: スキーマの %q をバリデーションが受け付けません", tt.name, value)
+				}
+			}
+			if validates(fmt.Sprintf(tt.format, "unknown")) {
+				t.Errorf("%s: スキーマにない値をバリデーションが受け付けます", tt.name)
+			}
+		})
+	}
+}
+
+func TestSchemaRequiredStringsMatchValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   []string
+		source string
+	}{
+		{name: "dialog_message", path: []string{"properties", "dialog_message"}, source: "dialog_message: \"\"\n"},
+		{
+			name:   "checklist[].label",
+			path:   []string{"properties", "checklist", "items", "properties", "label"},
+			source: "checklist:\n  - label: \"\"\n",
+		},
+		{
+			name:   "actions[].label",
+			path:   []string{"properties", "actions", "items", "oneOf", "1", "properties", "label"},
+			source: "actions:\n  - close\n  - label: \"\"\n    url: https://example.com\n",
+		},
+	}
+
+	schema := decodedSchema(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			property := schemaAt(t, schema, tt.path...)
+			if property["minLength"] != float64(1) {
+				t.Errorf("%s: minLength = %v, want 1", tt.name, property["minLength"])
+			}
+			if validates(tt.source) {
+				t.Errorf("%s: 空文字列をバリデーションが受け付けます", tt.name)
+			}
+		})
+	}
+}
+
+func TestCheckSchemaKeys(t *testing.T) {
+	type sample struct {
+		Label    string `yaml:"label"`
+		Required bool   `yaml:"required,omitempty"`
+	}
+
+	tests := []struct {
+		name       string
+		properties schemaObject
+		wantErr    bool
+	}{
+		{name: "一致", properties: schemaObject{"label": schemaObject{}, "required": schemaObject{}}},
+		{name: "スキーマの更新漏れ", properties: schemaObject{"label": schemaObject{}}, wantErr: true},
+		{name: "設定ファイルにないキー", properties: schemaObject{"label": schemaObject{}, "required": schemaObject{}, "default": schemaObject{}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSchemaKeys("checklist[].", reflect.TypeOf(sample{}), tt.properties)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSchemaKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
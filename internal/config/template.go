@@ -0,0 +1,147 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"shutdown-alert/internal/session"
+)
+
+// TemplateData は target_url と dialog_message のテンプレートに渡す変数を保持します。
+// テンプレートでは {{.URL}} のようにフィールド名で参照します。
+type TemplateData struct {
+	// URL は展開後の target_url です（target_url 自身の展開中は空文字列です）。
+	URL string
+	// Date は今日の日付（例: 2026-10-17）です。
+	Date string
+	// Weekday は今日の曜日（例: 土曜日）です。
+	Weekday string
+	// User はログオン中のユーザー名です。
+	User string
+	// Hostname はPCのホスト名です。
+	Hostname string
+	// SessionStart はセッションの開始時刻（例: 09:03）です。
+	SessionStart string
+	// Elapsed はセッション開始からの経過時間（例: 8h12m）です。
+	Elapsed string
+	// EndKind は終了理由の表示名（例: シャットダウン）です。
+	EndKind string
+}
+
+// weekdayLabels は time.Weekday の順に並べた曜日の表示名です。
+var weekdayLabels = [...]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"}
+
+// NewTemplateData は現在時刻とセッションの情報からテンプレート変数を作成します。
+// URL は Reminder.Expand で設定するため空のままです。
+// この関数は純粋関数です。
+func NewTemplateData(kind session.EndKind, sessionStart, now time.Time, userName, hostname string) TemplateData {
+	return TemplateData{
+		Date:         now.Format(TemplateDateFormat),
+		Weekday:      WeekdayLabel(now.Weekday()),
+		User:         userName,
+		Hostname:     hostname,
+		SessionStart: sessionStart.Format(TemplateTimeFormat),
+		Elapsed:      FormatElapsed(now.Sub(sessionStart)),
+		EndKind:      EndKindLabel(kind),
+	}
+}
+
+// FormatElapsed は経過時間を「8h12m」の形式で返します（負の値は0として扱います）。
+// この関数は純粋関数です。
+func FormatElapsed(elapsed time.Duration) string {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	minutes := int(elapsed / time.Minute)
+	return fmt.Sprintf(ElapsedFormat, minutes/60, minutes%60)
+}
+
+// WeekdayLabel は曜日の表示名を返します。
+// この関数は純粋関数です。
+func WeekdayLabel(weekday time.Weekday) string {
+	return weekdayLabels[weekday]
+}
+
+// EndKindLabel は終了理由の表示名を返します。
+// この関数は純粋関数です。
+func EndKindLabel(kind session.EndKind) string {
+	switch kind {
+	case session.EndKindRestart:
+		return EndKindLabelRestart
+	case session.EndKindLogoff:
+		return EndKindLabelLogoff
+	case session.EndKindSleep:
+		return EndKindLabelSleep
+	case session.EndKindLock:
+		return EndKindLabelLock
+	case session.EndKindCloseApp:
+		return EndKindLabelCloseApp
+	}
+	return EndKindLabelShutdown
+}
+
+// Expand は target_url と dialog_message のテンプレートを展開したリマインダーを返します。
+// dialog_message の {{.URL}} には展開後の target_url を渡します。
+// 条件によって展開結果が変わるテンプレートもあるため、展開した target_url の安全性をここでも検証し、
+// http・https 以外のURLになった場合はエラーを返します。
+// この関数は純粋関数です。
+func (reminder Reminder) Expand(data TemplateData) (Reminder, error) {
+	data.URL = ""
+	targetURL, err := expandTemplate("target_url", reminder.TargetURL, data)
+	if err != nil {
+		return reminder, err
+	}
+	if err := validateURL(targetURL); err != nil {
+		return reminder, fmt.Errorf("展開した target_url は使用できません: %w", err)
+	}
+
+	data.URL = targetURL
+	message, err := expandTemplate("dialog_message", reminder.DialogMessage, data)
+	if err != nil {
+		return reminder, err
+	}
+
+	reminder.TargetURL = targetURL
+	reminder.DialogMessage = message
+	return reminder, nil
+}
+
+// expandTemplate はtext/templateの書式でtextを展開します。
+// 未定義の変数を参照した場合はエラーを返します。
+// この関数は純粋関数です。
+func expandTemplate(name, text string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("テンプレートの書式が正しくありません: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("テンプレートを展開できません: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// sampleTemplateData は読み込み時の検証に使うテンプレート変数です。
+// 構文エラーや未定義の変数は実際の値に関係なく検出できるため、固定の値を使います。
+// この関数は純粋関数です。
+func sampleTemplateData() TemplateData {
+	start := time.Date(2006, time.January, 2, 9, 0, 0, 0, time.UTC)
+	data := NewTemplateData(session.EndKindShutdown, start, start.Add(8*time.Hour), "user", "localhost")
+	data.URL = TargetURL
+	return data
+}
+
+// validateTargetURL は target_url のテンプレートを検証し、展開したURLの安全性を検証します。
+// この関数は純粋関数です。
+func validateTargetURL(targetURL string) error {
+	data := sampleTemplateData()
+	data.URL = ""
+	expanded, err := expandTemplate("target_url", targetURL, data)
+	if err != nil {
+		return err
+	}
+	return validateURL(expanded)
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError は設定ファイルの問題1つを表します。
+type ValidationError struct {
+	// File は問題のある設定ファイルのパスです（不明な場合は空）。
+	File string
+	// Line と Column はYAML上の位置（1始まり）です。位置が不明な場合は 0 です。
+	Line   int
+	Column int
+	// Key は問題のあるキー（end_kinds.restart.target_url のようにドットで区切ったパス）です。
+	Key     string
+	Message string
+}
+
+// Error は「ファイル: N行目M列: キー: メッセージ」の形式で問題を返します。
+func (e ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ": ")
+	}
+	if e.Line > 0 {
+		b.WriteString(fmt.Sprintf("%d行目", e.Line))
+		if e.Column > 0 {
+			b.WriteString(fmt.Sprintf("%d列", e.Column))
+		}
+		b.WriteString(": ")
+	}
+	if e.Key != "" {
+		b.WriteString(e.Key + ": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationErrors は設定ファイルのすべての問題を、見つかった順に保持します。
+type ValidationErrors []ValidationError
+
+// Error は問題を1行に1つずつ並べて返します。
+func (errs ValidationErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withFile は File が空の問題に file を設定したコピーを返します。
+// この関数は純粋関数です。
+func (errs ValidationErrors) withFile(file func(key string) string) ValidationErrors {
+	updated := make(ValidationErrors, len(errs))
+	for i, err := range errs {
+		if err.File == "" {
+			err.File = file(err.Key)
+		}
+		updated[i] = err
+	}
+	return updated
+}
+
+// validator は設定ファイルの問題を、最初の問題で止まらずにすべて集めます。
+type validator struct {
+	// root はキーから位置を探すための最上位のマッピングです。
+	root   *yaml.Node
+	errors ValidationErrors
+}
+
+// add はキーの問題を記録します。位置はキーの値のノード（見つからなければ最も近い親）から求めます。
+// この関数は副作用（v の変更）を持ちます。
+func (v *validator) add(key string, err error) {
+	v.addAt(nodeAtKey(v.root, key), key, err.Error())
+}
+
+// addAt は node の位置にキーの問題を記録します（node が nil の場合は位置なし）。
+// この関数は副作用（v の変更）を持ちます。
+func (v *validator) addAt(node *yaml.Node, key, message string) {
+	issue := ValidationError{Key: key, Message: message}
+	if node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	v.errors = append(v.errors, issue)
+}
+
+// err は記録した問題をファイル上の位置の順に並べて返します（問題がなければ nil）。
+// 位置が不明な問題は最後に並べます。
+// この関数は純粋関数です。
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(v.errors)
+	slices.SortStableFunc(sorted, func(a, b ValidationError) int {
+		if (a.Line == 0) != (b.Line == 0) {
+			if a.Line == 0 {
+				return 1
+			}
+			return -1
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	return sorted
+}
+
+// typeErrorLine は yaml.TypeError のメッセージの先頭の「line N: 」です。
+var typeErrorLine = regexp.MustCompile(`^line (\d+): `)
+
+// syntaxErrorLine は yaml の構文エラーのメッセージの先頭の「yaml: line N: 」です。
+var syntaxErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// syntaxError は yaml の構文エラーを、行番号を持つ ValidationErrors に変換します。
+// 行番号がわからない場合はそのまま返します。
+// この関数は純粋関数です。
+func syntaxError(err error) error {
+	match := syntaxErrorLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(match[1])
+	return ValidationErrors{{Line: line, Message: "YAMLの構文エラー: " + err.Error()[len(match[0]):]}}
+}
+
+// withFile は err が ValidationErrors の場合、File が空の問題に file(キー) を設定して返します。
+// それ以外のエラーはそのまま返します。
+// この関数は純粋関数です。
+func withFile(err error, file func(key string) string) error {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	return errs.withFile(file)
+}
+
+// decodeMapping は mapping の値を、out（構造体へのポインタ）の yaml タグが一致するフィールドへ1つずつデコードします。
+// 型の誤りがあるフィールドは記録してゼロ値のまま残し、残りのフィールドのデコードを続けます。
+// 指定できないキーは、最も近いキーの候補とともに記録します。
+// この関数は副作用（out・v の変更）を持ちます。
+func (v *validator) decodeMapping(mapping *yaml.Node, prefix string, out interface{}) {
+	fields := reflect.ValueOf(out).Elem()
+	known := yamlKeys(fields.Type())
+	v.checkKeys(mapping, prefix, known)
+
+	for i, name := range yamlFieldNames(fields.Type()) {
+		if name == "" {
+			continue
+		}
+		value := mappingValue(mapping, name)
+		if value == nil {
+			continue
+		}
+		if err := value.Decode(fields.Field(i).Addr().Interface()); err != nil {
+			v.addDecodeError(value, prefix+name, err)
+			fields.Field(i).Set(reflect.Zero(fields.Field(i).Type()))
+		}
+	}
+}
+
+// addDecodeError は yaml のデコードエラーを記録します。
+// yaml.TypeError に含まれる型の誤りは、それぞれの行の問題として記録します。
+// この関数は副作用（v の変更）を持ちます。
+func (v *validator) addDecodeError(node *yaml.Node, key string, err error) {
+	var typeError *yaml.TypeError
+	if !errors.As(err, &typeError) {
+		v.addAt(node, key, err.Error())
+		return
+	}
+
+	for _, message := range typeError.Errors {
+		issue := ValidationError{Key: key, Message: message, Line: node.Line, Column: node.Column}
+		if match := typeErrorLine.FindStringSubmatch(message); match != nil {
+			issue.Line, _ = strconv.Atoi(match[1])
+			if issue.Line != node.Line {
+				issue.Column = 0
+			}
+			issue.Message = message[len(match[0]):]
+		}
+		v.errors = append(v.errors, issue)
+	}
+}
+
+// checkKeys は mapping のキーのうち known に含まれないものを、最も近いキーの候補とともに記録します。
+// mapping がマッピングでない場合は何もしません（型の誤りはデコード時に記録します）。
+// この関数は副作用（v の変更）を持ちます。
+func (v *validator) checkKeys(mapping *yaml.Node, prefix string, known []string) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if slices.Contains(known, key.Value) {
+			continue
+		}
+		message := "未知のキーです"
+		if suggestion := closestKey(key.Value, known); suggestion != "" {
+			message += fmt.Sprintf("（%s のことですか？）", suggestion)
+		}
+		v.addAt(key, prefix+key.Value, message)
+	}
+}
+
+// yamlFieldNames は構造体のフィールドごとの yaml タグの名前を返します（タグがない、または "-" のフィールドは空）。
+// この関数は純粋関数です。
+func yamlFieldNames(structType reflect.Type) []string {
+	names := make([]string, structType.NumField())
+	for i := range names {
+		name, _, _ := strings.Cut(structType.Field(i).Tag.Get("yaml"), ",")
+		if name != "-" {
+			names[i] = name
+		}
+	}
+	return names
+}
+
+// yamlKeys は構造体で指定できる yaml のキーを定義順に返します。
+// この関数は純粋関数です。
+func yamlKeys(structType reflect.Type) []string {
+	var keys []string
+	for _, name := range yamlFieldNames(structType) {
+		if name != "" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// nodeAtKey はドットで区切ったキー（rules[0].when.time のようにリストの位置を含められます）の値のノードを返します。
+// 途中までしか見つからない場合は見つかった最も深いノードを、最上位のキーもない場合は nil を返します。
+// この関数は純粋関数です。
+func nodeAtKey(root *yaml.Node, key string) *yaml.Node {
+	parts := strings.Split(key, ".")
+	if root == nil {
+		return nil
+	}
+	if top, _, _ := strings.Cut(parts[0], "["); mappingValue(root, top) == nil {
+		return nil
+	}
+	node := root
+	for _, part := range parts {
+		name, indices, _ := strings.Cut(part, "[")
+		if node.Kind != yaml.MappingNode {
+			break
+		}
+		value := mappingValue(node, name)
+		if value == nil {
+			break
+		}
+		node = value
+		// [0][1] のようなリストの位置を順にたどります。
+		for _, index := range strings.Split(indices, "[") {
+			i, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			if err != nil || node.Kind != yaml.SequenceNode || i < 0 || i >= len(node.Content) {
+				break
+			}
+			node = node.Content[i]
+		}
+	}
+	return node
+}
+
+// closestKey は candidates のうち key に最も近い（編集距離が小さい）キーを返します。
+// 十分に近いキーがない場合は空文字列を返します。
+// この関数は純粋関数です。
+func closestKey(key string, candidates []string) string {
+	best, bestDistance := "", len(key)/2+1
+	for _, candidate := range candidates {
+		if distance := editDistance(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance は2つの文字列のレーベンシュタイン距離を返します。
+// この関数は純粋関数です。
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadUserConfigReportsEveryProblem(t *testing.T) {
+	const source = `version: 1
+target_url: "ftp://attendance.example.com"
+dialog_widht: 500
+dialog_height: 99999
+countdown:
+  seconds: ten
+  action: close
+end_kinds:
+  reboot:
+    dialog_message: "再起動します"
+schedules:
+  - at: "25:00"
+    weekdays: [fri, friday]
+`
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	if err := os.WriteFile(path, []byte(source), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadUserConfig(path)
+	var got ValidationErrors
+	if !errors.As(err, &got) {
+		t.Fatalf("LoadUserConfig() error = %v, want ValidationErrors", err)
+	}
+
+	want := ValidationErrors{
+		{File: path, Line: 2, Column: 13, Key: "target_url", Message: "URLスキームは http または https のみ許可されています: ftp"},
+		{File: path, Line: 3, Column: 1, Key: "dialog_widht", Message: "未知のキーです（dialog_width のことですか？）"},
+		{File: path, Line: 4, Column: 16, Key: "dialog_height", Message: "0 から 10000 の範囲で指定してください: 99999"},
+		{File: path, Line: 6, Column: 3, Key: "countdown", Message: "cannot unmarshal !!str `ten` into int"},
+		{File: path, Line: 10, Column: 5, Key: "end_kinds.reboot", Message: "未知の終了理由です: reboot"},
+		{File: path, Line: 12, Column: 9, Key: "schedules[0].at", Message: "時刻は 00:00 から 23:59 の範囲で、18:00 の形式で指定してください: 25:00"},
+		{File: path, Line: 13, Column: 21, Key: "schedules[0].weekdays[1]", Message: "sun・mon・tue・wed・thu・fri・sat のいずれかを指定してください: friday"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadUserConfig() errors =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestValidationErrorFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		err  ValidationError
+		want string
+	}{
+		{
+			name: "位置とキー",
+			err:  ValidationError{File: "config.yaml", Line: 3, Column: 1, Key: "dialog_widht", Message: "未知のキーです（dialog_width のことですか？）"},
+			want: "config.yaml: 3行目1列: dialog_widht: 未知のキーです（dialog_width のことですか？）",
+		},
+		{
+			name: "列が不明",
+			err:  ValidationError{File: "config.yaml", Line: 7, Key: "countdown", Message: "cannot unmarshal !!str `ten` into int"},
+			want: "config.yaml: 7行目: countdown: cannot unmarshal !!str `ten` into int",
+		},
+		{
+			name: "位置もファイルも不明",
+			err:  ValidationError{Key: "rules[0].when.holidays", Message: "カレンダーを読み込めません"},
+			want: "rules[0].when.holidays: カレンダーを読み込めません",
+		},
+		{
+			name: "キーなし",
+			err:  ValidationError{Line: 1, Message: "YAMLの構文エラー: did not find expected key"},
+			want: "1行目: YAMLの構文エラー: did not find expected key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// fileStamp は変更の検出に使うファイルの更新時刻とサイズです。
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+	exists  bool
+}
+
+// statFile はファイルの現在の fileStamp を返します（ファイルがない場合は exists が false）。
+// この関数は副作用（ファイルシステムへのアクセス）を持ちます。
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size(), exists: true}
+}
+
+// equal は2つの fileStamp が同じ状態を表すかを返します。
+// この関数は純粋関数です。
+func (stamp fileStamp) equal(other fileStamp) bool {
+	return stamp.exists == other.exists && stamp.size == other.size && stamp.modTime.Equal(other.modTime)
+}
+
+// Watch は設定ファイル（paths のすべて）を interval ごとに確認し、
+// いずれかに変更（作成・削除を含む）があれば onChange を1回呼び出します。
+// 外部のライブラリに依存しないよう、更新時刻とサイズのポーリングで検出します。
+// onChange は監視用のゴルーチンから呼び出されます。停止する関数を返します。
+// この関数は副作用（ゴルーチンの起動、ファイルシステムへのアクセス）を持ちます。
+func Watch(paths []string, interval time.Duration, onChange func()) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	last := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		last[i] = statFile(path)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				changed := false
+				for i, path := range paths {
+					current := statFile(path)
+					if !current.equal(last[i]) {
+						last[i] = current
+						changed = true
+					}
+				}
+				if changed {
+					onChange()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
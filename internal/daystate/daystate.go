@@ -0,0 +1,109 @@
+// このパッケージは「今日済ませたこと」（その日に選んだボタン）を、実行ファイルと同じディレクトリの
+// 小さな JSON ファイルに保存する。
+//
+// 履歴（history）と異なり、ファイルには最後に記録した日の状態だけを保持し、日付が変わると読み捨てる。
+// 書き込みは一時ファイルからの置き換えで行うため、書き込み途中で終了しても以前の状態は壊れない。
+package daystate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"shutdown-alert/internal/config"
+)
+
+// dateFormat はファイルに保存する日付の書式です。
+const dateFormat = "2006-01-02"
+
+// State は1日分の状態を表します。
+type State struct {
+	// Date は状態を記録した日（dateFormat）です。
+	Date string `json:"date"`
+	// Actions はその日に選んだボタンの ReminderAction.ID を、最初に選んだ順に並べたものです。
+	Actions []string `json:"actions"`
+}
+
+// Today は now の日の状態であれば state を、別の日の状態であれば空の状態を返します。
+// この関数は純粋関数です。
+func (state State) Today(now time.Time) State {
+	today := now.Format(dateFormat)
+	if state.Date != today {
+		return State{Date: today}
+	}
+	return state
+}
+
+// With は action を加えた状態を返します（既に含まれている場合はそのまま返します）。
+// この関数は純粋関数です。
+func (state State) With(action string) State {
+	if slices.Contains(state.Actions, action) {
+		return state
+	}
+	state.Actions = append(slices.Clone(state.Actions), action)
+	return state
+}
+
+// Store は状態を保存するファイルを表します。
+type Store struct {
+	path string
+}
+
+// NewStore は path に状態を保存する Store を作成します。
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultStore は実行ファイルと同じディレクトリのファイルに状態を保存する Store を返します。
+// この関数は副作用（ファイルシステムへのアクセス）を持ちます。
+func DefaultStore() (*Store, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(filepath.Dir(execPath), config.DoneTodayFileName)), nil
+}
+
+// Load は now の日の状態を返します。ファイルがない場合や、別の日の状態の場合は空の状態を返します。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func (store *Store) Load(now time.Time) (State, error) {
+	data, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}.Today(now), nil
+	}
+	if err != nil {
+		return State{}.Today(now), err
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}.Today(now), err
+	}
+	return state.Today(now), nil
+}
+
+// Mark は now の日に action を選んだことを記録します。
+// 前の日の状態は残しません。読み込めない状態のファイルは置き換えます。
+// この関数は副作用（ファイルの読み取り・書き込み）を持ちます。
+func (store *Store) Mark(now time.Time, action string) error {
+	state, _ := store.Load(now)
+	return store.save(state.With(action))
+}
+
+// save は状態をファイルに書き込みます。一時ファイルに書いてから置き換えます。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func (store *Store) save(state State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	temp := store.path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, store.path)
+}
@@ -0,0 +1,141 @@
+package daystate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// day は2026年10月 day 日の hour 時を返します。
+func day(day, hour int) time.Time {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, time.Local)
+}
+
+// markStep は Mark の呼び出しです。
+type markStep struct {
+	now    time.Time
+	action string
+}
+
+func TestStore(t *testing.T) {
+	tests := []struct {
+		name        string
+		initial     string
+		marks       []markStep
+		loadAt      time.Time
+		wantActions []string
+		wantLoadErr bool
+	}{
+		{
+			name:   "ファイルなし",
+			loadAt: day(16, 18),
+		},
+		{
+			name:        "同じ日に記録したボタン",
+			marks:       []markStep{{now: day(16, 18), action: "open"}, {now: day(16, 19), action: "日報"}},
+			loadAt:      day(16, 23),
+			wantActions: []string{"open", "日報"},
+		},
+		{
+			name:        "同じボタンは1回だけ",
+			marks:       []markStep{{now: day(16, 18), action: "open"}, {now: day(16, 19), action: "open"}},
+			loadAt:      day(16, 20),
+			wantActions: []string{"open"},
+		},
+		{
+			name:   "日付が変わると読み捨てる",
+			marks:  []markStep{{now: day(16, 23), action: "open"}},
+			loadAt: day(17, 0),
+		},
+		{
+			name:        "前の日の状態は残さない",
+			marks:       []markStep{{now: day(16, 18), action: "open"}, {now: day(17, 18), action: "close"}},
+			loadAt:      day(17, 19),
+			wantActions: []string{"close"},
+		},
+		{
+			name:        "壊れたファイルはエラー",
+			initial:     "{",
+			loadAt:      day(16, 18),
+			wantLoadErr: true,
+		},
+		{
+			name:        "壊れたファイルは記録で置き換える",
+			initial:     "{",
+			marks:       []markStep{{now: day(16, 18), action: "open"}},
+			loadAt:      day(16, 19),
+			wantActions: []string{"open"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "done_today.json")
+			if tt.initial != "" {
+				if err := os.WriteFile(path, []byte(tt.initial), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			store := NewStore(path)
+			for _, mark := range tt.marks {
+				if err := store.Mark(mark.now, mark.action); err != nil {
+					t.Fatalf("Mark(%s) error = %v", mark.action, err)
+				}
+			}
+
+			state, err := store.Load(tt.loadAt)
+			if (err != nil) != tt.wantLoadErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantLoadErr)
+			}
+			if state.Date != tt.loadAt.Format(dateFormat) {
+				t.Errorf("Load().Date = %s, want %s", state.Date, tt.loadAt.Format(dateFormat))
+			}
+			if !slices.Equal(state.Actions, tt.wantActions) {
+				t.Errorf("Load().Actions = %v, want %v", state.Actions, tt.wantActions)
+			}
+			// 一時ファイルから置き換えるため、書き込みの後に一時ファイルは残りません。
+			if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("一時ファイルが残っています: %v", err)
+			}
+		})
+	}
+}
+
+func TestMarkKeepsStateWhenWriteFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "done_today.json")
+	store := NewStore(path)
+	if err := store.Mark(day(16, 18), "open"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 一時ファイルの位置にディレクトリがあると書き込めません。置き換える前に失敗するため、以前の状態は残ります。
+	if err := os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Mark(day(16, 19), "close"); err == nil {
+		t.Fatal("Mark() error = nil, want write error")
+	}
+
+	state, err := store.Load(day(16, 20))
+	if err != nil || !slices.Equal(state.Actions, []string{"open"}) {
+		t.Errorf("Load() = %v, %v, want [open]", state.Actions, err)
+	}
+}
+
+func TestStateWith(t *testing.T) {
+	original := State{Date: "2026-10-16", Actions: []string{"open"}}
+
+	added := original.With("日報")
+	if !slices.Equal(added.Actions, []string{"open", "日報"}) {
+		t.Errorf("With() = %v, want [open 日報]", added.Actions)
+	}
+	if !slices.Equal(original.Actions, []string{"open"}) {
+		t.Errorf("With() が元の状態を変更しました: %v", original.Actions)
+	}
+	if same := original.With("open"); !slices.Equal(same.Actions, original.Actions) {
+		t.Errorf("With(open) = %v, want %v", same.Actions, original.Actions)
+	}
+}
@@ -0,0 +1,225 @@
+// このパッケージはアプリケーションの履歴を、実行ファイルと同じディレクトリの
+// JSON Lines ファイル（1行1レコード）に追記する。
+//
+// error_log.json と異なり、履歴は追記のみで古いレコードを削除しない。
+// 1行ずつ独立しているため、書き込み途中で終了しても既存のレコードは壊れない。
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"shutdown-alert/internal/config"
+)
+
+// レコードの種類
+const (
+	// RecordTypeChecklistはチェックリストの記録を表すレコードの種類です。
+	RecordTypeChecklist = "checklist"
+	// RecordTypeSessionStartはセッションの開始（アプリケーションの起動）を表すレコードの種類です。
+	RecordTypeSessionStart = "session_start"
+	// RecordTypeQueryはリマインダーの表示（セッション終了の問い合わせなど）を表すレコードの種類です。
+	RecordTypeQuery = "query"
+	// RecordTypeAnswerはリマインダーへの応答を表すレコードの種類です。
+	RecordTypeAnswer = "answer"
+)
+
+// Record は履歴の1レコードを表します。
+type Record struct {
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	// EndKind は session.EndKind の名前です。
+	EndKind string `json:"end_kind,omitempty"`
+	// Trigger はリマインダーの表示のきっかけ（config.TriggerSessionEnd など）です（query・answer のレコードのみ）。
+	// 以前のバージョンが記録したレコードにはなく、その場合は session_end です。
+	Trigger string `json:"trigger,omitempty"`
+	// Action は選ばれたボタンの ReminderAction.ID です。
+	Action string `json:"action,omitempty"`
+	// Opened は選ばれたボタンでURLを開けたかどうかです（answer のレコードのみ。起動に失敗した場合は false）。
+	Opened    bool             `json:"opened,omitempty"`
+	Checklist []ChecklistEntry `json:"checklist,omitempty"`
+}
+
+// ChecklistEntry はチェックリストの項目1つの記録です。
+type ChecklistEntry struct {
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	Checked  bool   `json:"checked"`
+}
+
+// NewRecord は指定した時刻・種類のレコードを作成します。
+// この関数は純粋関数です。
+func NewRecord(now time.Time, recordType string) Record {
+	return Record{
+		Timestamp: now.Format(time.RFC3339),
+		Type:      recordType,
+	}
+}
+
+// Time はレコードの時刻を返します。
+// この関数は純粋関数です。
+func (record Record) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339, record.Timestamp)
+}
+
+// filePath は Append と ReadAll が使う履歴ファイルのパスを返します（テストでは一時ファイルに差し替えます）。
+var filePath = FilePath
+
+// Append はレコードを履歴ファイルの末尾に追記します。
+// この関数は副作用（ファイルへの書き込み）を持ちます。
+func Append(record Record) error {
+	path, err := filePath()
+	if err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// ReadAll は履歴ファイルのすべてのレコードを記録順に返します。
+// ファイルがない場合は空のスライスを返します。読み込めない行（書き込み途中の行など）は読み飛ばします。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func ReadAll() ([]Record, error) {
+	path, err := filePath()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if record, ok := parseLine(scanner.Bytes()); ok {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Reader は履歴ファイルを前回読み終えた位置から読み進め、読み込んだレコードを保持します。
+// 履歴は追記のみのため、定期的に読み直す場合（トレイの勤務時間の更新など）も、2回目以降は追記された行だけを読みます。
+// 複数のゴルーチンから使用できます。
+type Reader struct {
+	// path は履歴ファイルのパスを返します（テストでは一時ファイルに差し替えます）。
+	path func() (string, error)
+
+	mu sync.Mutex
+	// offset は読み終えた位置（最後に読んだ完全な行の次）です。読み進めるたびに増えるためミュータブルです。
+	offset int64
+	// records は読み込んだレコードです。読み進めるたびに追加するためミュータブルです。
+	records []Record
+}
+
+// NewReader は実行ファイルと同じディレクトリの履歴ファイルを読む Reader を作成します。
+func NewReader() *Reader {
+	return &Reader{path: FilePath}
+}
+
+// Records は履歴ファイルのすべてのレコードを記録順に返します。
+// 前回の呼び出し以降に追記された完全な行だけを読み込みます（書き込み途中の行は次の呼び出しで読みます）。
+// ファイルが前回より小さくなった場合（削除・置き換え）は、最初から読み直します。
+// 読み込めない場合は、それまでに読み込んだレコードとエラーを返します。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func (reader *Reader) Records() ([]Record, error) {
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+
+	err := reader.readAppended()
+	return slices.Clip(reader.records), err
+}
+
+// readAppended は offset 以降に追記された完全な行を読み込みます。
+// mu を保持して呼び出す必要があります。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func (reader *Reader) readAppended() error {
+	path, err := reader.path()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		reader.offset, reader.records = 0, nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < reader.offset {
+		reader.offset, reader.records = 0, nil
+	}
+	if info.Size() == reader.offset {
+		return nil
+	}
+
+	data := make([]byte, info.Size()-reader.offset)
+	if _, err := file.ReadAt(data, reader.offset); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	for _, line := range bytes.Split(data[:complete], []byte{'\n'}) {
+		if record, ok := parseLine(line); ok {
+			reader.records = append(reader.records, record)
+		}
+	}
+	reader.offset += int64(complete)
+	return nil
+}
+
+// parseLine は履歴ファイルの1行をレコードに変換します。読み込めない行は ok に false を返します。
+// この関数は純粋関数です。
+func parseLine(line []byte) (record Record, ok bool) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return Record{}, false
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return Record{}, false
+	}
+	return record, true
+}
+
+// FilePath は実行ファイルと同じディレクトリの履歴ファイルのパスを返します。
+// この関数は副作用（ファイルシステムへのアクセス）を持ちます。
+func FilePath() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(execPath), config.HistoryFileName), nil
+}
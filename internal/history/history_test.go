@@ -0,0 +1,198 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// testReader は path の履歴ファイルを読む Reader を作成します。
+func testReader(path string) *Reader {
+	return &Reader{path: func() (string, error) { return path, nil }}
+}
+
+// appendText は履歴ファイルに text を追記します。
+func appendText(t *testing.T, path, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordTypes はレコードの種類を並べて返します。
+func recordTypes(records []Record) []string {
+	types := make([]string, 0, len(records))
+	for _, record := range records {
+		types = append(types, record.Type)
+	}
+	return types
+}
+
+const (
+	startLine  = `{"timestamp":"2026-10-16T09:00:00+09:00","type":"session_start"}` + "\n"
+	queryLine  = `{"timestamp":"2026-10-16T18:00:00+09:00","type":"query","end_kind":"shutdown"}` + "\n"
+	answerLine = `{"timestamp":"2026-10-16T18:01:00+09:00","type":"answer","end_kind":"shutdown","action":"open","opened":true}` + "\n"
+)
+
+// readerStep はファイルの操作と、その後に Records が返すレコードの種類です。
+type readerStep struct {
+	change func(t *testing.T, path string)
+	want   []string
+}
+
+func TestReaderRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []readerStep
+	}{
+		{
+			name: "追記された行だけを読み足す",
+			steps: []readerStep{
+				{change: func(t *testing.T, path string) { appendText(t, path, startLine) }, want: []string{RecordTypeSessionStart}},
+				{change: func(t *testing.T, path string) {}, want: []string{RecordTypeSessionStart}},
+				{change: func(t *testing.T, path string) { appendText(t, path, queryLine+answerLine) }, want: []string{RecordTypeSessionStart, RecordTypeQuery, RecordTypeAnswer}},
+			},
+		},
+		{
+			name: "書き込み途中の行は次の呼び出しで読む",
+			steps: []readerStep{
+				{change: func(t *testing.T, path string) { appendText(t, path, startLine+queryLine[:20]) }, want: []string{RecordTypeSessionStart}},
+				{change: func(t *testing.T, path string) { appendText(t, path, queryLine[20:]) }, want: []string{RecordTypeSessionStart, RecordTypeQuery}},
+			},
+		},
+		{
+			name: "読み込めない行は読み飛ばす",
+			steps: []readerStep{
+				{change: func(t *testing.T, path string) { appendText(t, path, startLine+"{壊れた行\n\n"+answerLine) }, want: []string{RecordTypeSessionStart, RecordTypeAnswer}},
+			},
+		},
+		{
+			name: "ファイルがない",
+			steps: []readerStep{
+				{change: func(t *testing.T, path string) {}, want: []string{}},
+				{change: func(t *testing.T, path string) { appendText(t, path, startLine) }, want: []string{RecordTypeSessionStart}},
+			},
+		},
+		{
+			name: "小さくなったファイルは最初から読み直す",
+			steps: []readerStep{
+				{change: func(t *testing.T, path string) { appendText(t, path, startLine+queryLine) }, want: []string{RecordTypeSessionStart, RecordTypeQuery}},
+				{change: func(t *testing.T, path string) {
+					if err := os.WriteFile(path, []byte(answerLine), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}, want: []string{RecordTypeAnswer}},
+				{change: func(t *testing.T, path string) {
+					if err := os.Remove(path); err != nil {
+						t.Fatal(err)
+					}
+				}, want: []string{}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "history.jsonl")
+			reader := testReader(path)
+			for i, step := range tt.steps {
+				step.change(t, path)
+				records, err := reader.Records()
+				if err != nil {
+					t.Fatalf("step %d: Records() error = %v", i, err)
+				}
+				if got := recordTypes(records); !slices.Equal(got, step.want) {
+					t.Errorf("step %d: Records() = %v, want %v", i, got, step.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReaderKeepsOpened(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	appendText(t, path, answerLine)
+
+	records, err := testReader(path).Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !records[0].Opened || records[0].Action != "open" {
+		t.Errorf("Records() = %+v", records)
+	}
+}
+
+// useTempFile は Append と ReadAll が一時ファイルを使うよう設定し、そのパスを返します。
+func useTempFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	previous := filePath
+	filePath = func() (string, error) { return path, nil }
+	t.Cleanup(func() { filePath = previous })
+	return path
+}
+
+func TestAppendAndReadAll(t *testing.T) {
+	checklist := Record{
+		Timestamp: "2026-10-16T18:00:00+09:00",
+		Type:      RecordTypeChecklist,
+		EndKind:   "shutdown",
+		Action:    "open",
+		Checklist: []ChecklistEntry{
+			{Label: "勤怠を登録", Required: true, Checked: true},
+			{Label: "コードをpush"},
+		},
+	}
+	answer := Record{Timestamp: "2026-10-16T18:01:00+09:00", Type: RecordTypeAnswer, EndKind: "shutdown", Action: "open", Opened: true, Trigger: "session_end"}
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, path string)
+		appends []Record
+		want    []Record
+	}{
+		{
+			name: "ファイルなし",
+		},
+		{
+			name:    "チェックリストと応答を追記して読み直す",
+			appends: []Record{checklist, answer},
+			want:    []Record{checklist, answer},
+		},
+		{
+			name:    "読み込めない行を読み飛ばす",
+			prepare: func(t *testing.T, path string) { appendText(t, path, "{\n"+startLine) },
+			appends: []Record{checklist},
+			want:    []Record{{Timestamp: "2026-10-16T09:00:00+09:00", Type: RecordTypeSessionStart}, checklist},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := useTempFile(t)
+			if tt.prepare != nil {
+				tt.prepare(t, path)
+			}
+			for _, record := range tt.appends {
+				if err := Append(record); err != nil {
+					t.Fatalf("Append() error = %v", err)
+				}
+			}
+
+			got, err := ReadAll()
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadAll() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
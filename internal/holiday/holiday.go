@@ -0,0 +1,80 @@
+// このパッケージは休日のカレンダーを扱う。
+//
+// カレンダーは日付から休日の名前を引くだけのインターフェースで、
+// 組み込みの日本の祝日（Japan）のほか、会社の休日などを列挙したファイル（CSV・iCalendar）から読み込む。
+// ファイルの読み込み以外は純粋関数で、日付は呼び出し元のタイムゾーンの年月日として扱う。
+package holiday
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DateFormat はカレンダーファイルの日付の書式です。
+const DateFormat = "2006-01-02"
+
+// Calendar は休日のカレンダーを表します。
+type Calendar interface {
+	// Holiday は date の年月日が休日であれば、その名前と true を返します。
+	Holiday(date time.Time) (name string, ok bool)
+}
+
+// DateList は日付（DateFormat の文字列）ごとの休日の名前を保持するカレンダーです。
+type DateList map[string]string
+
+// Holiday は Calendar の実装です。
+// この関数は純粋関数です。
+func (list DateList) Holiday(date time.Time) (string, bool) {
+	name, ok := list[date.Format(DateFormat)]
+	return name, ok
+}
+
+// ParseCSV は「日付,名前」の形式のCSVから休日を読み込みます（名前は省略できます）。
+// 空行と # で始まる行は無視し、1行目の日付を読み取れない場合は見出しとみなして読み飛ばします。
+// この関数は純粋関数です。
+func ParseCSV(data []byte) (DateList, error) {
+	// Excelで保存したCSVの先頭に付くBOMは除きます。
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	list := DateList{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		field, name, _ := strings.Cut(line, ",")
+		date, err := time.Parse(DateFormat, strings.TrimSpace(field))
+		if err != nil {
+			if lineNumber == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("%d行目: 日付は %s の形式で指定してください: %s", lineNumber, DateFormat, field)
+		}
+		list[date.Format(DateFormat)] = strings.Trim(strings.TrimSpace(name), `"`)
+	}
+	return list, scanner.Err()
+}
+
+// LoadFile はカレンダーファイルを読み込みます（拡張子 .csv または .ics）。
+// この関数は副作用（ファイルの読み取り）を持ちます。
+func LoadFile(path string) (Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(data)
+	case ".ics":
+		return ParseICS(data)
+	}
+	return nil, fmt.Errorf("未対応のカレンダーファイルです（.csv または .ics を指定してください）: %s", path)
+}
@@ -0,0 +1,154 @@
+package holiday
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    DateList
+		wantErr bool
+	}{
+		{
+			name: "見出しとコメント",
+			data: "date,name\n# 会社の休日\n2026-12-29,年末休暇\n\n2026-12-30, \"年末休暇\"\n",
+			want: DateList{"2026-12-29": "年末休暇", "2026-12-30": "年末休暇"},
+		},
+		{
+			name: "名前なし",
+			data: "2026-08-13\n2026-08-14\n",
+			want: DateList{"2026-08-13": "", "2026-08-14": ""},
+		},
+		{
+			name: "BOM付き（Excel）",
+			data: "\ufeff2026-10-16,創立記念日\r\n",
+			want: DateList{"2026-10-16": "創立記念日"},
+		},
+		{
+			name:    "2行目以降の日付の誤り",
+			data:    "2026-12-29,年末休暇\n2026/12/30,年末休暇\n",
+			wantErr: true,
+		},
+		{
+			name:    "存在しない日付",
+			data:    "date,name\n2026-02-30,休日\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !maps.Equal(got, tt.want) {
+				t.Errorf("ParseCSV() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    DateList
+		wantErr bool
+	}{
+		{
+			name: "終日の予定",
+			data: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20261016\r\nDTEND;VALUE=DATE:20261017\r\nSUMMARY:創立記念日\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: DateList{"2026-10-16": "創立記念日"},
+		},
+		{
+			name: "複数日の予定（DTEND を含まない）",
+			data: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261229\nDTEND;VALUE=DATE:20270104\nSUMMARY:年末年始休暇\nEND:VEVENT\n",
+			want: DateList{
+				"2026-12-29": "年末年始休暇", "2026-12-30": "年末年始休暇", "2026-12-31": "年末年始休暇",
+				"2027-01-01": "年末年始休暇", "2027-01-02": "年末年始休暇", "2027-01-03": "年末年始休暇",
+			},
+		},
+		{
+			name: "DTEND なしと日時の DTSTART",
+			data: "BEGIN:VEVENT\nDTSTART:20260813T000000Z\nSUMMARY:夏季休暇\nEND:VEVENT\n",
+			want: DateList{"2026-08-13": "夏季休暇"},
+		},
+		{
+			name: "折り返しとエスケープ",
+			data: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261016\nSUMMARY:創立記念日\\, 全社\n  休業\nEND:VEVENT\n",
+			want: DateList{"2026-10-16": "創立記念日, 全社 休業"},
+		},
+		{
+			name: "予定の外の DTSTART は無視",
+			data: "BEGIN:VCALENDAR\nDTSTART;VALUE=DATE:20261016\nEND:VCALENDAR\n",
+			want: DateList{},
+		},
+		{
+			name:    "DTSTART がない予定",
+			data:    "BEGIN:VEVENT\nSUMMARY:休日\nEND:VEVENT\n",
+			wantErr: true,
+		},
+		{
+			name:    "DTEND の誤り",
+			data:    "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261016\nDTEND;VALUE=DATE:2026\nEND:VEVENT\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseICS([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseICS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !maps.Equal(got, tt.want) {
+				t.Errorf("ParseICS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		wantErr  bool
+	}{
+		{name: "CSV", fileName: "holidays.csv", data: "2026-10-16,創立記念日\n"},
+		{name: "大文字の拡張子", fileName: "HOLIDAYS.ICS", data: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20261016\nSUMMARY:創立記念日\nEND:VEVENT\n"},
+		{name: "未対応の拡張子", fileName: "holidays.txt", data: "2026-10-16\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			calendar, err := LoadFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if name, ok := calendar.Holiday(date(2026, 10, 16)); !ok || name != "創立記念日" {
+				t.Errorf("Holiday() = %q, %v, want 創立記念日", name, ok)
+			}
+		})
+	}
+
+	t.Run("ファイルなし", func(t *testing.T) {
+		if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.csv")); !os.IsNotExist(err) {
+			t.Errorf("LoadFile() error = %v, want not exist", err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package holiday
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// icsDateFormat は iCalendar の日付（DTSTART;VALUE=DATE:20260101）の書式です。
+const icsDateFormat = "20060102"
+
+// ParseICS は iCalendar（.ics）の終日の予定を休日として読み込みます。
+// 休日の名前は SUMMARY で、DTEND（含まない）までの複数日の予定はすべての日を休日にします。
+// 繰り返し（RRULE）は展開せず、最初の日だけを休日にします。
+// この関数は純粋関数です。
+func ParseICS(data []byte) (DateList, error) {
+	list := DateList{}
+	var inEvent bool
+	var events int
+	var start, end, summary string
+	for _, line := range unfoldICS(data) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		// DTSTART;VALUE=DATE のようなパラメータは使用しません。
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent, start, end, summary = true, "", "", ""
+			events++
+		case name == "END" && strings.EqualFold(value, "VEVENT") && inEvent:
+			inEvent = false
+			if err := addICSEvent(list, start, end, summary); err != nil {
+				return nil, fmt.Errorf("%d件目の予定: %w", events, err)
+			}
+		case !inEvent:
+		case name == "DTSTART":
+			start = value
+		case name == "DTEND":
+			end = value
+		case name == "SUMMARY":
+			summary = unescapeICS(value)
+		}
+	}
+	return list, nil
+}
+
+// addICSEvent は予定1つの日付を list に追加します。
+// この関数は副作用（list の変更）を持ちます。
+func addICSEvent(list DateList, start, end, summary string) error {
+	first, err := parseICSDate(start)
+	if err != nil {
+		return fmt.Errorf("DTSTART を読み取れません: %s", start)
+	}
+	last := first
+	if end != "" {
+		// DTEND はその日を含みません。
+		exclusive, err := parseICSDate(end)
+		if err != nil {
+			return fmt.Errorf("DTEND を読み取れません: %s", end)
+		}
+		if exclusive.After(first) {
+			last = exclusive.AddDate(0, 0, -1)
+		}
+	}
+
+	for date := first; !date.After(last); date = date.AddDate(0, 0, 1) {
+		list[date.Format(DateFormat)] = summary
+	}
+	return nil
+}
+
+// parseICSDate は DTSTART・DTEND の値（20260101 または 20260101T090000Z）の日付部分を読み取ります。
+// この関数は純粋関数です。
+func parseICSDate(value string) (time.Time, error) {
+	if len(value) < len(icsDateFormat) {
+		return time.Time{}, fmt.Errorf("日付が短すぎます: %s", value)
+	}
+	return time.Parse(icsDateFormat, value[:len(icsDateFormat)])
+}
+
+// unfoldICS は iCalendar の行を、折り返し（空白またはタブで始まる行）をつないで返します。
+// この関数は純粋関数です。
+func unfoldICS(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// unescapeICS は iCalendar のテキストのエスケープ（\, \; \n \\）を元に戻します。
+// この関数は純粋関数です。
+func unescapeICS(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ").Replace(text)
+}
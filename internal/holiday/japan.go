@@ -0,0 +1,221 @@
+package holiday
+
+import (
+	"math"
+	"time"
+)
+
+// JapanCalendarName は when.holidays で組み込みの日本の祝日カレンダーを指定する名前です。
+const JapanCalendarName = "japan"
+
+// 日本の祝日を計算できる年の範囲です（春分・秋分の日の近似式が使える範囲）。
+const (
+	JapanFirstYear = 1980
+	JapanLastYear  = 2099
+)
+
+// Japan は「国民の祝日に関する法律」に基づく日本の祝日のカレンダーです。
+// 固定日の祝日、ハッピーマンデー、春分・秋分の日、振替休日、国民の休日を計算し、日付を列挙したファイルは不要です。
+// JapanFirstYear から JapanLastYear までの年に対応し、範囲外の年には祝日がないものとして扱います。
+type Japan struct{}
+
+// Holiday は Calendar の実装です。
+// この関数は純粋関数です。
+func (Japan) Holiday(date time.Time) (string, bool) {
+	name, ok := JapaneseHolidays(date.Year())[date.Format(DateFormat)]
+	return name, ok
+}
+
+// BuiltIn は組み込みのカレンダーの名前（JapanCalendarName）に対応するカレンダーを返します。
+// 組み込みのカレンダーでない場合は ok に false を返します。
+// この関数は純粋関数です。
+func BuiltIn(name string) (calendar Calendar, ok bool) {
+	if name == JapanCalendarName {
+		return Japan{}, true
+	}
+	return nil, false
+}
+
+// JapaneseHolidays は year の日本の祝日（振替休日・国民の休日を含む）を返します。
+// 対応する範囲外の年は空のリストを返します。
+// この関数は純粋関数です。
+func JapaneseHolidays(year int) DateList {
+	list := DateList{}
+	if year < JapanFirstYear || year > JapanLastYear {
+		return list
+	}
+
+	add := func(month time.Month, day int, name string) {
+		list[time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format(DateFormat)] = name
+	}
+	addMonday := func(month time.Month, week int, name string) {
+		add(month, nthMonday(year, month, week), name)
+	}
+
+	add(time.January, 1, "元日")
+	if year >= 2000 {
+		addMonday(time.January, 2, "成人の日")
+	} else {
+		add(time.January, 15, "成人の日")
+	}
+	add(time.February, 11, "建国記念の日")
+	if year >= 2020 {
+		add(time.February, 23, "天皇誕生日")
+	}
+	add(time.March, springEquinoxDay(year), "春分の日")
+	switch {
+	case year >= 2007:
+		add(time.April, 29, "昭和の日")
+	case year >= 1989:
+		add(time.April, 29, "みどりの日")
+	default:
+		add(time.April, 29, "天皇誕生日")
+	}
+	add(time.May, 3, "憲法記念日")
+	if year >= 2007 {
+		add(time.May, 4, "みどりの日")
+	}
+	add(time.May, 5, "こどもの日")
+
+	// 東京オリンピック・パラリンピックの年は、海の日・山の日・スポーツの日が特例で移動しました。
+	switch {
+	case year == 2020:
+		add(time.July, 23, "海の日")
+		add(time.July, 24, "スポーツの日")
+		add(time.August, 10, "山の日")
+	case year == 2021:
+		add(time.July, 22, "海の日")
+		add(time.July, 23, "スポーツの日")
+		add(time.August, 8, "山の日")
+	default:
+		if year >= 2003 {
+			addMonday(time.July, 3, "海の日")
+		} else if year >= 1996 {
+			add(time.July, 20, "海の日")
+		}
+		if year >= 2016 {
+			add(time.August, 11, "山の日")
+		}
+		switch {
+		case year >= 2020:
+			addMonday(time.October, 2, "スポーツの日")
+		case year >= 2000:
+			addMonday(time.October, 2, "体育の日")
+		default:
+			add(time.October, 10, "体育の日")
+		}
+	}
+
+	if year >= 2003 {
+		addMonday(time.September, 3, "敬老の日")
+	} else {
+		add(time.September, 15, "敬老の日")
+	}
+	add(time.September, autumnEquinoxDay(year), "秋分の日")
+	add(time.November, 3, "文化の日")
+	add(time.November, 23, "勤労感謝の日")
+	if year >= 1989 && year <= 2018 {
+		add(time.December, 23, "天皇誕生日")
+	}
+
+	// 法律で定められた1回限りの休日です。
+	for _, special := range japaneseSpecialHolidays[year] {
+		add(special.month, special.day, special.name)
+	}
+
+	// どちらも「国民の祝日」だけから求めます。同じ日になる場合は振替休日とします。
+	national, substitutes := nationalHolidays(list, year), substituteHolidays(list, year)
+	for _, key := range national {
+		list[key] = "国民の休日"
+	}
+	for _, key := range substitutes {
+		list[key] = "振替休日"
+	}
+	return list
+}
+
+// specialHoliday は1回限りの休日です。
+type specialHoliday struct {
+	month time.Month
+	day   int
+	name  string
+}
+
+// japaneseSpecialHolidays は年ごとの1回限りの休日です。
+var japaneseSpecialHolidays = map[int][]specialHoliday{
+	1989: {{time.February, 24, "昭和天皇の大喪の礼"}},
+	1990: {{time.November, 12, "即位礼正殿の儀"}},
+	1993: {{time.June, 9, "皇太子徳仁親王の結婚の儀"}},
+	2019: {{time.May, 1, "天皇の即位の日"}, {time.October, 22, "即位礼正殿の儀"}},
+}
+
+// nationalHolidays は前日と翌日が祝日である平日（「国民の休日」、1986年以降）を返します。
+// 日曜日は振替休日の規定が優先するため含めません。
+// この関数は純粋関数です。
+func nationalHolidays(list DateList, year int) []string {
+	if year < 1986 {
+		return nil
+	}
+	var sandwiched []string
+	for date := time.Date(year, time.January, 2, 0, 0, 0, 0, time.UTC); date.Year() == year; date = date.AddDate(0, 0, 1) {
+		if !isListed(list, date) && isListed(list, date.AddDate(0, 0, -1)) && isListed(list, date.AddDate(0, 0, 1)) && date.Weekday() != time.Sunday {
+			sandwiched = append(sandwiched, date.Format(DateFormat))
+		}
+	}
+	return sandwiched
+}
+
+// substituteHolidays は日曜日の祝日の振替休日を返します。
+// 2007年以降は翌日以降の最初の祝日でない日、それより前は翌日の月曜日（祝日でない場合）です。
+// この関数は純粋関数です。
+func substituteHolidays(list DateList, year int) []string {
+	var substitutes []string
+	for key := range list {
+		date, _ := time.Parse(DateFormat, key)
+		if date.Weekday() != time.Sunday {
+			continue
+		}
+		next := date.AddDate(0, 0, 1)
+		for year >= 2007 && isListed(list, next) {
+			next = next.AddDate(0, 0, 1)
+		}
+		if !isListed(list, next) && next.Year() == year {
+			substitutes = append(substitutes, next.Format(DateFormat))
+		}
+	}
+	return substitutes
+}
+
+// isListed は date が list に含まれるかを返します。
+// この関数は純粋関数です。
+func isListed(list DateList, date time.Time) bool {
+	_, ok := list[date.Format(DateFormat)]
+	return ok
+}
+
+// nthMonday は year 年 month 月の第 week 月曜日の日を返します。
+// この関数は純粋関数です。
+func nthMonday(year int, month time.Month, week int) int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC).Weekday()
+	offset := (int(time.Monday) - int(first) + 7) % 7
+	return 1 + offset + (week-1)*7
+}
+
+// springEquinoxDay は year 年の春分の日（3月の日）を近似式で返します（1980年～2099年）。
+// この関数は純粋関数です。
+func springEquinoxDay(year int) int {
+	return equinoxDay(year, 20.8431)
+}
+
+// autumnEquinoxDay は year 年の秋分の日（9月の日）を近似式で返します（1980年～2099年）。
+// この関数は純粋関数です。
+func autumnEquinoxDay(year int) int {
+	return equinoxDay(year, 23.2488)
+}
+
+// equinoxDay は1980年を基準にした春分・秋分の日の近似式です。
+// この関数は純粋関数です。
+func equinoxDay(year int, base float64) int {
+	elapsed := year - 1980
+	return int(math.Floor(base + 0.242194*float64(elapsed) - math.Floor(float64(elapsed)/4)))
+}
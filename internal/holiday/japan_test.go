@@ -0,0 +1,260 @@
+package holiday
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+// date は year 年 month 月 day 日の0時（UTC）を返します。
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestJapaneseHolidaysFullYears(t *testing.T) {
+	// 内閣府が公表している「国民の祝日」の一覧（振替休日・国民の休日を含む）です。
+	tests := map[int]DateList{
+		2019: {
+			"2019-01-01": "元日",
+			"2019-01-14": "成人の日",
+			"2019-02-11": "建国記念の日",
+			"2019-03-21": "春分の日",
+			"2019-04-29": "昭和の日",
+			"2019-04-30": "国民の休日",
+			"2019-05-01": "天皇の即位の日",
+			"2019-05-02": "国民の休日",
+			"2019-05-03": "憲法記念日",
+			"2019-05-04": "みどりの日",
+			"2019-05-05": "こどもの日",
+			"2019-05-06": "振替休日",
+			"2019-07-15": "海の日",
+			"2019-08-11": "山の日",
+			"2019-08-12": "振替休日",
+			"2019-09-16": "敬老の日",
+			"2019-09-23": "秋分の日",
+			"2019-10-14": "体育の日",
+			"2019-10-22": "即位礼正殿の儀",
+			"2019-11-03": "文化の日",
+			"2019-11-04": "振替休日",
+			"2019-11-23": "勤労感謝の日",
+		},
+		2020: {
+			"2020-01-01": "元日",
+			"2020-01-13": "成人の日",
+			"2020-02-11": "建国記念の日",
+			"2020-02-23": "天皇誕生日",
+			"2020-02-24": "振替休日",
+			"2020-03-20": "春分の日",
+			"2020-04-29": "昭和の日",
+			"2020-05-03": "憲法記念日",
+			"2020-05-04": "みどりの日",
+			"2020-05-05": "こどもの日",
+			"2020-05-06": "振替休日",
+			"2020-07-23": "海の日",
+			"2020-07-24": "スポーツの日",
+			"2020-08-10": "山の日",
+			"2020-09-21": "敬老の日",
+			"2020-09-22": "秋分の日",
+			"2020-11-03": "文化の日",
+			"2020-11-23": "勤労感謝の日",
+		},
+		2021: {
+			"2021-01-01": "元日",
+			"2021-01-11": "成人の日",
+			"2021-02-11": "建国記念の日",
+			"2021-02-23": "天皇誕生日",
+			"2021-03-20": "春分の日",
+			"2021-04-29": "昭和の日",
+			"2021-05-03": "憲法記念日",
+			"2021-05-04": "みどりの日",
+			"2021-05-05": "こどもの日",
+			"2021-07-22": "海の日",
+			"2021-07-23": "スポーツの日",
+			"2021-08-08": "山の日",
+			"2021-08-09": "振替休日",
+			"2021-09-20": "敬老の日",
+			"2021-09-23": "秋分の日",
+			"2021-11-03": "文化の日",
+			"2021-11-23": "勤労感謝の日",
+		},
+		2025: {
+			"2025-01-01": "元日",
+			"2025-01-13": "成人の日",
+			"2025-02-11": "建国記念の日",
+			"2025-02-23": "天皇誕生日",
+			"2025-02-24": "振替休日",
+			"2025-03-20": "春分の日",
+			"2025-04-29": "昭和の日",
+			"2025-05-03": "憲法記念日",
+			"2025-05-04": "みどりの日",
+			"2025-05-05": "こどもの日",
+			"2025-05-06": "振替休日",
+			"2025-07-21": "海の日",
+			"2025-08-11": "山の日",
+			"2025-09-15": "敬老の日",
+			"2025-09-23": "秋分の日",
+			"2025-10-13": "スポーツの日",
+			"2025-11-03": "文化の日",
+			"2025-11-23": "勤労感謝の日",
+			"2025-11-24": "振替休日",
+		},
+		2026: {
+			"2026-01-01": "元日",
+			"2026-01-12": "成人の日",
+			"2026-02-11": "建国記念の日",
+			"2026-02-23": "天皇誕生日",
+			"2026-03-20": "春分の日",
+			"2026-04-29": "昭和の日",
+			"2026-05-03": "憲法記念日",
+			"2026-05-04": "みどりの日",
+			"2026-05-05": "こどもの日",
+			"2026-05-06": "振替休日",
+			"2026-07-20": "海の日",
+			"2026-08-11": "山の日",
+			"2026-09-21": "敬老の日",
+			"2026-09-22": "国民の休日",
+			"2026-09-23": "秋分の日",
+			"2026-10-12": "スポーツの日",
+			"2026-11-03": "文化の日",
+			"2026-11-23": "勤労感謝の日",
+		},
+	}
+
+	for year, want := range tests {
+		got := JapaneseHolidays(year)
+		if !maps.Equal(got, want) {
+			for key, name := range want {
+				if got[key] != name {
+					t.Errorf("%d: %s = %q, want %q", year, key, got[key], name)
+				}
+			}
+			for key, name := range got {
+				if _, ok := want[key]; !ok {
+					t.Errorf("%d: 余分な休日 %s %s", year, key, name)
+				}
+			}
+		}
+	}
+}
+
+func TestJapanHoliday(t *testing.T) {
+	tests := []struct {
+		name     string
+		date     time.Time
+		wantName string
+	}{
+		// ハッピーマンデー制度の前後
+		{name: "1998年の成人の日（固定日）", date: date(1998, time.January, 15), wantName: "成人の日"},
+		{name: "1998年の体育の日（固定日）", date: date(1998, time.October, 10), wantName: "体育の日"},
+		{name: "1998年の海の日（固定日）", date: date(1998, time.July, 20), wantName: "海の日"},
+		{name: "1998年の敬老の日（固定日）", date: date(1998, time.September, 15), wantName: "敬老の日"},
+		{name: "2000年の成人の日（第2月曜日）", date: date(2000, time.January, 10), wantName: "成人の日"},
+		{name: "2000年の1月15日は平日", date: date(2000, time.January, 15)},
+		{name: "2003年の海の日（第3月曜日）", date: date(2003, time.July, 21), wantName: "海の日"},
+		{name: "2003年の敬老の日（第3月曜日）", date: date(2003, time.September, 15), wantName: "敬老の日"},
+
+		// 4月29日・5月4日の名前の変遷
+		{name: "1988年の4月29日", date: date(1988, time.April, 29), wantName: "天皇誕生日"},
+		{name: "1989年の4月29日", date: date(1989, time.April, 29), wantName: "みどりの日"},
+		{name: "2007年の4月29日", date: date(2007, time.April, 29), wantName: "昭和の日"},
+		{name: "2006年の5月4日（国民の休日）", date: date(2006, time.May, 4), wantName: "国民の休日"},
+		{name: "2007年の5月4日", date: date(2007, time.May, 4), wantName: "みどりの日"},
+
+		// 振替休日の規定の変更（2007年）
+		{name: "1998年は5月3日の翌日が振替休日", date: date(1998, time.May, 4), wantName: "振替休日"},
+		{name: "2001年の秋分の日の振替休日", date: date(2001, time.September, 24), wantName: "振替休日"},
+		{name: "2008年は祝日の後の5月6日が振替休日", date: date(2008, time.May, 6), wantName: "振替休日"},
+
+		// 国民の休日（シルバーウィーク）
+		{name: "2009年9月22日", date: date(2009, time.September, 22), wantName: "国民の休日"},
+		{name: "2015年9月22日", date: date(2015, time.September, 22), wantName: "国民の休日"},
+		{name: "2032年9月21日", date: date(2032, time.September, 21), wantName: "国民の休日"},
+
+		// 天皇誕生日の変更と1回限りの休日
+		{name: "2018年12月23日", date: date(2018, time.December, 23), wantName: "天皇誕生日"},
+		{name: "2019年は天皇誕生日がない", date: date(2019, time.December, 23)},
+		{name: "2020年2月23日", date: date(2020, time.February, 23), wantName: "天皇誕生日"},
+		{name: "昭和天皇の大喪の礼", date: date(1989, time.February, 24), wantName: "昭和天皇の大喪の礼"},
+		{name: "即位礼正殿の儀（1990年）", date: date(1990, time.November, 12), wantName: "即位礼正殿の儀"},
+		{name: "皇太子徳仁親王の結婚の儀", date: date(1993, time.June, 9), wantName: "皇太子徳仁親王の結婚の儀"},
+
+		// 山の日
+		{name: "2015年8月11日は平日", date: date(2015, time.August, 11)},
+		{name: "2016年8月11日", date: date(2016, time.August, 11), wantName: "山の日"},
+
+		// 対応する範囲外
+		{name: "1979年の元日", date: date(1979, time.January, 1)},
+		{name: "2100年の元日", date: date(2100, time.January, 1)},
+		{name: "2099年の元日", date: date(2099, time.January, 1), wantName: "元日"},
+
+		// 時刻とタイムゾーンによらず年月日で判定
+		{name: "日本時間の元日の23時", date: time.Date(2026, time.January, 1, 23, 59, 0, 0, time.FixedZone("JST", 9*60*60)), wantName: "元日"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := Japan{}.Holiday(tt.date)
+			if name != tt.wantName || ok != (tt.wantName != "") {
+				t.Errorf("Holiday(%s) = %q, %v, want %q", tt.date.Format(DateFormat), name, ok, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestEquinoxDays(t *testing.T) {
+	tests := []struct {
+		year       int
+		wantSpring int
+		wantAutumn int
+	}{
+		{year: 1980, wantSpring: 20, wantAutumn: 23},
+		{year: 2012, wantSpring: 20, wantAutumn: 22},
+		{year: 2019, wantSpring: 21, wantAutumn: 23},
+		{year: 2020, wantSpring: 20, wantAutumn: 22},
+		{year: 2023, wantSpring: 21, wantAutumn: 23},
+		{year: 2024, wantSpring: 20, wantAutumn: 22},
+		{year: 2025, wantSpring: 20, wantAutumn: 23},
+		{year: 2026, wantSpring: 20, wantAutumn: 23},
+		{year: 2027, wantSpring: 21, wantAutumn: 23},
+		{year: 2028, wantSpring: 20, wantAutumn: 22},
+	}
+
+	for _, tt := range tests {
+		if got := springEquinoxDay(tt.year); got != tt.wantSpring {
+			t.Errorf("springEquinoxDay(%d) = %d, want %d", tt.year, got, tt.wantSpring)
+		}
+		if got := autumnEquinoxDay(tt.year); got != tt.wantAutumn {
+			t.Errorf("autumnEquinoxDay(%d) = %d, want %d", tt.year, got, tt.wantAutumn)
+		}
+	}
+}
+
+func TestNthMonday(t *testing.T) {
+	tests := []struct {
+		year  int
+		month time.Month
+		week  int
+		want  int
+	}{
+		{year: 2026, month: time.January, week: 2, want: 12},
+		{year: 2024, month: time.January, week: 2, want: 8},
+		{year: 2029, month: time.January, week: 2, want: 8},
+		{year: 2026, month: time.June, week: 1, want: 1},
+		{year: 2026, month: time.September, week: 3, want: 21},
+	}
+
+	for _, tt := range tests {
+		if got := nthMonday(tt.year, tt.month, tt.week); got != tt.want {
+			t.Errorf("nthMonday(%d, %s, %d) = %d, want %d", tt.year, tt.month, tt.week, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltIn(t *testing.T) {
+	if _, ok := BuiltIn(JapanCalendarName); !ok {
+		t.Errorf("BuiltIn(%q) = false, want true", JapanCalendarName)
+	}
+	if _, ok := BuiltIn("holidays.csv"); ok {
+		t.Error("BuiltIn(\"holidays.csv\") = true, want false")
+	}
+}
@@ -0,0 +1,192 @@
+// このパッケージは起動中のインスタンスと、後から起動したインスタンス（コマンドライン）の間の通信を提供する。
+//
+// 通信路はWindowsでは名前付きパイプ、それ以外ではUnixドメインソケットを使用する。
+// 1つの接続で、クライアントがJSONのRequestを1行送り、サーバーがJSONのResponseを1行返して閉じる。
+// 接続・やり取りには期限を設け、応答しない相手を待ち続けないようにする。
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// 起動中のインスタンスが受け付けるコマンド
+const (
+	// CommandShowは確認ダイアログを表示させます。
+	CommandShow = "show"
+	// CommandReloadは設定ファイルを再読み込みさせます。
+	CommandReload = "reload"
+	// CommandSnoozeは Args[0] の間隔（例: 30m）をおいて確認ダイアログを表示させます。
+	CommandSnooze = "snooze"
+	// CommandStatusは起動中のインスタンスの状態を返させます。
+	CommandStatus = "status"
+)
+
+// maxMessageBytesは1行のメッセージの最大サイズです。
+const maxMessageBytes = 64 * 1024
+
+var (
+	// dialTimeoutは接続を待つ時間の上限です。
+	dialTimeout = 2 * time.Second
+	// exchangeTimeoutは1つの接続でコマンドを送ってから応答を受け取るまでの時間の上限です。
+	// 応答しない相手（ダイアログの表示中に止まったインスタンスなど）を待ち続けないために使います。
+	// テストでは短くします。
+	exchangeTimeout = 5 * time.Second
+)
+
+var (
+	// ErrNotRunningは接続先のインスタンスが起動していないことを表します。
+	ErrNotRunning = errors.New("起動中のアプリケーションがありません")
+	// ErrAlreadyRunningは別のインスタンスが既に待ち受けていることを表します。
+	ErrAlreadyRunning = errors.New("アプリケーションは既に起動しています")
+	// ErrListenerClosedは待ち受けを終了したことを表します。
+	ErrListenerClosed = errors.New("待ち受けは終了しました")
+)
+
+// Request はクライアントからのコマンドです。
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response はコマンドの実行結果です。
+type Response struct {
+	OK bool `json:"ok"`
+	// Message は成功時に表示するメッセージです。
+	Message string `json:"message,omitempty"`
+	// Error は失敗時のエラーメッセージです。
+	Error string `json:"error,omitempty"`
+}
+
+// Success は成功を表す Response を返します。
+// この関数は純粋関数です。
+func Success(message string) Response {
+	return Response{OK: true, Message: message}
+}
+
+// Failure は失敗を表す Response を返します。
+// この関数は純粋関数です。
+func Failure(err error) Response {
+	return Response{OK: false, Error: err.Error()}
+}
+
+// Handler は受け取ったコマンドを実行します。
+// HandleRequest は接続ごとのゴルーチンから呼び出されます。
+type Handler interface {
+	HandleRequest(request Request) Response
+}
+
+// Listener は接続を待ち受けます（OSごとの実装）。
+type Listener interface {
+	// Accept は次の接続を待ちます。Close の後は ErrListenerClosed を返します。
+	Accept() (io.ReadWriteCloser, error)
+	// Close は待ち受けを終了します。
+	Close() error
+}
+
+// Serve は listener が閉じられるまで接続を受け付け、handler でコマンドを実行します。
+// Close によって終了した場合は nil を返します。
+// この関数は副作用（接続の受け付け、handler の呼び出し）を持ちます。
+func Serve(listener Listener, handler Handler) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, ErrListenerClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go serveConn(conn, handler)
+	}
+}
+
+// serveConn は1つの接続からコマンドを読み取り、結果を返して閉じます。
+// この関数は副作用（通信、handler の呼び出し）を持ちます。
+func serveConn(conn io.ReadWriteCloser, handler Handler) {
+	defer conn.Close()
+	if err := setDeadline(conn, exchangeTimeout); err != nil {
+		return
+	}
+
+	var response Response
+	var request Request
+	if err := readMessage(conn, &request); err != nil {
+		response = Failure(fmt.Errorf("コマンドを読み取れませんでした: %w", err))
+	} else {
+		response = handler.HandleRequest(request)
+	}
+	_ = writeMessage(conn, response)
+}
+
+// Send は起動中のインスタンスにコマンドを送り、結果を返します。
+// 起動中のインスタンスがない場合は ErrNotRunning を返します。
+// この関数は副作用（通信）を持ちます。
+func Send(request Request) (Response, error) {
+	conn, err := Dial()
+	if err != nil {
+		return Response{}, err
+	}
+	defer conn.Close()
+
+	if err := setDeadline(conn, exchangeTimeout); err != nil {
+		return Response{}, fmt.Errorf("通信の期限を設定できませんでした: %w", err)
+	}
+	return exchange(conn, request)
+}
+
+// setDeadline は conn の読み書きの期限を timeout 後に設定します。
+// 期限を設定できない種類の接続の場合は何もしません。
+// この関数は副作用（conn の期限の変更）を持ちます。
+func setDeadline(conn io.ReadWriteCloser, timeout time.Duration) error {
+	deadliner, ok := conn.(interface{ SetDeadline(time.Time) error })
+	if !ok {
+		return nil
+	}
+	err := deadliner.SetDeadline(time.Now().Add(timeout))
+	if errors.Is(err, os.ErrNoDeadline) {
+		return nil
+	}
+	return err
+}
+
+// exchange は conn にコマンドを書き込み、結果を読み取ります。
+// この関数は副作用（通信）を持ちます。
+func exchange(conn io.ReadWriter, request Request) (Response, error) {
+	if err := writeMessage(conn, request); err != nil {
+		return Response{}, fmt.Errorf("コマンドを送信できませんでした: %w", err)
+	}
+
+	var response Response
+	if err := readMessage(conn, &response); err != nil {
+		return Response{}, fmt.Errorf("応答を受信できませんでした: %w", err)
+	}
+	return response, nil
+}
+
+// writeMessage は value をJSONの1行として書き込みます。
+// この関数は副作用（w への書き込み）を持ちます。
+func writeMessage(w io.Writer, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// readMessage はJSONの1行を読み取り、value に格納します。
+// この関数は副作用（r からの読み取り）を持ちます。
+func readMessage(r io.Reader, value interface{}) error {
+	// 1つの接続でやり取りするのは1行ずつなので、先読みしても後続のデータを失いません。
+	reader := bufio.NewReader(io.LimitReader(r, maxMessageBytes))
+	line, err := reader.ReadBytes('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && len(line) > 0) {
+		return err
+	}
+	return json.Unmarshal(line, value)
+}
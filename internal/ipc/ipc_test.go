@@ -0,0 +1,244 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordingHandler は受け取ったコマンドを記録し、決まった応答を返す Handler です。
+type recordingHandler struct {
+	requests chan Request
+	response Response
+}
+
+// HandleRequest は request を記録して response を返します。
+func (handler *recordingHandler) HandleRequest(request Request) Response {
+	handler.requests <- request
+	return handler.response
+}
+
+// useTempSocket はソケットファイルをテストごとの一時ディレクトリに作るよう設定します。
+func useTempSocket(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+}
+
+// useShortTimeout は通信の期限をテスト用に短くします。
+func useShortTimeout(t *testing.T) {
+	t.Helper()
+	previous := exchangeTimeout
+	exchangeTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { exchangeTimeout = previous })
+}
+
+// serve は待ち受けを開始し、テストの終了時に閉じます。
+func serve(t *testing.T, handler Handler) {
+	t.Helper()
+	listener, err := Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- Serve(listener, handler) }()
+	t.Cleanup(func() {
+		_ = listener.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() = %v, want nil", err)
+		}
+	})
+}
+
+func TestSendRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  Request
+		response Response
+	}{
+		{
+			name:     "show",
+			request:  Request{Command: CommandShow},
+			response: Success("確認ダイアログを表示します"),
+		},
+		{
+			name:     "snooze（引数あり）",
+			request:  Request{Command: CommandSnooze, Args: []string{"30m"}},
+			response: Success("18:30 に確認ダイアログを表示します"),
+		},
+		{
+			name:     "失敗",
+			request:  Request{Command: "unknown"},
+			response: Failure(errors.New("未知のコマンドです: unknown")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempSocket(t)
+			handler := &recordingHandler{requests: make(chan Request, 1), response: tt.response}
+			serve(t, handler)
+
+			response, err := Send(tt.request)
+			if err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			if !reflect.DeepEqual(response, tt.response) {
+				t.Errorf("Send() = %+v, want %+v", response, tt.response)
+			}
+			if got := <-handler.requests; !reflect.DeepEqual(got, tt.request) {
+				t.Errorf("HandleRequest() got %+v, want %+v", got, tt.request)
+			}
+		})
+	}
+}
+
+func TestSendWithoutRunningInstance(t *testing.T) {
+	useTempSocket(t)
+
+	if _, err := Send(Request{Command: CommandStatus}); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Send() error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T)
+		wantErr error
+	}{
+		{
+			name:    "ソケットファイルなし",
+			prepare: func(t *testing.T) {},
+		},
+		{
+			name: "異常終了で残ったソケットファイル",
+			prepare: func(t *testing.T) {
+				if err := os.WriteFile(socketPath(), nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "別のインスタンスが待ち受け中",
+			prepare: func(t *testing.T) {
+				serve(t, &recordingHandler{requests: make(chan Request, 1)})
+			},
+			wantErr: ErrAlreadyRunning,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempSocket(t)
+			tt.prepare(t)
+
+			listener, err := Listen()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Listen() error = %v, want %v", err, tt.wantErr)
+			}
+			if listener != nil {
+				_ = listener.Close()
+			}
+		})
+	}
+}
+
+func TestServeConnResponses(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantOK    bool
+		wantError bool
+	}{
+		{name: "1行のコマンド", input: `{"command":"status"}` + "\n", wantOK: true},
+		{name: "改行なしで閉じたコマンド", input: `{"command":"status"}`, wantOK: true},
+		{name: "JSONではない", input: "status\n", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempSocket(t)
+			serve(t, &recordingHandler{requests: make(chan Request, 1), response: Success("ok")})
+
+			conn, err := net.Dial("unix", socketPath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte(tt.input)); err != nil {
+				t.Fatal(err)
+			}
+			// 書き込みを閉じ、改行がなくてもメッセージの終わりが分かるようにします。
+			if err := conn.(*net.UnixConn).CloseWrite(); err != nil {
+				t.Fatal(err)
+			}
+
+			var response Response
+			if err := readMessage(conn, &response); err != nil {
+				t.Fatalf("readMessage() error = %v", err)
+			}
+			if response.OK != tt.wantOK || (response.Error != "") != tt.wantError {
+				t.Errorf("response = %+v, want OK=%v, error=%v", response, tt.wantOK, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Run("コマンドを送らない相手を待ち続けない", func(t *testing.T) {
+		useShortTimeout(t)
+		server, client := net.Pipe()
+		defer client.Close()
+
+		done := make(chan struct{})
+		go func() {
+			serveConn(server, &recordingHandler{requests: make(chan Request, 1)})
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("serveConn() が期限を過ぎても戻りません")
+		}
+	})
+
+	t.Run("応答しない相手を待ち続けない", func(t *testing.T) {
+		useTempSocket(t)
+		useShortTimeout(t)
+		// 接続は受け付けるものの、応答を返さないインスタンス
+		listener, err := net.Listen("unix", socketPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				defer conn.Close()
+				time.Sleep(5 * time.Second)
+			}
+		}()
+
+		result := make(chan error, 1)
+		go func() {
+			_, err := Send(Request{Command: CommandShow})
+			result <- err
+		}()
+
+		select {
+		case err := <-result:
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				t.Errorf("Send() error = %v, want timeout", err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Send() が期限を過ぎても戻りません")
+		}
+	})
+}
@@ -0,0 +1,83 @@
+//go:build !windows
+
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// socketFileNameFormatはソケットファイル名の書式文字列です（ユーザーIDが入ります）。
+const socketFileNameFormat = "shutdown-alert-%d.sock"
+
+// unixListener はUnixドメインソケットで待ち受ける Listener です。
+type unixListener struct {
+	listener net.Listener
+}
+
+// Listen はユーザーごとのUnixドメインソケットで待ち受けを開始します。
+// 別のインスタンスが待ち受けている場合は ErrAlreadyRunning を返します。
+// 前回の異常終了で残ったソケットファイルは削除して作り直します。
+// この関数は副作用（ソケットの作成、ファイルの削除）を持ちます。
+func Listen() (Listener, error) {
+	path := socketPath()
+	listener, err := net.Listen("unix", path)
+	if err == nil {
+		return &unixListener{listener: listener}, nil
+	}
+
+	if conn, dialErr := net.DialTimeout("unix", path, dialTimeout); dialErr == nil {
+		_ = conn.Close()
+		return nil, ErrAlreadyRunning
+	}
+	if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+		return nil, fmt.Errorf("ソケットファイルを削除できませんでした: %w", removeErr)
+	}
+
+	listener, err = net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("ソケットで待ち受けできませんでした: %w", err)
+	}
+	return &unixListener{listener: listener}, nil
+}
+
+// Dial は起動中のインスタンスのソケットに接続します。dialTimeout を過ぎても接続できない場合は失敗します。
+// この関数は副作用（ソケットへの接続）を持ちます。
+func Dial() (io.ReadWriteCloser, error) {
+	conn, err := net.DialTimeout("unix", socketPath(), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	return conn, nil
+}
+
+// Accept は次の接続を待ちます。
+// この関数は副作用（接続の受け付け）を持ちます。
+func (listener *unixListener) Accept() (io.ReadWriteCloser, error) {
+	conn, err := listener.listener.Accept()
+	if errors.Is(err, net.ErrClosed) {
+		return nil, ErrListenerClosed
+	}
+	return conn, err
+}
+
+// Close は待ち受けを終了し、ソケットファイルを削除します。
+// この関数は副作用（ソケットのクローズ）を持ちます。
+func (listener *unixListener) Close() error {
+	return listener.listener.Close()
+}
+
+// socketPath はソケットファイルのパスを返します。
+// XDG_RUNTIME_DIR（ユーザー専用のディレクトリ）があればそこに、なければ一時ディレクトリに作成します。
+// この関数は副作用（環境変数の参照）を持ちます。
+func socketPath() string {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf(socketFileNameFormat, os.Getuid()))
+}
@@ -0,0 +1,169 @@
+//go:build windows
+
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// pipeNameは名前付きパイプの名前です（ミューテックスと同じGUIDを使います）。
+	pipeName = `\\.\pipe\ShutdownAlert-{E5F8A9C3-4D2B-4A1E-9F3C-8B7D6E5A4C2F}`
+	// pipeBufferSizeはパイプの入出力バッファのサイズです。
+	pipeBufferSize = 4096
+	// dialRetryIntervalはパイプが使用中（次の接続の準備中）の場合に再試行する間隔です。
+	dialRetryInterval = 50 * time.Millisecond
+)
+
+// pipeListener は名前付きパイプで待ち受ける Listener です。
+// 名前付きパイプは接続ごとにインスタンスを作るため、次の接続用のインスタンスを1つ用意しておきます。
+// 読み書きに期限を設定できるよう、パイプは非同期I/O（FILE_FLAG_OVERLAPPED）で開きます。
+type pipeListener struct {
+	mu     sync.Mutex
+	next   windows.Handle
+	closed bool
+}
+
+// Listen は名前付きパイプで待ち受けを開始します。
+// 別のインスタンスが待ち受けている場合は ErrAlreadyRunning を返します。
+// この関数は副作用（名前付きパイプの作成）を持ちます。
+func Listen() (Listener, error) {
+	handle, err := createPipe(true)
+	if errors.Is(err, windows.ERROR_ACCESS_DENIED) {
+		return nil, ErrAlreadyRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("名前付きパイプを作成できませんでした: %w", err)
+	}
+	return &pipeListener{next: handle}, nil
+}
+
+// Dial は起動中のインスタンスの名前付きパイプに接続します。dialTimeout を過ぎても接続できない場合は失敗します。
+// 読み書きに期限を設定できるよう、非同期I/O（FILE_FLAG_OVERLAPPED）で開きます。
+// この関数は副作用（名前付きパイプへの接続）を持ちます。
+func Dial() (io.ReadWriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(pipeName)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(dialTimeout)
+	for {
+		handle, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, windows.FILE_FLAG_OVERLAPPED, 0)
+		if err == nil {
+			return os.NewFile(uintptr(handle), pipeName), nil
+		}
+		// サーバーが次の接続用のインスタンスを作るまでの間は使用中になります。
+		if errors.Is(err, windows.ERROR_PIPE_BUSY) && time.Now().Before(deadline) {
+			time.Sleep(dialRetryInterval)
+			continue
+		}
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+}
+
+// Accept はクライアントが接続するまで待ち、接続を返します。
+// この関数は副作用（接続の受け付け、名前付きパイプの作成）を持ちます。
+func (listener *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	listener.mu.Lock()
+	handle := listener.next
+	listener.mu.Unlock()
+
+	if err := connectPipe(handle); err != nil {
+		return nil, err
+	}
+
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+	if listener.closed {
+		_ = windows.CloseHandle(handle)
+		return nil, ErrListenerClosed
+	}
+
+	next, err := createPipe(false)
+	if err != nil {
+		_ = windows.CloseHandle(handle)
+		return nil, fmt.Errorf("名前付きパイプを作成できませんでした: %w", err)
+	}
+	listener.next = next
+	return &pipeConn{File: os.NewFile(uintptr(handle), pipeName), handle: handle}, nil
+}
+
+// Close は待ち受けを終了します。
+// ConnectNamedPipe は別のスレッドから取り消せないため、自分自身に接続して待機を終わらせます。
+// この関数は副作用（名前付きパイプへの接続）を持ちます。
+func (listener *pipeListener) Close() error {
+	listener.mu.Lock()
+	if listener.closed {
+		listener.mu.Unlock()
+		return nil
+	}
+	listener.closed = true
+	listener.mu.Unlock()
+
+	if conn, err := Dial(); err == nil {
+		_ = conn.Close()
+	}
+	return nil
+}
+
+// pipeConn はサーバー側の名前付きパイプの接続です。
+type pipeConn struct {
+	*os.File
+	handle windows.Handle
+}
+
+// Close はクライアントが応答を読み終えるのを待ってから切断します。
+// この関数は副作用（名前付きパイプの切断）を持ちます。
+func (conn *pipeConn) Close() error {
+	_ = windows.FlushFileBuffers(conn.handle)
+	_ = windows.DisconnectNamedPipe(conn.handle)
+	return conn.File.Close()
+}
+
+// connectPipe はクライアントが handle のインスタンスに接続するまで待ちます。
+// handle は非同期I/Oで開いているため、イベントで完了を待ちます。
+// この関数は副作用（接続の待機）を持ちます。
+func connectPipe(handle windows.Handle) error {
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(event)
+
+	overlapped := windows.Overlapped{HEvent: event}
+	err = windows.ConnectNamedPipe(handle, &overlapped)
+	if errors.Is(err, windows.ERROR_IO_PENDING) {
+		var transferred uint32
+		err = windows.GetOverlappedResult(handle, &overlapped, &transferred, true)
+	}
+	// 待ち始める前にクライアントが接続していた場合も成功です。
+	if errors.Is(err, windows.ERROR_PIPE_CONNECTED) {
+		return nil
+	}
+	return err
+}
+
+// createPipe は名前付きパイプのインスタンスを1つ作成します。
+// first が true の場合、既に同じ名前のパイプがあれば失敗します（2重起動の検出）。
+// この関数は副作用（名前付きパイプの作成）を持ちます。
+func createPipe(first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(pipeName)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	openMode := uint32(windows.PIPE_ACCESS_DUPLEX | windows.FILE_FLAG_OVERLAPPED)
+	if first {
+		openMode |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+	pipeMode := uint32(windows.PIPE_TYPE_BYTE | windows.PIPE_READMODE_BYTE | windows.PIPE_WAIT | windows.PIPE_REJECT_REMOTE_CLIENTS)
+	return windows.CreateNamedPipe(name, openMode, pipeMode, windows.PIPE_UNLIMITED_INSTANCES, pipeBufferSize, pipeBufferSize, 0, nil)
+}
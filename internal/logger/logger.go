@@ -1,9 +1,8 @@
-//go:build windows
-
 package logger
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"shutdown-alert/internal/config"
@@ -57,6 +56,36 @@ func LogError(component, message string, err error, context map[string]interface
 	writeLogsToFile(logPath, entries)
 }
 
+// ReadAll はログファイルのすべてのエントリを記録順に返します。
+// ファイルがない場合は空のスライスを返します。
+// この関数は副作用を持ちます（ファイルの読み取り）。
+func ReadAll() ([]LogEntry, error) {
+	logPath, err := getLogFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return readExistingLogs(logPath), nil
+}
+
+// Clear はログファイルを削除します。ファイルがない場合は何もしません。
+// この関数は副作用を持ちます（ファイルの削除）。
+func Clear() error {
+	logPath, err := getLogFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(logPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// FilePath は実行ファイルと同じディレクトリのログファイルパスを返します。
+// この関数は副作用を持ちます（ファイルシステムへのアクセス）。
+func FilePath() (string, error) {
+	return getLogFilePath()
+}
+
 // getLogFilePath は実行ファイルと同じディレクトリのログファイルパスを返します。
 // この関数は副作用を持ちます（ファイルシステムへのアクセス）。
 func getLogFilePath() (string, error) {
@@ -0,0 +1,216 @@
+//go:build linux
+
+// このパッケージは systemd-logind の「delay」インヒビターロックを利用して
+// Linux のシャットダウン／スリープを検知する。
+//
+// 【仕組み】
+//
+// logind は D-Bus の org.freedesktop.login1.Manager.Inhibit メソッドで
+// インヒビターロック（ファイルディスクリプタ）を払い出す。
+// mode に "delay" を指定したロックを保持している間、logind は
+// PrepareForShutdown(true) / PrepareForSleep(true) シグナルを送信したあと
+// ロックが閉じられるか InhibitDelayMaxSec が経過するまで実際の処理を待つ。
+//
+// 【処理フロー】
+//
+//  1. Inhibit("shutdown:sleep", ..., "delay") でロックを取得
+//  2. PrepareForShutdown / PrepareForSleep シグナルを購読
+//  3. 引数 true のシグナルを受信したらハンドラ（リマインダー）を呼び出す
+//  4. ハンドラから戻ったらロックを閉じて logind に処理の続行を許可する
+//  5. 引数 false（キャンセル・スリープ復帰）を受信したらロックを取り直す
+//
+// 【技術的制約】
+//
+//   - godbus はシグナルを channel 経由で配送するため、このパッケージに限り channel を使用する。
+//     channel の受信は Run の中だけで行い、goroutine は作成しない。
+//   - 接続（*dbus.Conn）は呼び出し側から渡す。テストではプライベートなセッションバス上の
+//     偽の logind サービスに接続した Conn を渡せばよい。
+package logind
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	// logind の D-Bus 名とオブジェクトパス
+	busName          = "org.freedesktop.login1"
+	objectPath       = dbus.ObjectPath("/org/freedesktop/login1")
+	managerInterface = "org.freedesktop.login1.Manager"
+
+	// Inhibit メソッド名
+	inhibitMethod = managerInterface + ".Inhibit"
+
+	// 購読するシグナル名
+	prepareForShutdownSignal = "PrepareForShutdown"
+	prepareForSleepSignal    = "PrepareForSleep"
+
+	// インヒビターロックの対象と種類
+	inhibitWhat = "shutdown:sleep"
+	inhibitMode = "delay"
+
+	// シグナルのバッファサイズ
+	signalBufferSize = 10
+
+	// ロックを保持していないことを表すファイルディスクリプタ
+	noLock = -1
+)
+
+// Kind は logind が通知した処理の種類を表します。
+type Kind int
+
+const (
+	// KindShutdown はシャットダウン（再起動を含む）を表します。
+	KindShutdown Kind = iota
+	// KindSleep はサスペンド／ハイバネートを表します。
+	KindSleep
+)
+
+// String は種類を文字列で返します（ログ出力用）。
+func (kind Kind) String() string {
+	switch kind {
+	case KindShutdown:
+		return "shutdown"
+	case KindSleep:
+		return "sleep"
+	}
+	return "unknown"
+}
+
+// Monitor は logind のインヒビターロックとシグナル購読を管理します。
+type Monitor struct {
+	manager dbus.BusObject
+	conn    *dbus.Conn
+	who     string
+	why     string
+	signals chan *dbus.Signal
+	// lockFD は現在保持しているロックのファイルディスクリプタです。
+	// シグナルに応じて取得・解放を繰り返すためミュータブルです。
+	lockFD int
+}
+
+// NewMonitor は新しい Monitor を作成します。
+// who と why は logind のインヒビター一覧（systemd-inhibit --list）に表示されます。
+func NewMonitor(conn *dbus.Conn, who, why string) *Monitor {
+	return &Monitor{
+		manager: conn.Object(busName, objectPath),
+		conn:    conn,
+		who:     who,
+		why:     why,
+		signals: make(chan *dbus.Signal, signalBufferSize),
+		lockFD:  noLock,
+	}
+}
+
+// Start はシグナルを購読し、最初のインヒビターロックを取得します。
+// この関数は副作用（D-Bus 呼び出し）を持ちます。
+func (monitor *Monitor) Start() error {
+	for _, member := range []string{prepareForShutdownSignal, prepareForSleepSignal} {
+		err := monitor.conn.AddMatchSignal(
+			dbus.WithMatchObjectPath(objectPath),
+			dbus.WithMatchInterface(managerInterface),
+			dbus.WithMatchMember(member),
+		)
+		if err != nil {
+			return fmt.Errorf("%s シグナルの購読に失敗しました: %w", member, err)
+		}
+	}
+	monitor.conn.Signal(monitor.signals)
+
+	return monitor.acquireLock()
+}
+
+// Run はシグナルを待ち受け、シャットダウン／スリープの直前に onPrepare を呼び出します。
+// onPrepare から戻るとロックを解放し、logind に処理の続行を許可します。
+// キャンセル／スリープ復帰時はロックを取り直してから onResume を呼び出します。
+// 接続が閉じられると nil を返して終了します。
+// この関数は副作用（D-Bus 呼び出し、ファイルディスクリプタの操作）を持ちます。
+func (monitor *Monitor) Run(onPrepare, onResume func(Kind)) error {
+	for signal := range monitor.signals {
+		kind, active, ok := decodeSignal(signal)
+		if !ok {
+			continue
+		}
+
+		if active {
+			onPrepare(kind)
+			if err := monitor.releaseLock(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// キャンセルまたはスリープからの復帰 → 次回に備えてロックを取り直す
+		if err := monitor.acquireLock(); err != nil {
+			return err
+		}
+		onResume(kind)
+	}
+
+	return nil
+}
+
+// Close はシグナル購読を解除し、保持しているロックを解放します。
+// この関数は副作用（D-Bus 呼び出し、ファイルディスクリプタの操作）を持ちます。
+func (monitor *Monitor) Close() error {
+	monitor.conn.RemoveSignal(monitor.signals)
+	return monitor.releaseLock()
+}
+
+// acquireLock は delay インヒビターロックを取得します（既に保持していれば何もしません）。
+// この関数は副作用（D-Bus 呼び出し）を持ちます。
+func (monitor *Monitor) acquireLock() error {
+	if monitor.lockFD != noLock {
+		return nil
+	}
+
+	var fd dbus.UnixFD
+	err := monitor.manager.Call(inhibitMethod, 0, inhibitWhat, monitor.who, monitor.why, inhibitMode).Store(&fd)
+	if err != nil {
+		return fmt.Errorf("インヒビターロックの取得に失敗しました: %w", err)
+	}
+
+	monitor.lockFD = int(fd)
+	return nil
+}
+
+// releaseLock は保持しているロックを閉じます（保持していなければ何もしません）。
+// この関数は副作用（ファイルディスクリプタの操作）を持ちます。
+func (monitor *Monitor) releaseLock() error {
+	if monitor.lockFD == noLock {
+		return nil
+	}
+
+	err := syscall.Close(monitor.lockFD)
+	monitor.lockFD = noLock
+	if err != nil {
+		return fmt.Errorf("インヒビターロックの解放に失敗しました: %w", err)
+	}
+
+	return nil
+}
+
+// decodeSignal は logind のシグナルを種類と開始/終了に変換します。
+// 対象外のシグナルの場合は ok に false を返します。
+// この関数は純粋関数です。
+func decodeSignal(signal *dbus.Signal) (kind Kind, active bool, ok bool) {
+	if signal == nil || signal.Path != objectPath || len(signal.Body) != 1 {
+		return 0, false, false
+	}
+
+	active, ok = signal.Body[0].(bool)
+	if !ok {
+		return 0, false, false
+	}
+
+	switch signal.Name {
+	case managerInterface + "." + prepareForShutdownSignal:
+		return KindShutdown, active, true
+	case managerInterface + "." + prepareForSleepSignal:
+		return KindSleep, active, true
+	}
+
+	return 0, false, false
+}
@@ -0,0 +1,285 @@
+//go:build linux
+
+package logind
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// busConfig はテスト用のプライベートなバスの設定です（誰でも名前を取得・送信できます）。
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%DIR%</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// privateBus はテストごとに dbus-daemon を起動し、そのアドレスを返します。
+// dbus-daemon がない環境ではテストをスキップします。
+func privateBus(t *testing.T) string {
+	t.Helper()
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon がないためスキップします")
+	}
+
+	// Unixドメインソケットのパスの長さには上限があるため、t.TempDir より短いディレクトリを使います。
+	dir, err := os.MkdirTemp("", "bus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	configPath := filepath.Join(dir, "bus.conf")
+	if err := os.WriteFile(configPath, []byte(strings.ReplaceAll(busConfig, "%DIR%", dir)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	command := exec.Command(daemon, "--config-file="+configPath, "--nofork", "--print-address=1")
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := command.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = command.Process.Kill()
+		_ = command.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("dbus-daemon のアドレスを読み取れませんでした: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// connect はバスに接続し、テストの終了時に閉じます。
+func connect(t *testing.T, address string) *dbus.Conn {
+	t.Helper()
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// inhibitCall は偽の logind が受け取った Inhibit の引数です。
+type inhibitCall struct {
+	what, who, why, mode string
+}
+
+// fakeLogind は Inhibit を受け付け、シグナルを送る偽の logind です。
+type fakeLogind struct {
+	conn  *dbus.Conn
+	mu    sync.Mutex
+	calls []inhibitCall
+	// pipes はロックとして渡したパイプです（テストの終了時に閉じます）。
+	pipes []*os.File
+}
+
+// startFakeLogind はバスに logind の名前でサービスを登録します。
+func startFakeLogind(t *testing.T, address string) *fakeLogind {
+	t.Helper()
+	fake := &fakeLogind{conn: connect(t, address)}
+	if err := fake.conn.Export(fake, objectPath, managerInterface); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := fake.conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("RequestName() = %v, %v", reply, err)
+	}
+	t.Cleanup(func() {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		for _, pipe := range fake.pipes {
+			_ = pipe.Close()
+		}
+	})
+	return fake
+}
+
+// Inhibit は logind の Inhibit メソッドの偽の実装で、パイプの書き込み側をロックとして返します。
+func (fake *fakeLogind) Inhibit(what, who, why, mode string) (dbus.UnixFD, *dbus.Error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.calls = append(fake.calls, inhibitCall{what: what, who: who, why: why, mode: mode})
+	fake.pipes = append(fake.pipes, reader, writer)
+	return dbus.UnixFD(writer.Fd()), nil
+}
+
+// inhibitCalls は受け取った Inhibit の呼び出しを返します。
+func (fake *fakeLogind) inhibitCalls() []inhibitCall {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return append([]inhibitCall(nil), fake.calls...)
+}
+
+// emit はシグナルを送ります。
+func (fake *fakeLogind) emit(t *testing.T, member string, active bool) {
+	t.Helper()
+	if err := fake.conn.Emit(objectPath, managerInterface+"."+member, active); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// monitorEvent は Run のコールバックの呼び出しです。
+type monitorEvent struct {
+	prepare bool
+	kind    Kind
+	// locked はコールバックの時点でロックを保持していたかどうかです。
+	locked bool
+}
+
+func TestMonitorWithFakeLogind(t *testing.T) {
+	tests := []struct {
+		name        string
+		signal      string
+		want        Kind
+		wantInhibit int
+	}{
+		{name: "シャットダウン", signal: prepareForShutdownSignal, want: KindShutdown, wantInhibit: 2},
+		{name: "スリープ", signal: prepareForSleepSignal, want: KindSleep, wantInhibit: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := privateBus(t)
+			fake := startFakeLogind(t, address)
+			conn := connect(t, address)
+
+			monitor := NewMonitor(conn, "shutdown-alert", "確認中")
+			if err := monitor.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+
+			events := make(chan monitorEvent, 4)
+			done := make(chan error, 1)
+			go func() {
+				done <- monitor.Run(
+					func(kind Kind) { events <- monitorEvent{prepare: true, kind: kind, locked: monitor.lockFD != noLock} },
+					func(kind Kind) { events <- monitorEvent{prepare: false, kind: kind, locked: monitor.lockFD != noLock} },
+				)
+			}()
+
+			// 開始（true）では保留したまま onPrepare を呼び、戻ったらロックを解放します。
+			fake.emit(t, tt.signal, true)
+			if got := waitEvent(t, events); got != (monitorEvent{prepare: true, kind: tt.want, locked: true}) {
+				t.Errorf("onPrepare = %+v", got)
+			}
+
+			// キャンセル・復帰（false）ではロックを取り直してから onResume を呼びます。
+			fake.emit(t, tt.signal, false)
+			if got := waitEvent(t, events); got != (monitorEvent{prepare: false, kind: tt.want, locked: true}) {
+				t.Errorf("onResume = %+v", got)
+			}
+
+			calls := fake.inhibitCalls()
+			if len(calls) != tt.wantInhibit {
+				t.Fatalf("Inhibit calls = %d, want %d", len(calls), tt.wantInhibit)
+			}
+			want := inhibitCall{what: inhibitWhat, who: "shutdown-alert", why: "確認中", mode: inhibitMode}
+			for _, call := range calls {
+				if call != want {
+					t.Errorf("Inhibit(%+v), want %+v", call, want)
+				}
+			}
+
+			// アプリケーションと同じく、接続を閉じて Run を終えてから Close します。
+			_ = conn.Close()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Errorf("Run() error = %v", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("接続を閉じても Run() が戻りません")
+			}
+			if err := monitor.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
+
+// waitEvent は Run のコールバックを待ちます。
+func waitEvent(t *testing.T, events <-chan monitorEvent) monitorEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("シグナルを受け取りませんでした")
+		return monitorEvent{}
+	}
+}
+
+func TestDecodeSignal(t *testing.T) {
+	tests := []struct {
+		name       string
+		signal     *dbus.Signal
+		wantKind   Kind
+		wantActive bool
+		wantOK     bool
+	}{
+		{
+			name:       "PrepareForShutdown(true)",
+			signal:     &dbus.Signal{Path: objectPath, Name: managerInterface + "." + prepareForShutdownSignal, Body: []interface{}{true}},
+			wantKind:   KindShutdown,
+			wantActive: true,
+			wantOK:     true,
+		},
+		{
+			name:     "PrepareForSleep(false)",
+			signal:   &dbus.Signal{Path: objectPath, Name: managerInterface + "." + prepareForSleepSignal, Body: []interface{}{false}},
+			wantKind: KindSleep,
+			wantOK:   true,
+		},
+		{
+			name:   "別のオブジェクト",
+			signal: &dbus.Signal{Path: "/other", Name: managerInterface + "." + prepareForShutdownSignal, Body: []interface{}{true}},
+		},
+		{
+			name:   "別のシグナル",
+			signal: &dbus.Signal{Path: objectPath, Name: managerInterface + ".SessionNew", Body: []interface{}{true}},
+		},
+		{
+			name:   "引数が真偽値ではない",
+			signal: &dbus.Signal{Path: objectPath, Name: managerInterface + "." + prepareForShutdownSignal, Body: []interface{}{"true"}},
+		},
+		{
+			name: "nil",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, active, ok := decodeSignal(tt.signal)
+			if ok != tt.wantOK || (ok && (kind != tt.wantKind || active != tt.wantActive)) {
+				t.Errorf("decodeSignal() = %v, %v, %v, want %v, %v, %v", kind, active, ok, tt.wantKind, tt.wantActive, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+//go:build linux
+
+package session
+
+import (
+	"github.com/godbus/dbus/v5"
+
+	"shutdown-alert/internal/logind"
+)
+
+// LogindSource は systemd-logind のシグナルをセッションイベントに変換します。
+//
+// Handler が Holder を実装し保留を求めない場合は、EventQuery を渡さずにすぐロックを解放し、
+// シャットダウン／スリープをそのまま進める（WindowsSource と同じ）。
+type LogindSource struct {
+	monitor *logind.Monitor
+	// handler は Start で設定され、Stop で解除されるためミュータブルです。
+	handler Handler
+}
+
+// NewLogindSource は指定した D-Bus 接続で logind を監視する Source を作成します。
+func NewLogindSource(conn *dbus.Conn, who, why string) *LogindSource {
+	return &LogindSource{monitor: logind.NewMonitor(conn, who, why)}
+}
+
+// Start はシグナルを購読し、インヒビターロックを取得します。
+// イベントは Run の中で handler に渡されます。
+// この関数は副作用（D-Bus 呼び出し）を持ちます。
+func (source *LogindSource) Start(handler Handler) error {
+	source.handler = handler
+	return source.monitor.Start()
+}
+
+// Run はシグナルを待ち受け、イベントを handler に渡します。
+// この関数は副作用（D-Bus 呼び出し）を持ちます。
+func (source *LogindSource) Run() error {
+	return source.monitor.Run(source.handlePrepare, source.handleResume)
+}
+
+// Stop はシグナル購読を解除し、ロックを解放します。
+// この関数は副作用（D-Bus 呼び出し）を持ちます。
+func (source *LogindSource) Stop() error {
+	source.handler = nil
+	return source.monitor.Close()
+}
+
+// handlePrepare はシャットダウン／スリープの直前に、保留する場合だけ EventQuery を渡します。
+// 戻るとロックが解放されるため、保留しない場合は何もせずに戻ります。
+// この関数は副作用（handler の呼び出し）を持ちます。
+func (source *LogindSource) handlePrepare(kind logind.Kind) {
+	if source.handler == nil {
+		return
+	}
+	endKind := endKindFromLogind(kind)
+	if !shouldHold(source.handler, endKind) {
+		return
+	}
+	source.handler.HandleSessionEvent(Event{Type: EventQuery, Kind: endKind})
+}
+
+// handleResume はキャンセル／スリープ復帰時に EventCancel を渡します。
+func (source *LogindSource) handleResume(kind logind.Kind) {
+	if source.handler != nil {
+		source.handler.HandleSessionEvent(Event{Type: EventCancel, Kind: endKindFromLogind(kind)})
+	}
+}
+
+// endKindFromLogind は logind の種類を EndKind に変換します。
+// この関数は純粋関数です。
+func endKindFromLogind(kind logind.Kind) EndKind {
+	if kind == logind.KindSleep {
+		return EndKindSleep
+	}
+	return EndKindShutdown
+}
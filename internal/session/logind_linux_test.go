@@ -0,0 +1,82 @@
+//go:build linux
+
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"shutdown-alert/internal/logind"
+)
+
+// recordingHandler は受け取ったイベントを記録する Handler です。
+type recordingHandler struct {
+	events []Event
+}
+
+// HandleSessionEvent は event を記録します。
+func (handler *recordingHandler) HandleSessionEvent(event Event) {
+	handler.events = append(handler.events, event)
+}
+
+// recorded は記録したイベントを返します。
+func (handler *recordingHandler) recorded() []Event {
+	return handler.events
+}
+
+// holdingHandler は hold の終了理由だけ保留を求める Handler です。
+type holdingHandler struct {
+	recordingHandler
+	hold map[EndKind]bool
+}
+
+// ShouldHold は kind を保留するかを返します。
+func (handler *holdingHandler) ShouldHold(kind EndKind) bool {
+	return handler.hold[kind]
+}
+
+func TestLogindSourceHandlePrepare(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler interface {
+			Handler
+			recorded() []Event
+		}
+		kind logind.Kind
+		want []Event
+	}{
+		{
+			name:    "Holder を実装しない Handler は常に保留",
+			handler: &recordingHandler{},
+			kind:    logind.KindShutdown,
+			want:    []Event{{Type: EventQuery, Kind: EndKindShutdown}},
+		},
+		{
+			name:    "保留を求めるシャットダウン",
+			handler: &holdingHandler{hold: map[EndKind]bool{EndKindShutdown: true}},
+			kind:    logind.KindShutdown,
+			want:    []Event{{Type: EventQuery, Kind: EndKindShutdown}},
+		},
+		{
+			name:    "保留を求めないスリープは EventQuery を渡さない",
+			handler: &holdingHandler{hold: map[EndKind]bool{EndKindShutdown: true}},
+			kind:    logind.KindSleep,
+		},
+		{
+			name:    "保留を求めるスリープ",
+			handler: &holdingHandler{hold: map[EndKind]bool{EndKindSleep: true}},
+			kind:    logind.KindSleep,
+			want:    []Event{{Type: EventQuery, Kind: EndKindSleep}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &LogindSource{handler: tt.handler}
+			source.handlePrepare(tt.kind)
+			if got := tt.handler.recorded(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
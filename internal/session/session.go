@@ -0,0 +1,144 @@
+// このパッケージはOSに依存しない「セッション終了」イベントを定義する。
+//
+// Windows の WM_QUERYENDSESSION や Linux の logind シグナルといった
+// プラットフォーム固有の通知は、各バックエンド（Source の実装）で Event に変換され、
+// Handler に渡される。アプリケーションは Handler だけを実装すればよく、
+// 偽の Source を使えばリマインダーの流れ全体をどの OS 上でもテストできる。
+package session
+
+import "errors"
+
+// ErrUnsupportedEndKind は Initiator が開始できない終了理由を指定されたことを表します。
+var ErrUnsupportedEndKind = errors.New("この終了理由は開始できません")
+
+// EventType はセッションイベントの種類を表します。
+type EventType int
+
+const (
+	// EventQuery はセッション終了の問い合わせ（まだ終了していない）を表します。
+	EventQuery EventType = iota
+	// EventCancel はセッション終了がキャンセルされたことを表します。
+	EventCancel
+	// EventEnd はセッションが実際に終了することを表します。
+	EventEnd
+	// EventNotice は保留できない通知（ロック、Windows のスリープ）を表します。
+	// セッション終了は既に始まっているか伴わないため、Handler から戻るのを待たずに進みます。
+	EventNotice
+)
+
+// String はイベントの種類を文字列で返します（ログ出力用）。
+func (eventType EventType) String() string {
+	switch eventType {
+	case EventQuery:
+		return "query"
+	case EventCancel:
+		return "cancel"
+	case EventEnd:
+		return "end"
+	case EventNotice:
+		return "notice"
+	}
+	return "unknown"
+}
+
+// EndKind はセッション終了の理由を表します。
+type EndKind int
+
+const (
+	// EndKindShutdown はシャットダウンを表します。
+	EndKindShutdown EndKind = iota
+	// EndKindRestart は再起動を表します。
+	EndKindRestart
+	// EndKindLogoff はログオフを表します。
+	EndKindLogoff
+	// EndKindSleep はスリープ／ハイバネートを表します。
+	EndKindSleep
+	// EndKindLock は画面のロックを表します（EventNotice でのみ通知します）。
+	EndKindLock
+	// EndKindCloseApp は Restart Manager（インストーラーや Windows Update）がアプリケーションの終了を求めたことを表します（Windowsのみ）。
+	// 再起動するかどうかは要求元が決めるため、Initiator では開始しません。
+	EndKindCloseApp
+)
+
+// String は終了理由を文字列で返します（ログ出力用）。
+func (kind EndKind) String() string {
+	switch kind {
+	case EndKindShutdown:
+		return "shutdown"
+	case EndKindRestart:
+		return "restart"
+	case EndKindLogoff:
+		return "logoff"
+	case EndKindSleep:
+		return "sleep"
+	case EndKindLock:
+		return "lock"
+	case EndKindCloseApp:
+		return "close_app"
+	}
+	return "unknown"
+}
+
+// EndKinds はすべての終了理由を定義順に返します。
+// この関数は純粋関数です。
+func EndKinds() []EndKind {
+	return []EndKind{EndKindShutdown, EndKindRestart, EndKindLogoff, EndKindSleep, EndKindLock, EndKindCloseApp}
+}
+
+// ParseEndKind は String で得られる名前から終了理由を返します。
+// 該当する終了理由がない場合は ok に false を返します。
+// この関数は純粋関数です。
+func ParseEndKind(name string) (kind EndKind, ok bool) {
+	for _, candidate := range EndKinds() {
+		if candidate.String() == name {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+// Event はセッションイベントを表します。
+type Event struct {
+	Type EventType
+	Kind EndKind
+}
+
+// Handler はセッションイベントを受け取ります。
+// EventQuery の処理中はセッション終了が保留されており、
+// HandleSessionEvent から戻るとバックエンドが保留を解除します。
+type Handler interface {
+	HandleSessionEvent(event Event)
+}
+
+// Holder は Handler が任意で実装するインターフェースで、
+// EventQuery を渡す前にセッション終了を保留するかどうかを決めます。
+// 実装していない Handler に対しては常に保留します。
+type Holder interface {
+	ShouldHold(kind EndKind) bool
+}
+
+// shouldHold は handler がセッション終了の保留を求めているかを返します。
+// この関数は純粋関数です。
+func shouldHold(handler Handler, kind EndKind) bool {
+	if holder, ok := handler.(Holder); ok {
+		return holder.ShouldHold(kind)
+	}
+	return true
+}
+
+// Source はセッションイベントの発生源（OSごとのバックエンド）を表します。
+type Source interface {
+	// Start はイベントの配送を開始します。
+	// イベントは各プラットフォームのイベントループ上で handler に渡されます。
+	Start(handler Handler) error
+	// Stop はイベントの配送を停止し、確保したリソースを解放します。
+	Stop() error
+}
+
+// Initiator はリマインダーのために中断したセッション終了を改めて開始します（OSごとの実装）。
+// 偽の Initiator を使えば、実際にシャットダウンせずに再開の流れをテストできる。
+type Initiator interface {
+	// Initiate は kind のセッション終了を開始します。
+	// 開始できない終了理由の場合は ErrUnsupportedEndKind を返します。
+	Initiate(kind EndKind) error
+}
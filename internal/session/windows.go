@@ -0,0 +1,200 @@
+//go:build windows
+
+package session
+
+import (
+	"fmt"
+
+	"github.com/lxn/win"
+
+	"shutdown-alert/internal/win32"
+	"shutdown-alert/internal/wndproc"
+)
+
+// WindowsSource は WM_QUERYENDSESSION / WM_ENDSESSION とロック・スリープの通知をセッションイベントに変換します。
+//
+// 【処理フロー】
+//
+//  1. Windows が WM_QUERYENDSESSION を送信
+//  2. ShutdownBlockReasonCreate でシャットダウン画面に理由を表示
+//  3. WM_SHOW_DIALOG を自分自身に PostMessage
+//  4. 一旦シャットダウンを拒否（return 0）
+//  5. 通常のメッセージループ内で WM_SHOW_DIALOG を受信
+//  6. Handler に EventQuery を渡し、戻ったらブロック理由をクリア
+//
+// Handler が Holder を実装し保留を求めない場合は、1 の時点でセッション終了を許可する。
+//
+// 画面のロック（WM_WTSSESSION_CHANGE の WTS_SESSION_LOCK）と
+// スリープ・休止状態（WM_POWERBROADCAST の PBT_APMSUSPEND）は拒否できないため、
+// 同じく WM_SHOW_NOTICE をポストして EventNotice を渡すだけで、ロック・スリープはそのまま進む。
+// ダイアログはロック解除・復帰後に目にすることになる。
+//
+// ※ WM_QUERYENDSESSION のハンドラ内で直接 UI を表示すると不安定になるため、
+// PostMessage を使って処理を遅延させている。
+type WindowsSource struct {
+	hwnd        win.HWND
+	blockReason string
+	// logError は続行できる失敗（ロックの通知を登録できないなど）を記録します。
+	logError func(message string, err error)
+	// handler は Start で設定され、Stop で解除されるためミュータブルです。
+	handler Handler
+	// lockNotice はロックの通知を登録できたかどうかです。Start で設定され、Stop で解除されるためミュータブルです。
+	lockNotice bool
+}
+
+// NewWindowsSource は指定したウィンドウに届くメッセージを監視する Source を作成します。
+// blockReason はシャットダウンをブロックしている間、シャットダウン画面に表示されます。
+// logError はセッション終了の監視を続けられる失敗を記録します。
+func NewWindowsSource(hwnd win.HWND, blockReason string, logError func(message string, err error)) *WindowsSource {
+	return &WindowsSource{hwnd: hwnd, blockReason: blockReason, logError: logError}
+}
+
+// Start はロックの通知を受け取るよう登録し、ウィンドウプロシージャにフックを登録します。
+// ロックの通知を登録できない場合（リモートデスクトップのサービスが停止している場合など）は logError に記録し、
+// ロックの通知なしでセッション終了とスリープの監視を続けます。
+// この関数は副作用（Win32 API呼び出し、ウィンドウプロシージャの変更）を持ちます。
+func (source *WindowsSource) Start(handler Handler) error {
+	err := win32.WTSRegisterSessionNotification(source.hwnd, win32.NOTIFY_FOR_THIS_SESSION)
+	source.lockNotice = err == nil
+	if err != nil {
+		source.logError("ロックの通知を登録できませんでした（ロックではリマインダーを表示しません）", err)
+	}
+	source.handler = handler
+	wndproc.Add(source.hwnd, source)
+	return nil
+}
+
+// Stop はウィンドウプロシージャからフックを解除し、ロックの通知の登録を解除します。
+// この関数は副作用（Win32 API呼び出し、ウィンドウプロシージャの変更）を持ちます。
+func (source *WindowsSource) Stop() error {
+	wndproc.Remove(source.hwnd, source)
+	source.handler = nil
+	if !source.lockNotice {
+		return nil
+	}
+	source.lockNotice = false
+	if err := win32.WTSUnRegisterSessionNotification(source.hwnd); err != nil {
+		return fmt.Errorf("ロックの通知の登録を解除できませんでした: %w", err)
+	}
+	return nil
+}
+
+// HandleMessage はセッション関連のウィンドウメッセージを処理します。
+// この関数は副作用（Win32メッセージ処理）を持ちます。
+func (source *WindowsSource) HandleMessage(hwnd win.HWND, msg uint32, wParam, lParam uintptr) (uintptr, bool) {
+	if source.handler == nil {
+		return 0, false
+	}
+
+	switch msg {
+	case win32.WM_QUERYENDSESSION:
+		if !shouldHold(source.handler, decodeEndKind(lParam)) {
+			// 応答済みのセッション終了はそのまま許可します（TRUE を返す）。
+			return 1, true
+		}
+
+		// シャットダウン画面に表示されるブロック理由を設定します。
+		win32.ShutdownBlockReasonCreate(hwnd, source.blockReason)
+
+		// このハンドラから戻った後にダイアログを表示するためのメッセージをポストします。
+		// 終了理由は wParam で受け渡します。
+		win32.PostMessage(hwnd, win32.WM_SHOW_DIALOG, uintptr(decodeEndKind(lParam)), 0)
+
+		// シャットダウンを一時的にブロックするためにFALSEを返します。
+		return 0, true
+
+	case win32.WM_SHOW_DIALOG:
+		source.handler.HandleSessionEvent(Event{Type: EventQuery, Kind: EndKind(wParam)})
+		// ダイアログが閉じられた後、ブロック理由をクリアします。
+		win32.ShutdownBlockReasonDestroy(hwnd)
+		return 0, true
+
+	case win32.WM_ENDSESSION:
+		// wParam が FALSE の場合はセッション終了がキャンセルされています。
+		eventType := EventCancel
+		if wParam != 0 {
+			eventType = EventEnd
+		}
+		source.handler.HandleSessionEvent(Event{Type: eventType, Kind: decodeEndKind(lParam)})
+		// 元のウィンドウプロシージャにも処理させます。
+		return 0, false
+
+	case win32.WM_WTSSESSION_CHANGE:
+		if wParam == win32.WTS_SESSION_LOCK {
+			win32.PostMessage(hwnd, win32.WM_SHOW_NOTICE, uintptr(EndKindLock), 0)
+		}
+		return 0, false
+
+	case win32.WM_POWERBROADCAST:
+		if wParam == win32.PBT_APMSUSPEND {
+			win32.PostMessage(hwnd, win32.WM_SHOW_NOTICE, uintptr(EndKindSleep), 0)
+		}
+		return 0, false
+
+	case win32.WM_SHOW_NOTICE:
+		source.handler.HandleSessionEvent(Event{Type: EventNotice, Kind: EndKind(wParam)})
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// WindowsInitiator は ExitWindowsEx で中断したセッション終了を再開します。
+// 再開したセッション終了を再びブロックしないよう、Handler は Holder を実装して
+// 応答済みの EventQuery を保留しないようにしてください。
+type WindowsInitiator struct{}
+
+// NewWindowsInitiator は新しい WindowsInitiator を作成します。
+func NewWindowsInitiator() *WindowsInitiator {
+	return &WindowsInitiator{}
+}
+
+// Initiate は kind のセッション終了を開始します。
+// スリープとロックは WindowsSource が保留せずに通知するだけのため ErrUnsupportedEndKind を返します。
+// Restart Manager による終了要求（EndKindCloseApp）も、再起動するかどうかを要求元が決めるため ErrUnsupportedEndKind を返します。
+// この関数は副作用（Win32 API呼び出し）を持ちます。
+func (initiator *WindowsInitiator) Initiate(kind EndKind) error {
+	flags, ok := exitWindowsFlags(kind)
+	if !ok {
+		return ErrUnsupportedEndKind
+	}
+
+	if kind != EndKindLogoff {
+		// シャットダウンと再起動にはシャットダウン特権が必要です。
+		if err := win32.EnableShutdownPrivilege(); err != nil {
+			return err
+		}
+	}
+
+	return win32.ExitWindowsEx(flags, win32.SHTDN_REASON_MAJOR_APPLICATION|win32.SHTDN_REASON_FLAG_PLANNED)
+}
+
+// exitWindowsFlags は終了理由を ExitWindowsEx のフラグに変換します。
+// この関数は純粋関数です。
+func exitWindowsFlags(kind EndKind) (flags uint32, ok bool) {
+	switch kind {
+	case EndKindShutdown:
+		return win32.EWX_SHUTDOWN | win32.EWX_POWEROFF, true
+	case EndKindRestart:
+		return win32.EWX_REBOOT, true
+	case EndKindLogoff:
+		return win32.EWX_LOGOFF, true
+	}
+	return 0, false
+}
+
+// decodeEndKind は WM_QUERYENDSESSION / WM_ENDSESSION の lParam を終了理由に変換します。
+// ENDSESSION_CRITICAL は強制かどうかを表すだけなので理由の判定には使用しません。
+// ユーザーが選んだ再起動は lParam が 0 で届き、シャットダウンと区別できないため EndKindShutdown になります
+// （再開すると再起動ではなく電源を切ります）。
+// この関数は純粋関数です。
+func decodeEndKind(lParam uintptr) EndKind {
+	switch {
+	case lParam&win32.ENDSESSION_LOGOFF != 0:
+		return EndKindLogoff
+	case lParam&win32.ENDSESSION_CLOSEAPP != 0:
+		// Restart Manager（インストーラーや Windows Update）による終了要求
+		return EndKindCloseApp
+	}
+	return EndKindShutdown
+}
@@ -0,0 +1,56 @@
+//go:build windows
+
+package session
+
+import (
+	"testing"
+
+	"shutdown-alert/internal/win32"
+)
+
+func TestDecodeEndKind(t *testing.T) {
+	tests := []struct {
+		name   string
+		lParam uintptr
+		want   EndKind
+	}{
+		{name: "シャットダウン・ユーザーが選んだ再起動", lParam: 0, want: EndKindShutdown},
+		{name: "強制シャットダウン", lParam: win32.ENDSESSION_CRITICAL, want: EndKindShutdown},
+		{name: "ログオフ", lParam: win32.ENDSESSION_LOGOFF, want: EndKindLogoff},
+		{name: "強制ログオフ", lParam: win32.ENDSESSION_LOGOFF | win32.ENDSESSION_CRITICAL, want: EndKindLogoff},
+		{name: "Restart Manager", lParam: win32.ENDSESSION_CLOSEAPP, want: EndKindCloseApp},
+		{name: "強制の Restart Manager", lParam: win32.ENDSESSION_CLOSEAPP | win32.ENDSESSION_CRITICAL, want: EndKindCloseApp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeEndKind(tt.lParam); got != tt.want {
+				t.Errorf("decodeEndKind(%#x) = %v, want %v", tt.lParam, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitWindowsFlags(t *testing.T) {
+	tests := []struct {
+		kind      EndKind
+		wantFlags uint32
+		wantOK    bool
+	}{
+		{kind: EndKindShutdown, wantFlags: win32.EWX_SHUTDOWN | win32.EWX_POWEROFF, wantOK: true},
+		{kind: EndKindRestart, wantFlags: win32.EWX_REBOOT, wantOK: true},
+		{kind: EndKindLogoff, wantFlags: win32.EWX_LOGOFF, wantOK: true},
+		{kind: EndKindSleep},
+		{kind: EndKindLock},
+		{kind: EndKindCloseApp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind.String(), func(t *testing.T) {
+			flags, ok := exitWindowsFlags(tt.kind)
+			if flags != tt.wantFlags || ok != tt.wantOK {
+				t.Errorf("exitWindowsFlags(%v) = %#x, %v, want %#x, %v", tt.kind, flags, ok, tt.wantFlags, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"shutdown-alert/internal/config"
+)
+
+// visibleActionsはダイアログに実際に表示するアクションを返します。
+// URLが空の場合（アラートモード）は組み込みの「開く」を表示しません。
+// この関数は純粋関数です。
+func visibleActions(reminder config.Reminder) []config.ReminderAction {
+	var actions []config.ReminderAction
+	for _, action := range reminder.Actions {
+		if action.Name == config.ActionOpen && reminder.TargetURL == "" {
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// defaultActionはEnterキーで選ばれるアクションを返します。
+// default: true のボタンが表示される場合はそのボタン、なければ「開く」、それ以外は「閉じる」です。
+// この関数は純粋関数です。
+func defaultAction(reminder config.Reminder) config.ReminderAction {
+	actions := visibleActions(reminder)
+	for _, action := range actions {
+		if action.Default {
+			return action
+		}
+	}
+	for _, action := range actions {
+		if action.Name == config.ActionOpen {
+			return action
+		}
+	}
+	return config.BuiltInAction(config.ActionClose)
+}
+
+// findActionはIDに一致する表示中のアクションを返します。
+// この関数は純粋関数です。
+func findAction(actions []config.ReminderAction, id string) (config.ReminderAction, bool) {
+	for _, action := range actions {
+		if action.ID() == id {
+			return action, true
+		}
+	}
+	return config.ReminderAction{}, false
+}
+
+// buttonLabelはボタンに表示するラベル（Windows形式のアクセラレータ付き）を返します。
+// この関数は純粋関数です。
+func buttonLabel(action config.ReminderAction) string {
+	switch action.Name {
+	case config.ActionOpen:
+		return config.OpenButtonLabel
+	case config.ActionSnooze:
+		return config.SnoozeButtonLabel
+	case config.ActionClose:
+		return config.ExitButtonLabel
+	}
+
+	if action.Key == "" {
+		return action.Label
+	}
+	return action.Label + "(&" + action.Key + ")"
+}
+
+// actionNameはアクションの表示名（アクセラレータなし）を返します。
+// この関数は純粋関数です。
+func actionName(action config.ReminderAction) string {
+	return config.ActionDisplayName(action.ID())
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"shutdown-alert/internal/config"
+)
+
+// ChecklistStateはダイアログに表示したチェックリストの状態です。
+// walkに依存しない純粋なモデルで、状態を変える操作は新しい値を返します。
+type ChecklistState struct {
+	Items []config.ChecklistItem
+	// Checked はItemsと同じ順で、各項目にチェックが入っているかを表します。
+	Checked []bool
+}
+
+// newChecklistStateはすべての項目が未チェックの状態を作成します。
+// この関数は純粋関数です。
+func newChecklistState(items []config.ChecklistItem) ChecklistState {
+	return ChecklistState{
+		Items:   items,
+		Checked: make([]bool, len(items)),
+	}
+}
+
+// withCheckedはindex番目の項目のチェックを変更した状態を返します。
+// この関数は純粋関数です。
+func (state ChecklistState) withChecked(index int, checked bool) ChecklistState {
+	next := make([]bool, len(state.Checked))
+	copy(next, state.Checked)
+	if 0 <= index && index < len(next) {
+		next[index] = checked
+	}
+	return ChecklistState{Items: state.Items, Checked: next}
+}
+
+// withCheckedLabelsは表示名がlabelsに含まれる項目だけにチェックを入れた状態を返します。
+// この関数は純粋関数です。
+func (state ChecklistState) withCheckedLabels(labels []string) ChecklistState {
+	selected := map[string]bool{}
+	for _, label := range labels {
+		selected[label] = true
+	}
+
+	next := state
+	for index, item := range state.Items {
+		next = next.withChecked(index, selected[item.Label])
+	}
+	return next
+}
+
+// Completeは必須の項目にすべてチェックが入っているかを返します。
+// この関数は純粋関数です。
+func (state ChecklistState) Complete() bool {
+	for index, item := range state.Items {
+		if item.Required && !state.Checked[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// checklistItemLabelは項目に表示するラベル（必須の印付き）を返します。
+// この関数は純粋関数です。
+func checklistItemLabel(item config.ChecklistItem) string {
+	if item.Required {
+		return item.Label + config.ChecklistRequiredSuffix
+	}
+	return item.Label
+}
+
+// actionEnabledはチェックリストの状態でactionのボタンを押せるかを返します。
+// 「後で」はシャットダウンを先送りするだけなので、必須項目が残っていても押せます。
+// この関数は純粋関数です。
+func (state ChecklistState) actionEnabled(action config.ReminderAction) bool {
+	return action.Name == config.ActionSnooze || state.Complete()
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"shutdown-alert/internal/config"
+)
+
+// checklistItems は必須の項目2つと任意の項目1つのチェックリストです。
+var checklistItems = []config.ChecklistItem{
+	{Label: "勤怠を登録", Required: true},
+	{Label: "VPNを切断", Required: true},
+	{Label: "コードをpush"},
+}
+
+func TestChecklistState(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []config.ChecklistItem
+		change       func(ChecklistState) ChecklistState
+		wantChecked  []bool
+		wantComplete bool
+	}{
+		{
+			name:         "項目なし",
+			change:       func(state ChecklistState) ChecklistState { return state },
+			wantChecked:  []bool{},
+			wantComplete: true,
+		},
+		{
+			name:        "未チェック",
+			items:       checklistItems,
+			change:      func(state ChecklistState) ChecklistState { return state },
+			wantChecked: []bool{false, false, false},
+		},
+		{
+			name:        "必須の項目が残っている",
+			items:       checklistItems,
+			change:      func(state ChecklistState) ChecklistState { return state.withChecked(0, true).withChecked(2, true) },
+			wantChecked: []bool{true, false, true},
+		},
+		{
+			name:         "必須の項目だけチェック",
+			items:        checklistItems,
+			change:       func(state ChecklistState) ChecklistState { return state.withChecked(0, true).withChecked(1, true) },
+			wantChecked:  []bool{true, true, false},
+			wantComplete: true,
+		},
+		{
+			name:  "チェックを外す",
+			items: checklistItems,
+			change: func(state ChecklistState) ChecklistState {
+				return state.withChecked(0, true).withChecked(1, true).withChecked(1, false)
+			},
+			wantChecked: []bool{true, false, false},
+		},
+		{
+			name:        "範囲外は無視",
+			items:       checklistItems,
+			change:      func(state ChecklistState) ChecklistState { return state.withChecked(-1, true).withChecked(3, true) },
+			wantChecked: []bool{false, false, false},
+		},
+		{
+			name:  "表示名で選ぶ（zenity の結果）",
+			items: checklistItems,
+			change: func(state ChecklistState) ChecklistState {
+				return state.withCheckedLabels([]string{"VPNを切断", "勤怠を登録", "不明な項目"})
+			},
+			wantChecked:  []bool{true, true, false},
+			wantComplete: true,
+		},
+		{
+			name:  "表示名で選ぶと選ばれなかった項目は外れる",
+			items: checklistItems,
+			change: func(state ChecklistState) ChecklistState {
+				return state.withChecked(2, true).withCheckedLabels([]string{"勤怠を登録"})
+			},
+			wantChecked: []bool{true, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			initial := newChecklistState(tt.items)
+			got := tt.change(initial)
+
+			if !slices.Equal(got.Checked, tt.wantChecked) {
+				t.Errorf("Checked = %v, want %v", got.Checked, tt.wantChecked)
+			}
+			if got.Complete() != tt.wantComplete {
+				t.Errorf("Complete() = %v, want %v", got.Complete(), tt.wantComplete)
+			}
+			if slices.Contains(initial.Checked, true) {
+				t.Errorf("元の状態が変更されました: %v", initial.Checked)
+			}
+		})
+	}
+}
+
+func TestChecklistActionEnabled(t *testing.T) {
+	incomplete := newChecklistState(checklistItems)
+	complete := incomplete.withChecked(0, true).withChecked(1, true)
+
+	tests := []struct {
+		name   string
+		state  ChecklistState
+		action config.ReminderAction
+		want   bool
+	}{
+		{name: "未完了でも「後で」は押せる", state: incomplete, action: config.BuiltInAction(config.ActionSnooze), want: true},
+		{name: "未完了では「開く」を押せない", state: incomplete, action: config.BuiltInAction(config.ActionOpen)},
+		{name: "未完了では「閉じる」を押せない", state: incomplete, action: config.BuiltInAction(config.ActionClose)},
+		{name: "未完了では独自のボタンを押せない", state: incomplete, action: config.ReminderAction{Label: "日報", URL: "https://report.example.com"}},
+		{name: "完了すれば「開く」を押せる", state: complete, action: config.BuiltInAction(config.ActionOpen), want: true},
+		{name: "完了すれば独自のボタンを押せる", state: complete, action: config.ReminderAction{Label: "日報", URL: "https://report.example.com"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.actionEnabled(tt.action); got != tt.want {
+				t.Errorf("actionEnabled(%s) = %v, want %v", tt.action.ID(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChecklistItemLabel(t *testing.T) {
+	tests := []struct {
+		item config.ChecklistItem
+		want string
+	}{
+		{item: config.ChecklistItem{Label: "勤怠を登録", Required: true}, want: "勤怠を登録" + config.ChecklistRequiredSuffix},
+		{item: config.ChecklistItem{Label: "コードをpush"}, want: "コードをpush"},
+	}
+
+	for _, tt := range tests {
+		if got := checklistItemLabel(tt.item); got != tt.want {
+			t.Errorf("checklistItemLabel(%+v) = %q, want %q", tt.item, got, tt.want)
+		}
+	}
+}
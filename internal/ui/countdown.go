@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"shutdown-alert/internal/config"
+)
+
+// countdownStateはカウントダウンの状態を表します。
+type countdownState int
+
+const (
+	// countdownDisabledはカウントダウンしない（自動で応答しない）ことを表します。
+	countdownDisabled countdownState = iota
+	// countdownRunningはカウントダウン中であることを表します。
+	countdownRunning
+	// countdownExpiredは残り時間が0になり、自動で応答すべきことを表します。
+	countdownExpired
+)
+
+// countdownは確認ダイアログの自動応答までのカウントダウンを表す状態機械です。
+// 現在時刻はすべて引数で受け取るため、時計を差し替えればUIなしでテストできます。
+// 状態を変える操作は新しい値を返し、元の値は変更しません。
+type countdown struct {
+	state    countdownState
+	deadline time.Time
+	// actionは残り時間が0になったときに選ぶアクションです。
+	action config.ReminderAction
+}
+
+// newCountdownはnowから設定の秒数だけカウントダウンする状態機械を作成します。
+// 秒数が0の場合、または選ぶアクションがダイアログに表示されない場合は無効な状態を返します。
+// この関数は純粋関数です。
+func newCountdown(setting config.Countdown, reminder config.Reminder, now time.Time) countdown {
+	action, ok := findAction(visibleActions(reminder), setting.Action)
+	if setting.Seconds <= 0 || !ok {
+		return countdown{state: countdownDisabled}
+	}
+
+	return countdown{
+		state:    countdownRunning,
+		deadline: now.Add(time.Duration(setting.Seconds) * time.Second),
+		action:   action,
+	}
+}
+
+// tickは現在時刻nowでの状態を返します。
+// 期限に達していれば countdownExpired に遷移します。それ以外の状態は変化しません。
+// この関数は純粋関数です。
+func (timer countdown) tick(now time.Time) countdown {
+	if timer.state == countdownRunning && !now.Before(timer.deadline) {
+		timer.state = countdownExpired
+	}
+	return timer
+}
+
+// remainingSecondsは期限までの残り秒数を切り上げて返します（期限を過ぎていれば0）。
+// この関数は純粋関数です。
+func (timer countdown) remainingSeconds(now time.Time) int {
+	if timer.state == countdownDisabled || !now.Before(timer.deadline) {
+		return 0
+	}
+	remaining := timer.deadline.Sub(now)
+	return int((remaining + time.Second - 1) / time.Second)
+}
+
+// messageはカウントダウン中にダイアログへ表示する文言を返します（無効な場合は空文字列）。
+// この関数は純粋関数です。
+func (timer countdown) message(now time.Time) string {
+	if timer.state == countdownDisabled {
+		return ""
+	}
+	return fmt.Sprintf(config.CountdownMessageFormat, timer.remainingSeconds(now), actionName(timer.action))
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/config"
+)
+
+// countdownStart はテストでカウントダウンを開始する時刻です。
+var countdownStart = time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+
+// countdownReminder は「開く」「後で」「閉じる」と独自のボタンを表示するリマインダーです。
+func countdownReminder(targetURL string) config.Reminder {
+	return config.Reminder{
+		TargetURL: targetURL,
+		Actions: []config.ReminderAction{
+			config.BuiltInAction(config.ActionOpen),
+			config.BuiltInAction(config.ActionSnooze),
+			{Label: "日報", URL: "https://report.example.com"},
+			config.BuiltInAction(config.ActionClose),
+		},
+	}
+}
+
+func TestNewCountdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		setting    config.Countdown
+		targetURL  string
+		wantState  countdownState
+		wantAction string
+	}{
+		{
+			name:       "開く",
+			setting:    config.Countdown{Seconds: 30, Action: config.ActionOpen},
+			targetURL:  "https://attendance.example.com",
+			wantState:  countdownRunning,
+			wantAction: config.ActionOpen,
+		},
+		{
+			name:       "独自のボタン",
+			setting:    config.Countdown{Seconds: 30, Action: "日報"},
+			wantState:  countdownRunning,
+			wantAction: "日報",
+		},
+		{
+			name:      "0秒は無効",
+			setting:   config.Countdown{Seconds: 0, Action: config.ActionClose},
+			wantState: countdownDisabled,
+		},
+		{
+			name:      "表示されない「開く」は無効",
+			setting:   config.Countdown{Seconds: 30, Action: config.ActionOpen},
+			wantState: countdownDisabled,
+		},
+		{
+			name:      "存在しないボタンは無効",
+			setting:   config.Countdown{Seconds: 30, Action: "打刻"},
+			wantState: countdownDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timer := newCountdown(tt.setting, countdownReminder(tt.targetURL), countdownStart)
+			if timer.state != tt.wantState {
+				t.Fatalf("state = %v, want %v", timer.state, tt.wantState)
+			}
+			if timer.state == countdownRunning {
+				if timer.action.ID() != tt.wantAction {
+					t.Errorf("action = %s, want %s", timer.action.ID(), tt.wantAction)
+				}
+				if want := countdownStart.Add(time.Duration(tt.setting.Seconds) * time.Second); !timer.deadline.Equal(want) {
+					t.Errorf("deadline = %v, want %v", timer.deadline, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCountdownTick(t *testing.T) {
+	running := newCountdown(config.Countdown{Seconds: 10, Action: config.ActionClose}, countdownReminder(""), countdownStart)
+	disabled := newCountdown(config.Countdown{}, countdownReminder(""), countdownStart)
+
+	tests := []struct {
+		name          string
+		timer         countdown
+		elapsed       time.Duration
+		wantState     countdownState
+		wantRemaining int
+		wantMessage   string
+	}{
+		{
+			name:          "開始直後",
+			timer:         running,
+			wantState:     countdownRunning,
+			wantRemaining: 10,
+			wantMessage:   "あと 10 秒で「閉じる」を自動で選択します。",
+		},
+		{
+			name:          "端数は切り上げ",
+			timer:         running,
+			elapsed:       500 * time.Millisecond,
+			wantState:     countdownRunning,
+			wantRemaining: 10,
+			wantMessage:   "あと 10 秒で「閉じる」を自動で選択します。",
+		},
+		{
+			name:          "残り1秒",
+			timer:         running,
+			elapsed:       9 * time.Second,
+			wantState:     countdownRunning,
+			wantRemaining: 1,
+			wantMessage:   "あと 1 秒で「閉じる」を自動で選択します。",
+		},
+		{
+			name:        "期限ちょうど",
+			timer:       running,
+			elapsed:     10 * time.Second,
+			wantState:   countdownExpired,
+			wantMessage: "あと 0 秒で「閉じる」を自動で選択します。",
+		},
+		{
+			name:        "期限を過ぎた",
+			timer:       running,
+			elapsed:     time.Minute,
+			wantState:   countdownExpired,
+			wantMessage: "あと 0 秒で「閉じる」を自動で選択します。",
+		},
+		{
+			name:      "無効なカウントダウンは期限にならない",
+			timer:     disabled,
+			elapsed:   time.Hour,
+			wantState: countdownDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := countdownStart.Add(tt.elapsed)
+			got := tt.timer.tick(now)
+			if got.state != tt.wantState {
+				t.Errorf("tick().state = %v, want %v", got.state, tt.wantState)
+			}
+			if remaining := got.remainingSeconds(now); remaining != tt.wantRemaining {
+				t.Errorf("remainingSeconds() = %d, want %d", remaining, tt.wantRemaining)
+			}
+			if message := got.message(now); message != tt.wantMessage {
+				t.Errorf("message() = %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+
+	t.Run("元の値を変更しない", func(t *testing.T) {
+		running.tick(countdownStart.Add(time.Minute))
+		if running.state != countdownRunning {
+			t.Errorf("state = %v, want countdownRunning", running.state)
+		}
+	})
+}
@@ -3,74 +3,168 @@
 package ui
 
 import (
+	"time"
+
 	"github.com/lxn/walk"
 	"github.com/lxn/walk/declarative"
 
 	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
 )
 
+// countdownTickIntervalはカウントダウン表示を更新する間隔です。
+const countdownTickInterval = 200 * time.Millisecond
+
 // ShowConfirmationDialogはシャットダウン確認ダイアログを表示します。
+// ボタンはreminder.Actionsの順に1つずつ表示し、タイトルには終了理由を表示します。
+// countdownが有効な場合は残り秒数を表示し、0になると設定されたアクションを自動で選びます。
+// チェックリストがある場合、必須の項目にすべてチェックが入るまで「後で」以外のボタンは押せません
+// （無人のシャットダウンに備えて、カウントダウンによる自動応答はチェックに関係なく行います）。
+// 選ばれたアクションとチェックリストの状態はonChooseに渡します。
 // この関数は副作用（UIの表示、アプリケーションの終了の可能性）を持ちます。
-// ユーザーの選択と発生したエラーを返します。
-func ShowConfirmationDialog(owner walk.Form, urlToOpen string, dialogWidth, dialogHeight int, dialogMessage string, onOpen, onExit func()) error {
+func ShowConfirmationDialog(owner walk.Form, kind session.EndKind, reminder config.Reminder, countdownSetting config.Countdown, dialogWidth, dialogHeight int, onChoose func(config.ReminderAction, ChecklistState)) error {
 	var dlg *walk.Dialog
-	var openBtn, exitBtn *walk.PushButton
+	var countdownLabel *walk.Label
+	timer := newCountdown(countdownSetting, reminder, time.Now())
+	checklist := newChecklistState(reminder.Checklist)
+	// chosen はボタンまたはカウントダウンで応答したかどうかです。
+	chosen := false
 
-	// URLの有無によってボタンの構成を決定します。
+	actions := visibleActions(reminder)
+	defaultID := defaultAction(reminder).ID()
+
+	// アクションの構成によってボタンを決定します。
 	var buttons []declarative.Widget
 	buttons = append(buttons, declarative.HSpacer{})
 
-	if urlToOpen != "" {
-		// URLがある場合：「開く」と「閉じる」両方のボタンを表示
+	// ボタンとカウントダウンの両方から呼び出す、アクションごとの処理です。
+	choose := map[string]func(){}
+	pushButtons := make([]*walk.PushButton, len(actions))
+	var defaultButton, cancelButton **walk.PushButton
+
+	for i, action := range actions {
+		choose[action.ID()] = func() {
+			chosen = true
+			if onChoose != nil {
+				onChoose(action, checklist)
+			}
+			dlg.Accept()
+		}
 		buttons = append(buttons, declarative.PushButton{
-			AssignTo: &openBtn,
-			Text:     config.OpenButtonLabel,
+			AssignTo: &pushButtons[i],
+			Text:     buttonLabel(action),
+			Enabled:  checklist.actionEnabled(action),
 			OnClicked: func() {
-				if onOpen != nil {
-					onOpen()
+				// EnterキーやEscキーは無効なボタンでも押されたことになるため、ここでも確認します。
+				if checklist.actionEnabled(action) {
+					choose[action.ID()]()
 				}
-				dlg.Accept()
 			},
 		})
-	}
 
-	// 「閉じる」ボタンは常に表示
-	buttons = append(buttons, declarative.PushButton{
-		AssignTo: &exitBtn,
-		Text:     config.ExitButtonLabel,
-		OnClicked: func() {
-			if onExit != nil {
-				onExit()
-			}
-			dlg.Accept()
-		},
-	})
+		// デフォルトボタンとキャンセルボタンの設定
+		if action.ID() == defaultID {
+			defaultButton = &pushButtons[i]
+		}
+		if action.Name == config.ActionClose {
+			cancelButton = &pushButtons[i]
+		}
+	}
 
-	// デフォルトボタンとキャンセルボタンの設定
-	var defaultButton **walk.PushButton
-	if urlToOpen != "" {
-		defaultButton = &openBtn
-	} else {
-		defaultButton = &exitBtn
+	// チェックリストの項目ごとにチェックボックスを作成します。
+	checkBoxes := make([]*walk.CheckBox, len(checklist.Items))
+	var checklistWidgets []declarative.Widget
+	for i, item := range checklist.Items {
+		checklistWidgets = append(checklistWidgets, declarative.CheckBox{
+			AssignTo: &checkBoxes[i],
+			Text:     checklistItemLabel(item),
+			OnCheckedChanged: func() {
+				checklist = checklist.withChecked(i, checkBoxes[i].Checked())
+				for j, action := range actions {
+					pushButtons[j].SetEnabled(checklist.actionEnabled(action))
+				}
+			},
+		})
 	}
 
-	_, err := declarative.Dialog{
+	err := declarative.Dialog{
 		AssignTo:      &dlg,
-		Title:         config.DialogTitle,
+		Title:         dialogTitle(kind),
 		DefaultButton: defaultButton,
-		CancelButton:  &exitBtn,
+		CancelButton:  cancelButton,
 		MinSize:       declarative.Size{Width: dialogWidth, Height: dialogHeight},
 		Layout:        declarative.VBox{},
 		Children: []declarative.Widget{
 			declarative.Label{
-				Text: dialogMessage,
+				Text: reminder.DialogMessage,
+			},
+			declarative.GroupBox{
+				Title:    config.ChecklistTitle,
+				Layout:   declarative.VBox{},
+				Visible:  len(checklistWidgets) > 0,
+				Children: checklistWidgets,
+			},
+			declarative.Label{
+				AssignTo: &countdownLabel,
+				Text:     timer.message(time.Now()),
+				Visible:  timer.state != countdownDisabled,
 			},
 			declarative.Composite{
 				Layout:   declarative.HBox{},
 				Children: buttons,
 			},
 		},
-	}.Run(owner)
+	}.Create(owner)
+	if err != nil {
+		return err
+	}
+
+	// 必須の項目が残っている間は、ウィンドウの閉じるボタンでも閉じられないようにします。
+	dlg.Closing().Attach(func(canceled *bool, reason walk.CloseReason) {
+		if !chosen && !checklist.Complete() {
+			*canceled = true
+		}
+	})
+
+	if timer.state != countdownDisabled {
+		stop := startCountdown(dlg, countdownLabel, timer, choose)
+		defer stop()
+	}
+
+	dlg.Run()
+	return nil
+}
+
+// startCountdownはカウントダウン表示の更新を開始し、停止する関数を返します。
+// 残り時間が0になると、choose から対応するアクションの処理を呼び出します。
+// walkにはタイマーがないため、ゴルーチンのティッカーからSynchronizeでUIスレッドに処理を戻します。
+// この関数は副作用（ゴルーチンの起動、UIの更新）を持ちます。
+func startCountdown(dlg *walk.Dialog, label *walk.Label, timer countdown, choose map[string]func()) (stop func()) {
+	ticker := time.NewTicker(countdownTickInterval)
+	done := make(chan struct{})
 
-	return err
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				dlg.Synchronize(func() {
+					if timer.state != countdownRunning {
+						return
+					}
+					timer = timer.tick(now)
+					_ = label.SetText(timer.message(now))
+					if timer.state == countdownExpired {
+						choose[timer.action.ID()]()
+					}
+				})
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
 }
@@ -0,0 +1,211 @@
+//go:build linux
+
+package ui
+
+import (
+	"errors"
+	"html"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+const (
+	// zenityCommandはLinuxでダイアログを表示するコマンドです。
+	zenityCommand = "zenity"
+	// zenityCancelledExitCodeは「キャンセル」側のボタンが押されたときの終了コードです。
+	zenityCancelledExitCode = 1
+	// zenityTimeoutExitCodeは--timeoutで指定した時間が経過したときの終了コードです。
+	zenityTimeoutExitCode = 5
+	// zenityListSeparatorはzenityのリストで選ばれた項目を区切る文字です。
+	zenityListSeparator = "|"
+)
+
+// ShowConfirmationDialogはzenityでシャットダウン確認ダイアログを表示します。
+// Enterキーで選ばれるアクションをOKボタン、「閉じる」をキャンセルボタンとし、
+// それ以外のアクションはzenityの追加ボタンとして表示します。
+// countdownが有効な場合はzenityの--timeoutで自動応答します（残り秒数は表示開始時の値のみ表示します）。
+// チェックリストがある場合は、先にzenityのリストで必須の項目がすべてチェックされるまで尋ねます。
+// チェックリストがキャンセルされた場合は「後で」（表示されない場合は「閉じる」）を選んだものとします。
+// 選ばれたアクションとチェックリストの状態はonChooseに渡します。
+// この関数は副作用（外部プロセスの起動、UIの表示）を持ちます。
+func ShowConfirmationDialog(kind session.EndKind, reminder config.Reminder, countdownSetting config.Countdown, dialogWidth, dialogHeight int, onChoose func(config.ReminderAction, ChecklistState)) error {
+	checklist, completed, err := askChecklist(kind, newChecklistState(reminder.Checklist), dialogWidth, dialogHeight)
+	if err != nil {
+		return err
+	}
+	if !completed {
+		action := config.BuiltInAction(config.ActionClose)
+		if snooze, ok := findAction(visibleActions(reminder), config.ActionSnooze); ok {
+			action = snooze
+		}
+		if onChoose != nil {
+			onChoose(action, checklist)
+		}
+		return nil
+	}
+
+	timer := newCountdown(countdownSetting, reminder, time.Now())
+	command := exec.Command(zenityCommand, zenityArguments(kind, reminder, timer, time.Now(), dialogWidth, dialogHeight)...)
+	output, err := command.Output()
+
+	exitCode := 0
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		exitCode = exitError.ExitCode()
+	} else if err != nil {
+		return err
+	}
+
+	action, ok := zenityResultAction(reminder, timer, exitCode, strings.TrimSpace(string(output)))
+	if !ok {
+		return err
+	}
+
+	if onChoose != nil {
+		onChoose(action, checklist)
+	}
+	return nil
+}
+
+// askChecklistはzenityのチェックリストを、必須の項目がすべてチェックされるまで繰り返し表示します。
+// 項目がない場合は何も表示しません。キャンセルされた場合は completed に false を返します。
+// この関数は副作用（外部プロセスの起動、UIの表示）を持ちます。
+func askChecklist(kind session.EndKind, state ChecklistState, dialogWidth, dialogHeight int) (result ChecklistState, completed bool, err error) {
+	for len(state.Items) > 0 {
+		output, err := exec.Command(zenityCommand, zenityChecklistArguments(kind, state, dialogWidth, dialogHeight)...).Output()
+
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) && exitError.ExitCode() == zenityCancelledExitCode {
+			return state, false, nil
+		} else if err != nil {
+			return state, false, err
+		}
+
+		state = state.withCheckedLabels(strings.Split(strings.TrimSpace(string(output)), zenityListSeparator))
+		if state.Complete() {
+			break
+		}
+	}
+	return state, true, nil
+}
+
+// zenityChecklistArgumentsはチェックリストを表示するzenityの引数を組み立てます。
+// 前回チェックした項目はチェック済みで表示します。
+// この関数は純粋関数です。
+func zenityChecklistArguments(kind session.EndKind, state ChecklistState, dialogWidth, dialogHeight int) []string {
+	arguments := []string{
+		"--list",
+		"--checklist",
+		"--title=" + dialogTitle(kind),
+		"--text=" + config.ChecklistTitle,
+		"--width=" + strconv.Itoa(dialogWidth),
+		"--height=" + strconv.Itoa(dialogHeight),
+		"--separator=" + zenityListSeparator,
+		"--column=" + config.ChecklistCheckColumn,
+		// 出力は項目の表示名（必須の印なし）にするため、非表示の列に表示名を置き、印付きの列を別に表示します。
+		"--column=",
+		"--column=" + config.ChecklistItemColumn,
+		"--hide-column=2",
+		"--print-column=2",
+	}
+	for index, item := range state.Items {
+		checked := "FALSE"
+		if state.Checked[index] {
+			checked = "TRUE"
+		}
+		arguments = append(arguments, checked, item.Label, checklistItemLabel(item))
+	}
+	return arguments
+}
+
+// zenityResultActionはzenityの終了コードと標準出力から選ばれたアクションを返します。
+// 想定外の終了コードの場合は ok に false を返します。
+// この関数は純粋関数です。
+func zenityResultAction(reminder config.Reminder, timer countdown, exitCode int, output string) (action config.ReminderAction, ok bool) {
+	switch exitCode {
+	case 0:
+		return defaultAction(reminder), true
+	case zenityCancelledExitCode:
+		// 追加ボタンはラベルを標準出力に書き出し、キャンセルと同じ終了コードで終了します。
+		for _, extra := range zenityExtraActions(reminder) {
+			if output != "" && output == toZenityMnemonic(buttonLabel(extra)) {
+				return extra, true
+			}
+		}
+		return config.BuiltInAction(config.ActionClose), true
+	case zenityTimeoutExitCode:
+		return timer.action, true
+	}
+	return config.ReminderAction{}, false
+}
+
+// zenityExtraActionsはzenityの追加ボタンとして表示するアクションを返します。
+// OKボタン（Enterキーで選ばれるアクション）とキャンセルボタン（「閉じる」）以外のアクションです。
+// この関数は純粋関数です。
+func zenityExtraActions(reminder config.Reminder) []config.ReminderAction {
+	defaultID := defaultAction(reminder).ID()
+	var extras []config.ReminderAction
+	for _, action := range visibleActions(reminder) {
+		if action.ID() == defaultID || action.Name == config.ActionClose {
+			continue
+		}
+		extras = append(extras, action)
+	}
+	return extras
+}
+
+// zenityArgumentsはzenityに渡す引数を組み立てます。
+// メッセージは設定ファイルやテンプレートの展開結果をそのまま表示するため、--no-markup で
+// Pangoマークアップとして解釈させません（& や < を含むと表示できなくなるため）。
+// この関数は純粋関数です。
+func zenityArguments(kind session.EndKind, reminder config.Reminder, timer countdown, now time.Time, dialogWidth, dialogHeight int) []string {
+	text := reminder.DialogMessage
+	if timer.state != countdownDisabled {
+		text += "\n\n" + timer.message(now)
+	}
+
+	arguments := []string{
+		"--title=" + dialogTitle(kind),
+		"--text=" + text,
+		"--no-markup",
+		"--width=" + strconv.Itoa(dialogWidth),
+		"--height=" + strconv.Itoa(dialogHeight),
+	}
+	if timer.state != countdownDisabled {
+		arguments = append(arguments, "--timeout="+strconv.Itoa(timer.remainingSeconds(now)))
+	}
+
+	for _, extra := range zenityExtraActions(reminder) {
+		arguments = append(arguments, "--extra-button="+toZenityMnemonic(buttonLabel(extra)))
+	}
+
+	primary := defaultAction(reminder)
+	if primary.Name == config.ActionClose {
+		// 「閉じる」がOKボタンを兼ねる（アラートモードなど）
+		return append([]string{"--info", "--ok-label=" + toZenityMnemonic(config.ExitButtonLabel)}, arguments...)
+	}
+
+	return append([]string{
+		"--question",
+		"--ok-label=" + toZenityMnemonic(buttonLabel(primary)),
+		"--cancel-label=" + toZenityMnemonic(config.ExitButtonLabel),
+	}, arguments...)
+}
+
+// toZenityMnemonicはWindows形式のアクセラレータ（&）をGTK形式（_）に変換します。
+// この関数は純粋関数です。
+func toZenityMnemonic(label string) string {
+	return strings.ReplaceAll(label, "&", "_")
+}
+
+// ShowNotificationはzenityの通知で text を表示します。ダイアログと異なり応答を待ちません。
+// 通知は --no-markup に対応しないため、マークアップとして解釈されないよう text をエスケープします。
+// この関数は副作用（外部コマンドの実行）を持ちます。
+func ShowNotification(text string) error {
+	return exec.Command(zenityCommand, "--notification", "--text="+html.EscapeString(text)).Run()
+}
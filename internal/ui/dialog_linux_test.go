@@ -0,0 +1,90 @@
+//go:build linux
+
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+func TestZenityArgumentsDisableMarkup(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{name: "アンパサンド", message: "勤怠 & 日報を登録してください"},
+		{name: "山括弧", message: "<b>退勤</b>を登録してください"},
+		{name: "改行を含む", message: "1行目\n2 < 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := config.DefaultUserConfig().ReminderFor(session.EndKindShutdown)
+			reminder.DialogMessage = tt.message
+
+			arguments := zenityArguments(session.EndKindShutdown, reminder, countdown{state: countdownDisabled}, now, 400, 200)
+			if !slices.Contains(arguments, "--no-markup") {
+				t.Errorf("zenityArguments() = %v, want --no-markup", arguments)
+			}
+			// マークアップとして解釈させないため、メッセージはエスケープせずにそのまま渡します。
+			if !slices.Contains(arguments, "--text="+tt.message) {
+				t.Errorf("zenityArguments() = %v, want --text=%s", arguments, tt.message)
+			}
+		})
+	}
+}
+
+func TestZenityArgumentsButtons(t *testing.T) {
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		actions     []config.ReminderAction
+		wantMode    string
+		wantExtras  int
+		wantOKLabel string
+	}{
+		{
+			name:        "開く・後で・閉じる",
+			actions:     []config.ReminderAction{config.BuiltInAction(config.ActionOpen), config.BuiltInAction(config.ActionSnooze), config.BuiltInAction(config.ActionClose)},
+			wantMode:    "--question",
+			wantExtras:  1,
+			wantOKLabel: toZenityMnemonic(config.OpenButtonLabel),
+		},
+		{
+			name:        "閉じるだけ（アラートモード）",
+			actions:     []config.ReminderAction{config.BuiltInAction(config.ActionClose)},
+			wantMode:    "--info",
+			wantOKLabel: toZenityMnemonic(config.ExitButtonLabel),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminder := config.DefaultUserConfig().ReminderFor(session.EndKindShutdown)
+			reminder.Actions = tt.actions
+
+			arguments := zenityArguments(session.EndKindShutdown, reminder, countdown{state: countdownDisabled}, now, 400, 200)
+			if arguments[0] != tt.wantMode {
+				t.Errorf("mode = %s, want %s", arguments[0], tt.wantMode)
+			}
+			extras := 0
+			for _, argument := range arguments {
+				if strings.HasPrefix(argument, "--extra-button=") {
+					extras++
+				}
+			}
+			if extras != tt.wantExtras {
+				t.Errorf("extra buttons = %d, want %d (%v)", extras, tt.wantExtras, arguments)
+			}
+			if !slices.Contains(arguments, "--ok-label="+tt.wantOKLabel) {
+				t.Errorf("zenityArguments() = %v, want --ok-label=%s", arguments, tt.wantOKLabel)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package ui
+
+import (
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/session"
+)
+
+// dialogTitleは終了理由を付けた確認ダイアログのタイトルを返します。
+// この関数は純粋関数です。
+func dialogTitle(kind session.EndKind) string {
+	return config.DialogTitle + " - " + config.EndKindLabel(kind)
+}
@@ -4,6 +4,7 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/lxn/walk"
 
@@ -11,30 +12,42 @@ import (
 )
 
 // InitNotifyIconは通知アイコンを作成して設定します。
+// 「後で」による再通知を取り消すメニュー項目は、SetSnoozeActionで予定を設定するまで非表示です。
+// 今日の勤務時間を表示するメニュー項目（押せません）はSetWorkTimeで更新します。
+// configPath は使用している設定ファイルのパスで、表示専用のメニュー項目に表示します。
 // この関数は副作用（UI要素の作成）を持ちます。
-func InitNotifyIcon(mainWindow *walk.MainWindow, onTest, onToggleStartup, onExit func(), isStartupRegistered bool) (*walk.NotifyIcon, *walk.Action, error) {
+func InitNotifyIcon(mainWindow *walk.MainWindow, onTest, onToggleStartup, onCancelSnooze, onReload, onExit func(), isStartupRegistered bool, configPath string) (notifyIcon *walk.NotifyIcon, startupAction, snoozeAction, workTimeAction *walk.Action, err error) {
 	// リソースから直接アイコンを読み込む（rsrcで埋め込まれたアイコン）
 	icon, err := walk.NewIconFromResourceId(config.IconResourceID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("アイコンの読み込みに失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("アイコンの読み込みに失敗しました: %w", err)
 	}
 
-	notifyIcon, err := walk.NewNotifyIcon(mainWindow)
+	notifyIcon, err = walk.NewNotifyIcon(mainWindow)
 	if err != nil {
-		return nil, nil, fmt.Errorf("通知アイコンの作成に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("通知アイコンの作成に失敗しました: %w", err)
 	}
 
 	if err := notifyIcon.SetIcon(icon); err != nil {
-		return nil, nil, fmt.Errorf("アイコンの設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("アイコンの設定に失敗しました: %w", err)
 	}
 	if err := notifyIcon.SetToolTip(config.TrayIconTooltip); err != nil {
-		return nil, nil, fmt.Errorf("ツールチップの設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("ツールチップの設定に失敗しました: %w", err)
+	}
+
+	// 今日の勤務時間の表示を作成します（表示専用のため押せません）。
+	workTimeAction = walk.NewAction()
+	if err := workTimeAction.SetText(config.TrayWorkTimeNone); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("勤務時間テキストの設定に失敗しました: %w", err)
+	}
+	if err := workTimeAction.SetEnabled(false); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("勤務時間メニューの設定に失敗しました: %w", err)
 	}
 
 	// テストアクションを作成します（確認ダイアログのテスト用）。
 	testAction := walk.NewAction()
 	if err := testAction.SetText(config.TrayMenuTest); err != nil {
-		return nil, nil, fmt.Errorf("テストテキストの設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("テストテキストの設定に失敗しました: %w", err)
 	}
 	testAction.Triggered().Attach(func() {
 		if onTest != nil {
@@ -43,9 +56,9 @@ func InitNotifyIcon(mainWindow *walk.MainWindow, onTest, onToggleStartup, onExit
 	})
 
 	// スタートアップ登録アクションを作成します。
-	startupAction := walk.NewAction()
+	startupAction = walk.NewAction()
 	if err := startupAction.SetText(config.TrayMenuStartup); err != nil {
-		return nil, nil, fmt.Errorf("スタートアップテキストの設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("スタートアップテキストの設定に失敗しました: %w", err)
 	}
 	startupAction.SetCheckable(true)
 	startupAction.SetChecked(isStartupRegistered)
@@ -55,10 +68,41 @@ func InitNotifyIcon(mainWindow *walk.MainWindow, onTest, onToggleStartup, onExit
 		}
 	})
 
+	// 再通知の取り消しアクションを作成します（予定があるときだけ表示します）。
+	snoozeAction = walk.NewAction()
+	if err := snoozeAction.SetVisible(false); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("再通知メニューの設定に失敗しました: %w", err)
+	}
+	snoozeAction.Triggered().Attach(func() {
+		if onCancelSnooze != nil {
+			onCancelSnooze()
+		}
+	})
+
+	// 使用している設定ファイルの表示を作成します（表示専用のため押せません）。
+	configPathAction := walk.NewAction()
+	if err := configPathAction.SetText(fmt.Sprintf(config.TrayConfigPathFormat, configPath)); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("設定ファイルテキストの設定に失敗しました: %w", err)
+	}
+	if err := configPathAction.SetEnabled(false); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("設定ファイルメニューの設定に失敗しました: %w", err)
+	}
+
+	// 設定の再読み込みアクションを作成します。
+	reloadAction := walk.NewAction()
+	if err := reloadAction.SetText(config.TrayMenuReload); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("再読み込みテキストの設定に失敗しました: %w", err)
+	}
+	reloadAction.Triggered().Attach(func() {
+		if onReload != nil {
+			onReload()
+		}
+	})
+
 	// 終了アクションを作成します。
 	exitAction := walk.NewAction()
 	if err := exitAction.SetText(config.TrayMenuExit); err != nil {
-		return nil, nil, fmt.Errorf("終了テキストの設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("終了テキストの設定に失敗しました: %w", err)
 	}
 	exitAction.Triggered().Attach(func() {
 		if onExit != nil {
@@ -67,19 +111,55 @@ func InitNotifyIcon(mainWindow *walk.MainWindow, onTest, onToggleStartup, onExit
 	})
 
 	// コンテキストメニューにアクションを追加します。
+	if err := notifyIcon.ContextMenu().Actions().Add(workTimeAction); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("勤務時間アクションの追加に失敗しました: %w", err)
+	}
+	if err := notifyIcon.ContextMenu().Actions().Add(walk.NewSeparatorAction()); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("区切り線の追加に失敗しました: %w", err)
+	}
 	if err := notifyIcon.ContextMenu().Actions().Add(testAction); err != nil {
-		return nil, nil, fmt.Errorf("テストアクションの追加に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("テストアクションの追加に失敗しました: %w", err)
 	}
 	if err := notifyIcon.ContextMenu().Actions().Add(startupAction); err != nil {
-		return nil, nil, fmt.Errorf("スタートアップアクションの追加に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("スタートアップアクションの追加に失敗しました: %w", err)
+	}
+	if err := notifyIcon.ContextMenu().Actions().Add(snoozeAction); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("再通知アクションの追加に失敗しました: %w", err)
+	}
+	if err := notifyIcon.ContextMenu().Actions().Add(configPathAction); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("設定ファイルアクションの追加に失敗しました: %w", err)
+	}
+	if err := notifyIcon.ContextMenu().Actions().Add(reloadAction); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("再読み込みアクションの追加に失敗しました: %w", err)
 	}
 	if err := notifyIcon.ContextMenu().Actions().Add(exitAction); err != nil {
-		return nil, nil, fmt.Errorf("終了アクションの追加に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("終了アクションの追加に失敗しました: %w", err)
 	}
 
 	if err := notifyIcon.SetVisible(true); err != nil {
-		return nil, nil, fmt.Errorf("表示設定に失敗しました: %w", err)
+		return nil, nil, nil, nil, fmt.Errorf("表示設定に失敗しました: %w", err)
 	}
 
-	return notifyIcon, startupAction, nil
+	return notifyIcon, startupAction, snoozeAction, workTimeAction, nil
+}
+
+// SetSnoozeActionは「後で」による再通知の予定をトレイメニューに反映します。
+// pending が false の場合はメニュー項目を非表示にします。
+// この関数は副作用（UIの更新）を持ちます。
+func SetSnoozeAction(snoozeAction *walk.Action, due time.Time, pending bool) error {
+	if pending {
+		if err := snoozeAction.SetText(fmt.Sprintf(config.TrayMenuSnoozeFormat, due.Format("15:04"))); err != nil {
+			return err
+		}
+	}
+	return snoozeAction.SetVisible(pending)
+}
+
+// SetWorkTimeは今日の勤務時間をトレイメニューとツールチップに反映します。
+// この関数は副作用（UIの更新）を持ちます。
+func SetWorkTime(notifyIcon *walk.NotifyIcon, workTimeAction *walk.Action, summary string) error {
+	if err := workTimeAction.SetText(summary); err != nil {
+		return err
+	}
+	return notifyIcon.SetToolTip(config.TrayIconTooltip + "\n" + summary)
 }
@@ -3,24 +3,33 @@
 package win32
 
 import (
+	"fmt"
+	"os"
 	"syscall"
 	"unsafe"
 
 	"github.com/lxn/win"
+	"golang.org/x/sys/windows"
 )
 
 // Windows API
 var (
-	kernel32         = syscall.NewLazyDLL("kernel32.dll")
-	procCreateMutexW = kernel32.NewProc("CreateMutexW")
-	procReleaseMutex = kernel32.NewProc("ReleaseMutex")
-	procCloseHandle  = kernel32.NewProc("CloseHandle")
+	kernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procCreateMutexW  = kernel32.NewProc("CreateMutexW")
+	procReleaseMutex  = kernel32.NewProc("ReleaseMutex")
+	procCloseHandle   = kernel32.NewProc("CloseHandle")
+	procAttachConsole = kernel32.NewProc("AttachConsole")
 
 	user32                         = syscall.NewLazyDLL("user32.dll")
 	procShutdownBlockReasonCreate  = user32.NewProc("ShutdownBlockReasonCreate")
 	procShutdownBlockReasonDestroy = user32.NewProc("ShutdownBlockReasonDestroy")
 	procPostMessageW               = user32.NewProc("PostMessageW")
 	procSetForegroundWindow        = user32.NewProc("SetForegroundWindow")
+	procExitWindowsEx              = user32.NewProc("ExitWindowsEx")
+
+	wtsapi32                             = syscall.NewLazyDLL("wtsapi32.dll")
+	procWTSRegisterSessionNotification   = wtsapi32.NewProc("WTSRegisterSessionNotification")
+	procWTSUnRegisterSessionNotification = wtsapi32.NewProc("WTSUnRegisterSessionNotification")
 )
 
 // ShutdownBlockReasonCreateはシャットダウンをブロックする理由を設定します。
@@ -48,12 +57,67 @@ func SetForegroundWindow(hwnd win.HWND) {
 	_, _, _ = procSetForegroundWindow.Call(uintptr(hwnd))
 }
 
+// ExitWindowsExはログオフ・シャットダウン・再起動を開始します。
+// シャットダウンと再起動には事前に EnableShutdownPrivilege を呼び出す必要があります。
+// この関数は副作用（Win32 API呼び出し）を持ちます。
+func ExitWindowsEx(flags, reason uint32) error {
+	ret, _, err := procExitWindowsEx.Call(uintptr(flags), uintptr(reason))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// WTSRegisterSessionNotificationはウィンドウにセッション状態の変化（WM_WTSSESSION_CHANGE）を通知するよう登録します。
+// この関数は副作用（Win32 API呼び出し）を持ちます。
+func WTSRegisterSessionNotification(hwnd win.HWND, flags uint32) error {
+	ret, _, err := procWTSRegisterSessionNotification.Call(uintptr(hwnd), uintptr(flags))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// WTSUnRegisterSessionNotificationはセッション状態の変化の通知の登録を解除します。
+// この関数は副作用（Win32 API呼び出し）を持ちます。
+func WTSUnRegisterSessionNotification(hwnd win.HWND) error {
+	ret, _, err := procWTSUnRegisterSessionNotification.Call(uintptr(hwnd))
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// EnableShutdownPrivilegeは現在のプロセスのトークンでシャットダウン特権を有効にします。
+// この関数は副作用（Win32 API呼び出し）を持ちます。
+func EnableShutdownPrivilege() error {
+	var token windows.Token
+	err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token)
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+
+	var luid windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr(SE_SHUTDOWN_NAME), &luid); err != nil {
+		return err
+	}
+
+	privileges := windows.Tokenprivileges{PrivilegeCount: 1}
+	privileges.Privileges[0] = windows.LUIDAndAttributes{Luid: luid, Attributes: windows.SE_PRIVILEGE_ENABLED}
+	return windows.AdjustTokenPrivileges(token, false, &privileges, 0, nil, nil)
+}
+
 // ShellExecuteはデフォルトのアプリケーションを使用してファイルまたはURLを開きます。
+// 開けなかった場合はエラーを返します。
 // この関数は副作用（Win32 API呼び出し）を持ちます。
-func ShellExecute(hwnd win.HWND, url string) {
+func ShellExecute(hwnd win.HWND, url string) error {
 	verb, _ := syscall.UTF16PtrFromString("open")
 	file, _ := syscall.UTF16PtrFromString(url)
-	win.ShellExecute(hwnd, verb, file, nil, nil, SW_SHOWNORMAL)
+	if !win.ShellExecute(hwnd, verb, file, nil, nil, SW_SHOWNORMAL) {
+		return fmt.Errorf("ShellExecute に失敗しました: %s", url)
+	}
+	return nil
 }
 
 // CreateMutexは名前付きミューテックスを作成します。
@@ -100,3 +164,22 @@ func CloseHandle(handle syscall.Handle) error {
 	}
 	return nil
 }
+
+// AttachParentConsoleは親プロセス（コマンドプロンプトなど）のコンソールに接続し、
+// 標準出力と標準エラー出力をそのコンソールに向けます。
+// GUIアプリケーションとしてビルドした実行ファイルからサブコマンドの結果を表示するために使用します。
+// この関数は副作用（Win32 API呼び出し、os.Stdout・os.Stderrの差し替え）を持ちます。
+func AttachParentConsole() error {
+	ret, _, err := procAttachConsole.Call(uintptr(ATTACH_PARENT_PROCESS))
+	if ret == 0 {
+		return err
+	}
+
+	console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	os.Stdout = console
+	os.Stderr = console
+	return nil
+}
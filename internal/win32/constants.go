@@ -4,12 +4,54 @@ package win32
 
 // Win32メッセージ定数
 const (
-	WM_QUERYENDSESSION = 0x0011      // セッション終了の問い合わせ
-	WM_ENDSESSION      = 0x0016      // セッション終了
-	WM_USER            = 0x0400      // ユーザー定義メッセージの開始
-	WM_SHOW_DIALOG     = WM_USER + 1 // ダイアログ表示用のカスタムメッセージ
+	WM_QUERYENDSESSION   = 0x0011      // セッション終了の問い合わせ
+	WM_ENDSESSION        = 0x0016      // セッション終了
+	WM_POWERBROADCAST    = 0x0218      // 電源状態の変化
+	WM_WTSSESSION_CHANGE = 0x02B1      // セッション状態の変化（ロックなど）
+	WM_USER              = 0x0400      // ユーザー定義メッセージの開始
+	WM_SHOW_DIALOG       = WM_USER + 1 // ダイアログ表示用のカスタムメッセージ
+	WM_SHOW_NOTICE       = WM_USER + 2 // ロック・スリープの通知用のカスタムメッセージ
 )
 
+// WM_WTSSESSION_CHANGE の wParam
+const (
+	WTS_SESSION_LOCK = 0x7 // セッションがロックされた
+)
+
+// WM_POWERBROADCAST の wParam
+const (
+	PBT_APMSUSPEND = 0x4 // スリープ・休止状態に入る
+)
+
+// WTSRegisterSessionNotification のフラグ
+const (
+	NOTIFY_FOR_THIS_SESSION = 0 // 自分のセッションの通知だけを受け取る
+)
+
+// WM_QUERYENDSESSION / WM_ENDSESSION の lParam フラグ
+const (
+	ENDSESSION_CLOSEAPP = 0x00000001 // インストーラー等による再起動のためにアプリを閉じる
+	ENDSESSION_CRITICAL = 0x40000000 // 強制終了（ブロックできない）
+	ENDSESSION_LOGOFF   = 0x80000000 // ログオフ
+)
+
+// ExitWindowsEx のフラグ
+const (
+	EWX_LOGOFF   = 0x00000000 // ログオフ
+	EWX_SHUTDOWN = 0x00000001 // シャットダウン
+	EWX_REBOOT   = 0x00000002 // 再起動
+	EWX_POWEROFF = 0x00000008 // シャットダウンして電源を切る
+)
+
+// ExitWindowsEx の終了理由（計画的なアプリケーション操作）
+const (
+	SHTDN_REASON_MAJOR_APPLICATION = 0x00040000
+	SHTDN_REASON_FLAG_PLANNED      = 0x80000000
+)
+
+// SE_SHUTDOWN_NAMEはシャットダウン／再起動に必要な特権の名前です。
+const SE_SHUTDOWN_NAME = "SeShutdownPrivilege"
+
 // ShellExecute定数
 const (
 	SW_SHOWNORMAL = 1 // ウィンドウを通常表示
@@ -19,3 +61,8 @@ const (
 const (
 	ERROR_ALREADY_EXISTS = 183 // 既に存在するエラーコード
 )
+
+// AttachConsole定数
+const (
+	ATTACH_PARENT_PROCESS = ^uint32(0) // 親プロセスのコンソール（(DWORD)-1）
+)
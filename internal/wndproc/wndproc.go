@@ -0,0 +1,96 @@
+//go:build windows
+
+// このパッケージはメインウィンドウの WndProc（Window Procedure）を差し替え、
+// walk の公開APIからは受け取れないウィンドウメッセージを Go 側のハンドラへ配送する。
+//
+// 【WndProc（Window Procedure）とは】
+//
+// Windows の GUI はすべて「メッセージ駆動」で動作している。
+// 各ウィンドウは WndProc と呼ばれるコールバック関数を持ち、
+//
+//   - マウスクリック
+//   - キー入力
+//   - 再描画
+//   - シャットダウン通知
+//
+// といった OS からのイベントはすべて WndProc に送られる。
+//
+// つまり WndProc は
+//
+//	「Windows → アプリケーション の唯一の入口」
+//
+// になっている低レベル関数である。
+//
+// 通常 walk が内部で WndProc を管理しているが、
+// WM_QUERYENDSESSION（シャットダウン開始通知）などは walk の公開APIからは捕捉できないため、
+// 本アプリでは Win32 API を使って WndProc を差し替え、登録された MessageHandler に
+// 順番にメッセージを渡している。どのハンドラも処理しなかったメッセージは元の WndProc に委譲する。
+//
+// 【技術的制約】
+//
+//   - Win32 のコールバックは Go のクロージャやメソッドを保持できないため、
+//     登録されたハンドラはグローバル変数 handlers に保持している。
+//   - origWndProc には元の walk の WndProc を保存し、未処理メッセージは必ず委譲する。
+//
+// この構造は Win32 API の制約によるものであり、意図的にこのパッケージへ隔離している。
+package wndproc
+
+import (
+	"syscall"
+
+	"github.com/lxn/win"
+)
+
+// MessageHandler はウィンドウメッセージを処理します。
+// handled に true を返した場合、result が WndProc の戻り値になり、元の WndProc は呼ばれません。
+type MessageHandler interface {
+	HandleMessage(hwnd win.HWND, msg uint32, wParam, lParam uintptr) (result uintptr, handled bool)
+}
+
+// handlersはWndProcコールバックから呼び出すハンドラを保持します。
+// これは、WindowsコールバックがGoのクロージャをキャプチャできないために必要です。
+// これはWin32 APIの制約であり、回避できません。
+var handlers []MessageHandler
+
+// origWndProcは元のウィンドウプロシージャを保持します。
+var origWndProc uintptr
+
+// wndProcCallbackは登録されたハンドラにメッセージを配送するカスタムウィンドウプロシージャです。
+// この関数は副作用（Win32メッセージ処理）を持ちます。
+func wndProcCallback(hwnd win.HWND, msg uint32, wParam, lParam uintptr) uintptr {
+	for _, handler := range handlers {
+		if result, handled := handler.HandleMessage(hwnd, msg, wParam, lParam); handled {
+			return result
+		}
+	}
+
+	// 元のウィンドウプロシージャを呼び出します。
+	return win.CallWindowProc(origWndProc, hwnd, msg, wParam, lParam)
+}
+
+// Addはメッセージハンドラを登録し、必要であればカスタムウィンドウプロシージャをインストールします。
+// この関数は副作用（ウィンドウプロシージャの変更）を持ちます。
+func Add(hwnd win.HWND, handler MessageHandler) {
+	if origWndProc == 0 {
+		origWndProc = win.SetWindowLongPtr(hwnd, win.GWLP_WNDPROC, syscall.NewCallback(wndProcCallback))
+	}
+	handlers = append(handlers, handler)
+}
+
+// Removeはメッセージハンドラの登録を解除します。
+// ハンドラがすべて解除されると元のウィンドウプロシージャに戻します。
+// この関数は副作用（ウィンドウプロシージャの変更）を持ちます。
+func Remove(hwnd win.HWND, handler MessageHandler) {
+	remaining := handlers[:0]
+	for _, registered := range handlers {
+		if registered != handler {
+			remaining = append(remaining, registered)
+		}
+	}
+	handlers = remaining
+
+	if len(handlers) == 0 && origWndProc != 0 {
+		win.SetWindowLongPtr(hwnd, win.GWLP_WNDPROC, origWndProc)
+		origWndProc = 0
+	}
+}
@@ -0,0 +1,77 @@
+package worktime
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+)
+
+// ReportRow は勤怠レポートの1日分の行です。
+// 人事システムへの転記を想定し、時刻は文字列で、所要時間は分単位の数値でも出力します。
+type ReportRow struct {
+	Date            string `json:"date"`
+	FirstSeen       string `json:"first_seen"`
+	LastSeen        string `json:"last_seen"`
+	Duration        string `json:"duration"`
+	DurationMinutes int    `json:"duration_minutes"`
+	URLOpened       bool   `json:"url_opened"`
+}
+
+// reportHeader はCSVの見出し行です（ReportRow のJSONの名前と同じ順）。
+var reportHeader = []string{"date", "first_seen", "last_seen", "duration", "duration_minutes", "url_opened"}
+
+// Report は from の日から to の日まで（両端を含む）の記録がある日について、レポートの行を日付順に返します。
+// 日付は loc で区切ります。
+// この関数は純粋関数です。
+func Report(records []history.Record, from, to time.Time, loc *time.Location) []ReportRow {
+	days := Between(Days(records, loc), from.In(loc), to.In(loc))
+	rows := make([]ReportRow, 0, len(days))
+	for _, day := range days {
+		rows = append(rows, ReportRow{
+			Date:            day.Date.Format(config.TemplateDateFormat),
+			FirstSeen:       day.Start.Format(config.TemplateTimeFormat),
+			LastSeen:        day.End.Format(config.TemplateTimeFormat),
+			Duration:        config.FormatElapsed(day.Duration()),
+			DurationMinutes: int(day.Duration() / time.Minute),
+			URLOpened:       day.Opened,
+		})
+	}
+	return rows
+}
+
+// WriteCSV はレポートを見出し行付きのCSVとして書き出します。
+// この関数は副作用（w への書き込み）を持ちます。
+func WriteCSV(w io.Writer, rows []ReportRow) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(reportHeader); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Date,
+			row.FirstSeen,
+			row.LastSeen,
+			row.Duration,
+			strconv.Itoa(row.DurationMinutes),
+			strconv.FormatBool(row.URLOpened),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON はレポートをJSONの配列として書き出します。
+// この関数は副作用（w への書き込み）を持ちます。
+func WriteJSON(w io.Writer, rows []ReportRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
@@ -0,0 +1,114 @@
+package worktime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"shutdown-alert/internal/history"
+)
+
+// update が指定された場合は、期待する出力（testdata のゴールデンファイル）を書き直します。
+var update = flag.Bool("update", false, "testdata のゴールデンファイルを更新します")
+
+// jst はテストで日付を区切るタイムゾーンです（実行環境のタイムゾーンに依存しないようにします）。
+var jst = time.FixedZone("JST", 9*60*60)
+
+// readHistory は testdata の履歴ファイルを読み込みます。
+func readHistory(t *testing.T, name string) []history.Record {
+	t.Helper()
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []history.Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record history.Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("%s: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+// compareGolden は got を testdata のゴールデンファイルと比較します。
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s と一致しません\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestReportGolden(t *testing.T) {
+	records := readHistory(t, "history.jsonl")
+
+	tests := []struct {
+		name   string
+		from   time.Time
+		to     time.Time
+		write  func(io.Writer, []ReportRow) error
+		golden string
+	}{
+		{
+			name:   "CSV",
+			from:   time.Date(2026, 10, 13, 0, 0, 0, 0, jst),
+			to:     time.Date(2026, 10, 19, 0, 0, 0, 0, jst),
+			write:  WriteCSV,
+			golden: "report.csv",
+		},
+		{
+			name:   "JSON",
+			from:   time.Date(2026, 10, 13, 0, 0, 0, 0, jst),
+			to:     time.Date(2026, 10, 19, 0, 0, 0, 0, jst),
+			write:  WriteJSON,
+			golden: "report.json",
+		},
+		{
+			name:   "期間外の日を除く",
+			from:   time.Date(2026, 10, 14, 0, 0, 0, 0, jst),
+			to:     time.Date(2026, 10, 15, 23, 59, 0, 0, jst),
+			write:  WriteCSV,
+			golden: "report_range.csv",
+		},
+		{
+			name:   "記録のない期間",
+			from:   time.Date(2026, 10, 1, 0, 0, 0, 0, jst),
+			to:     time.Date(2026, 10, 7, 0, 0, 0, 0, jst),
+			write:  WriteJSON,
+			golden: "report_empty.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := tt.write(&output, Report(records, tt.from, tt.to, jst)); err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, tt.golden, output.Bytes())
+		})
+	}
+}
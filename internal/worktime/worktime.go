@@ -0,0 +1,139 @@
+// このパッケージは履歴のレコードから1日ごとの勤務時間を求める。
+//
+// 1日の勤務時間は、その日の最初の記録（セッションの開始など）から最後の記録
+// （セッション終了の問い合わせや応答など）までとする。
+// すべて純粋関数で、現在時刻とタイムゾーンは引数で受け取る。
+package worktime
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/history"
+)
+
+// Day は1日分の勤務時間を表します。
+type Day struct {
+	// Date はその日の0時です。
+	Date time.Time
+	// Start はその日の最初の記録の時刻です。
+	Start time.Time
+	// End はその日の最後の記録の時刻です（今日の場合は現在時刻）。
+	End time.Time
+	// Opened はその日にダイアログからURLを開いたかどうかです。
+	Opened bool
+}
+
+// Duration は開始から終了までの時間を返します。
+// この関数は純粋関数です。
+func (day Day) Duration() time.Duration {
+	return day.End.Sub(day.Start)
+}
+
+// Days はレコードを loc での日付ごとにまとめ、日付の古い順に返します。
+// 時刻を読み取れないレコードは無視します。
+// この関数は純粋関数です。
+func Days(records []history.Record, loc *time.Location) []Day {
+	// time.Time をキーにすると内部表現の違いで別の日とみなされることがあるため、日付の文字列をキーにします。
+	byDate := map[string]Day{}
+	for _, record := range records {
+		timestamp, err := record.Time()
+		if err != nil {
+			continue
+		}
+		timestamp = timestamp.In(loc)
+		date := startOfDay(timestamp)
+
+		key := date.Format(config.TemplateDateFormat)
+		day, ok := byDate[key]
+		if !ok {
+			day = Day{Date: date, Start: timestamp, End: timestamp}
+		}
+		if timestamp.Before(day.Start) {
+			day.Start = timestamp
+		}
+		if timestamp.After(day.End) {
+			day.End = timestamp
+		}
+		day.Opened = day.Opened || record.Opened
+		byDate[key] = day
+	}
+
+	days := make([]Day, 0, len(byDate))
+	for _, day := range byDate {
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days
+}
+
+// Today は now の日の勤務時間を返します。勤務中とみなし、終了は now です。
+// その日の記録がない場合は false を返します。
+// この関数は純粋関数です。
+func Today(records []history.Record, now time.Time) (Day, bool) {
+	date := startOfDay(now)
+	for _, day := range Days(records, now.Location()) {
+		if day.Date.Equal(date) {
+			day.End = now
+			return day, true
+		}
+	}
+	return Day{}, false
+}
+
+// Since は from の日以降の勤務時間だけを返します。
+// この関数は純粋関数です。
+func Since(days []Day, from time.Time) []Day {
+	date := startOfDay(from)
+	var result []Day
+	for _, day := range days {
+		if !day.Date.Before(date) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// Between は from の日から to の日まで（両端を含む）の勤務時間だけを返します。
+// この関数は純粋関数です。
+func Between(days []Day, from, to time.Time) []Day {
+	last := startOfDay(to)
+	var result []Day
+	for _, day := range Since(days, from) {
+		if !day.Date.After(last) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+// TraySummary はトレイに表示する今日の勤務時間（例: 今日: 8h12m（09:03 から））を返します。
+// この関数は純粋関数です。
+func TraySummary(records []history.Record, now time.Time) string {
+	day, ok := Today(records, now)
+	if !ok {
+		return config.TrayWorkTimeNone
+	}
+	return fmt.Sprintf(config.TrayWorkTimeFormat, config.FormatElapsed(day.Duration()), day.Start.Format(config.TemplateTimeFormat))
+}
+
+// Line はworktimeサブコマンドで出力する1日分の行を返します。
+// この関数は純粋関数です。
+func (day Day) Line() string {
+	return fmt.Sprintf(config.WorkTimeLineFormat,
+		day.Date.Format(config.TemplateDateFormat),
+		config.WeekdayLabel(day.Date.Weekday()),
+		day.Start.Format(config.TemplateTimeFormat),
+		day.End.Format(config.TemplateTimeFormat),
+		config.FormatElapsed(day.Duration()),
+	)
+}
+
+// startOfDay は t の日の0時を返します（タイムゾーンは t のものを使います）。
+// この関数は純粋関数です。
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -3,24 +3,46 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"syscall"
 
 	"github.com/lxn/win"
 
 	"shutdown-alert/internal/app"
+	"shutdown-alert/internal/cli"
 	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/ipc"
 	"shutdown-alert/internal/mutex"
+	"shutdown-alert/internal/win32"
 )
 
 func main() {
-	// 設定ファイルを読み込み
-	userConfig, err := config.LoadUserConfig("config.yaml")
+	// --config 以外の引数がある場合はGUIを起動せずにサブコマンドを実行
+	configFlag := ""
+	if len(os.Args) > 1 {
+		// GUIアプリケーションとしてビルドしているため、呼び出し元のコンソールに出力を向けます。
+		// コンソールアプリケーションとしてビルドした場合は失敗しますが、そのまま標準出力を使います。
+		_ = win32.AttachParentConsole()
+		flagValue, args, err := cli.ParseGlobalFlags(os.Args[1:], os.Stderr)
+		if err != nil {
+			os.Exit(cli.ExitUsage)
+		}
+		if len(args) > 0 {
+			os.Exit(cli.Run(args, flagValue, os.Stdout, os.Stderr))
+		}
+		configFlag = flagValue
+	}
+
+	// 設定ファイルを探し、管理者の設定と重ねて読み込み
+	configPath := config.DiscoverConfig(configFlag).Path
+	layered, err := config.LoadConfig(configPath)
 	if err != nil {
-		// パース失敗時はエラーメッセージを表示
-		log.Printf("設定ファイルの読み込みに失敗しました（デフォルト値を使用）: %v", err)
-		errorMessage := fmt.Sprintf("設定ファイルの読み込みに失敗しました。\nデフォルト値を使用します。\n\nエラー: %v", err)
+		// 問題がある場合はすべての問題（ファイル・行・列）を表示
+		log.Printf("設定ファイルの読み込みに失敗しました（問題のある項目はデフォルト値を使用）: %v", err)
+		errorMessage := fmt.Sprintf("設定ファイルの読み込みに失敗しました。\n問題のある項目はデフォルト値を使用します。\n\n%v", err)
 		message, _ := syscall.UTF16PtrFromString(errorMessage)
 		title, _ := syscall.UTF16PtrFromString("設定エラー")
 		win.MessageBox(0, message, title, win.MB_OK|win.MB_ICONWARNING)
@@ -31,10 +53,8 @@ func main() {
 	// プロセス間で排他制御を行い、既に起動している場合はエラーを返す
 	appMutex, err := mutex.Acquire()
 	if err != nil {
-		// 既に起動している場合、GUIモードでもエラーメッセージを表示
-		message, _ := syscall.UTF16PtrFromString("アプリケーションは既に起動しています。")
-		title, _ := syscall.UTF16PtrFromString(config.DialogTitle)
-		win.MessageBox(0, message, title, win.MB_OK|win.MB_ICONINFORMATION)
+		// 既に起動している場合は、起動中のインスタンスに確認ダイアログの表示を依頼する
+		forwardToRunningInstance()
 		return
 	}
 	// Mutex はOS,リソースハンドルなので必ず解放しないといけない。
@@ -46,8 +66,26 @@ func main() {
 	}()
 
 	// アプリケーションを実行
-	a := app.NewApp(userConfig)
+	a := app.NewApp(layered.Config, configPath)
 	if err := a.Run(); err != nil {
 		log.Fatalf("アプリケーションの実行に失敗しました: %v", err)
 	}
 }
+
+// forwardToRunningInstanceは起動中のインスタンスに確認ダイアログの表示（show コマンド）を依頼します。
+// 依頼できなかった場合（起動中のインスタンスが待ち受けを始める前など）は、既に起動していることを表示します。
+// この関数は副作用（通信、UIの表示）を持ちます。
+func forwardToRunningInstance() {
+	response, err := ipc.Send(ipc.Request{Command: ipc.CommandShow})
+	if err == nil && response.OK {
+		return
+	}
+	if err == nil {
+		err = errors.New(response.Error)
+	}
+	log.Printf("起動中のインスタンスに確認ダイアログの表示を依頼できませんでした: %v", err)
+
+	message, _ := syscall.UTF16PtrFromString("アプリケーションは既に起動しています。")
+	title, _ := syscall.UTF16PtrFromString(config.DialogTitle)
+	win.MessageBox(0, message, title, win.MB_OK|win.MB_ICONINFORMATION)
+}
@@ -0,0 +1,44 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"shutdown-alert/internal/app"
+	"shutdown-alert/internal/cli"
+	"shutdown-alert/internal/config"
+	"shutdown-alert/internal/ipc"
+)
+
+func main() {
+	// --config 以外の引数がある場合はアプリケーションを起動せずにサブコマンドを実行
+	configFlag, args, err := cli.ParseGlobalFlags(os.Args[1:], os.Stderr)
+	if err != nil {
+		os.Exit(cli.ExitUsage)
+	}
+	if len(args) > 0 {
+		os.Exit(cli.Run(args, configFlag, os.Stdout, os.Stderr))
+	}
+
+	// 設定ファイルを探し、管理者の設定と重ねて読み込み
+	configPath := config.DiscoverConfig(configFlag).Path
+	layered, err := config.LoadConfig(configPath)
+	if err != nil {
+		// Linux版はダイアログを出さずにログのみ出力してデフォルト値で続行
+		log.Printf("設定ファイルの読み込みに失敗しました（問題のある項目はデフォルト値を使用）:\n%v", err)
+	}
+
+	// アプリケーションを実行
+	a := app.NewApp(layered.Config, configPath)
+	err = a.Run()
+	if errors.Is(err, ipc.ErrAlreadyRunning) {
+		// 既に起動している場合は、起動中のインスタンスに確認ダイアログの表示を依頼
+		os.Exit(cli.Forward(ipc.CommandShow, os.Stdout, os.Stderr))
+	}
+	if err != nil {
+		log.Fatalf("アプリケーションの実行に失敗しました: %v", err)
+	}
+}